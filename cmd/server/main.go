@@ -15,6 +15,11 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	_ "github.com/lucheng0127/courier/internal/adapter/anthropic"
+	_ "github.com/lucheng0127/courier/internal/adapter/azure"
+	_ "github.com/lucheng0127/courier/internal/adapter/bedrock"
+	_ "github.com/lucheng0127/courier/internal/adapter/gemini"
+	_ "github.com/lucheng0127/courier/internal/adapter/ollama"
 	_ "github.com/lucheng0127/courier/internal/adapter/openai"
 	_ "github.com/lucheng0127/courier/internal/adapter/vllm"
 	"github.com/lucheng0127/courier/internal/bootstrap"
@@ -72,6 +77,10 @@ func main() {
 	providerRepo := repository.NewProviderRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	usageRepo := repository.NewUsageRepository(db)
+	modelAliasRepo := repository.NewModelAliasRepository(db)
+	responseCacheRepo := repository.NewResponseCacheRepository(db)
+	semanticCacheRepo := repository.NewSemanticCacheRepository(db)
+	rateLimitRepo := repository.NewRateLimitRepository(db)
 
 	// 5. 初始化 Service
 	jwtSvc, err := service.NewJWTService()
@@ -84,6 +93,31 @@ func main() {
 	authSvc := service.NewAuthService(userRepo, jwtSvc)
 	usageSvc := service.NewUsageService(usageRepo, userRepo)
 	routerSvc := service.NewRouterService()
+	providerSvc.SetBreakers(routerSvc.Breakers())
+	modelAliasSvc := service.NewModelAliasService(modelAliasRepo, providerRepo, routerSvc)
+	rateLimitBackend, err := service.NewRateLimitBackend(service.RateLimitBackendConfigFromEnv(), rateLimitRepo)
+	if err != nil {
+		logger.L.Fatal("Failed to initialize rate limit backend",
+			zap.Error(err))
+	}
+	rateLimitSvc := service.NewRateLimitService(service.RateLimitConfigFromEnv(), userRepo, rateLimitBackend)
+	responseCache, err := service.NewResponseCache(service.ResponseCacheConfigFromEnv(), responseCacheRepo)
+	if err != nil {
+		logger.L.Fatal("Failed to initialize response cache",
+			zap.Error(err))
+	}
+	semanticCacheCfg := service.SemanticCacheConfigFromEnv()
+	semanticCache, err := service.NewSemanticCache(semanticCacheCfg, semanticCacheRepo)
+	if err != nil {
+		logger.L.Fatal("Failed to initialize semantic cache",
+			zap.Error(err))
+	}
+	if semanticCache != nil && semanticCacheCfg.Backend == service.SemanticCacheBackendPgvector {
+		if err := semanticCacheRepo.EnsureSchema(context.Background()); err != nil {
+			logger.L.Fatal("Failed to prepare semantic cache table",
+				zap.Error(err))
+		}
+	}
 
 	// 6. 确保存在初始管理员用户
 	if err := authSvc.EnsureInitialAdmin(context.Background()); err != nil {
@@ -98,11 +132,23 @@ func main() {
 			zap.Error(err))
 	}
 
+	// 加载模型别名
+	if err := modelAliasSvc.LoadAliases(ctx); err != nil {
+		logger.L.Warn("Failed to load model aliases",
+			zap.Error(err))
+	}
+
+	// 启动 Provider 主动健康检查
+	healthChecker := service.NewHealthChecker()
+	routerSvc.SetHealthChecker(healthChecker)
+	healthCtx, stopHealthCheck := context.WithCancel(ctx)
+	healthChecker.Start(healthCtx)
+
 	// 8. 创建路由
 	router := gin.Default()
 
 	// 设置路由
-	setupRoutes(router, providerSvc, authSvc, usageSvc, routerSvc, jwtSvc)
+	setupRoutes(router, providerSvc, authSvc, usageSvc, routerSvc, modelAliasSvc, healthChecker, responseCache, semanticCache, rateLimitSvc, rateLimitBackend, jwtSvc)
 
 	// 9. 启动服务器
 	addr := ":8080"
@@ -133,6 +179,9 @@ func main() {
 
 	logger.L.Info("Shutting down...")
 
+	// 停止健康检查
+	stopHealthCheck()
+
 	// 关闭 Usage Service
 	if err := usageSvc.Close(); err != nil {
 		logger.L.Error("Failed to close usage service",
@@ -151,12 +200,13 @@ func main() {
 }
 
 // setupRoutes 设置所有路由
-func setupRoutes(router *gin.Engine, providerSvc *service.ProviderService, authSvc *service.AuthService, usageSvc *service.UsageService, routerSvc *service.RouterService, jwtSvc service.JWTService) {
+func setupRoutes(router *gin.Engine, providerSvc *service.ProviderService, authSvc *service.AuthService, usageSvc *service.UsageService, routerSvc *service.RouterService, modelAliasSvc *service.ModelAliasService, healthChecker *service.HealthChecker, responseCache service.ResponseCache, semanticCache *service.SemanticCache, rateLimitSvc *service.RateLimitService, rateLimitBackend service.RateLimitBackend, jwtSvc service.JWTService) {
 	// API v1 组（管理接口）
 	api := router.Group("/api/v1")
 
 	// ========== 认证接口（无需鉴权） ==========
 	authCtrl := controller.NewAuthController(authSvc)
+	authCtrl.SetRateLimitBackend(rateLimitBackend)
 	authCtrl.RegisterRoutes(api)
 
 	// ========== 需要 JWT 鉴权的组 ==========
@@ -169,6 +219,7 @@ func setupRoutes(router *gin.Engine, providerSvc *service.ProviderService, authS
 
 	// Provider 管理操作（仅管理员）
 	providerCtrl := controller.NewProviderController(providerSvc)
+	providerCtrl.SetRouterService(routerSvc)
 	adminOnly.POST("/providers", providerCtrl.CreateProvider)
 	adminOnly.PUT("/providers/:name", providerCtrl.UpdateProvider)
 	adminOnly.DELETE("/providers/:name", providerCtrl.DeleteProvider)
@@ -178,6 +229,18 @@ func setupRoutes(router *gin.Engine, providerSvc *service.ProviderService, authS
 	reloadCtrl := controller.NewProviderReloadController(providerSvc)
 	reloadCtrl.RegisterRoutes(adminOnly)
 
+	// Provider 健康检查（仅管理员）
+	healthCtrl := controller.NewProviderHealthController(healthChecker)
+	healthCtrl.RegisterRoutes(adminOnly)
+
+	// 模型别名管理（仅管理员）
+	modelAliasCtrl := controller.NewModelAliasController(modelAliasSvc)
+	modelAliasCtrl.RegisterRoutes(adminOnly)
+
+	// 用户和 API Key 速率限制（仅管理员）
+	rateLimitCtrl := controller.NewRateLimitController(rateLimitSvc)
+	rateLimitCtrl.RegisterRoutes(adminOnly)
+
 	// ========== Provider 查询操作（所有认证用户）==========
 	jwtAuth.GET("/providers", providerCtrl.ListProviders)
 	jwtAuth.GET("/providers/:name/models", providerCtrl.ListProviderModels)
@@ -195,7 +258,14 @@ func setupRoutes(router *gin.Engine, providerSvc *service.ProviderService, authS
 	// ========== Chat API（支持 JWT 和 API Key 双重鉴权） ==========
 	v1 := router.Group("/v1")
 	chatCtrl := controller.NewChatController(routerSvc, usageSvc)
+	chatCtrl.SetResponseCache(responseCache)
+	chatCtrl.SetSemanticCache(semanticCache)
+	chatCtrl.SetRateLimiter(rateLimitSvc)
 	chatGroup := v1.Group("")
-	chatGroup.Use(middleware.DualAuth(authSvc, jwtSvc), middleware.TraceID())
+	chatGroup.Use(middleware.BodySizeLimit(middleware.MaxBodyBytesFromEnv()), middleware.DualAuth(authSvc, jwtSvc), middleware.TraceID())
 	chatCtrl.RegisterRoutes(chatGroup)
+
+	// 模型列表（OpenAI SDK 通过 GET /v1/models 发现模型）
+	modelsCtrl := controller.NewModelsController(routerSvc)
+	modelsCtrl.RegisterRoutes(chatGroup)
 }
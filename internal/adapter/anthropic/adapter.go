@@ -0,0 +1,138 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// Adapter Anthropic Adapter（原生 Messages API）
+type Adapter struct {
+	config *adapter.ProviderConfig
+}
+
+// NewAdapter 创建 Anthropic Adapter
+func NewAdapter(provider *model.Provider) (adapter.Provider, error) {
+	config := adapter.NewProviderConfig(provider)
+
+	// 验证配置
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("anthropic adapter requires base_url")
+	}
+
+	return &Adapter{config: config}, nil
+}
+
+// newClient 创建客户端，anthropic-version 可通过 extra_config.anthropic_version 覆盖
+func (a *Adapter) newClient() *Client {
+	apiVersion, _ := a.config.ExtraConfig["anthropic_version"].(string)
+	return NewClient(a.config.BaseURL, a.config.APIKey, apiVersion, a.config.TimeoutSeconds)
+}
+
+// Chat 完成对话调用（非流式）
+func (a *Adapter) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	// Messages API 不支持音频输入
+	if err := adapter.CheckContentParts(a.Type(), req.Messages, adapter.ContentPartImageURL); err != nil {
+		return nil, err
+	}
+	// Messages API 没有惩罚、种子、logprobs 和结构化输出参数
+	if err := adapter.CheckParameters(a.Type(), req); err != nil {
+		return nil, err
+	}
+
+	// Messages API 每次只返回一个结果，n > 1 时并发请求
+	return adapter.FanOutChoices(ctx, a.Type(), req, a.chat)
+}
+
+// chat 发送单次 Messages 请求
+func (a *Adapter) chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	client := a.newClient()
+
+	// 转换请求格式
+	anthropicReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	// 发送请求
+	resp, err := client.DoMessagesRequest(ctx, anthropicReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换响应格式
+	return ConvertChatResponse(resp), nil
+}
+
+// ChatStream 流式对话调用
+func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	// Messages API 不支持音频输入
+	if err := adapter.CheckContentParts(a.Type(), req.Messages, adapter.ContentPartImageURL); err != nil {
+		return nil, err
+	}
+	if err := adapter.CheckParameters(a.Type(), req); err != nil {
+		return nil, err
+	}
+	if err := adapter.CheckStreamChoices(a.Type(), req); err != nil {
+		return nil, err
+	}
+
+	client := a.newClient()
+
+	// 转换请求格式
+	anthropicReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 创建响应 channel
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	// 启动 goroutine 处理流式请求
+	go func() {
+		defer close(respChan)
+
+		// 设置超时
+		streamCtx := ctx
+		if a.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+			defer cancel()
+		}
+
+		// 发送流式请求
+		if err := client.DoMessagesStreamRequest(streamCtx, anthropicReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
+		}
+	}()
+
+	return respChan, nil
+}
+
+// Type 返回 Provider 类型
+func (a *Adapter) Type() string {
+	return string(adapter.AdapterTypeAnthropic)
+}
+
+// Name 返回 Provider 实例名称
+func (a *Adapter) Name() string {
+	return a.config.Name
+}
+
+// Timeout 返回超时时间（秒）
+func (a *Adapter) Timeout() int {
+	return a.config.TimeoutSeconds
+}
+
+// Config 返回配置信息
+func (a *Adapter) Config() map[string]any {
+	return a.config.GetConfig()
+}
+
+func init() {
+	adapter.RegisterAdapterType(adapter.AdapterTypeAnthropic, NewAdapter)
+}
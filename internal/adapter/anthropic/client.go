@@ -0,0 +1,617 @@
+package anthropic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+const (
+	// DefaultAPIVersion 默认 anthropic-version 请求头
+	DefaultAPIVersion = "2023-06-01"
+	// DefaultMaxTokens Messages API 要求必须提供 max_tokens，未配置时使用该默认值
+	DefaultMaxTokens = 4096
+)
+
+// Client Anthropic Messages API 客户端
+type Client struct {
+	baseURL    string
+	apiKey     string
+	apiVersion string
+	httpClient *http.Client
+}
+
+// NewClient 创建 Anthropic 客户端
+func NewClient(baseURL, apiKey, apiVersion string, timeout int) *Client {
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		apiVersion: apiVersion,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// buildMessagesURL 构建 Messages API URL
+// - https://api.anthropic.com/v1 -> https://api.anthropic.com/v1/messages
+// - https://api.anthropic.com/v1/messages -> https://api.anthropic.com/v1/messages
+func buildMessagesURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	if strings.HasSuffix(baseURL, "/messages") {
+		return baseURL
+	}
+
+	return baseURL + "/messages"
+}
+
+// MessagesRequest Messages API 请求格式
+type MessagesRequest struct {
+	Model       string      `json:"model"`
+	Messages    []Message   `json:"messages"`
+	System      string      `json:"system,omitempty"`
+	MaxTokens   int         `json:"max_tokens"`
+	Temperature *float64    `json:"temperature,omitempty"`
+	TopP        *float64    `json:"top_p,omitempty"`
+	TopK        *int        `json:"top_k,omitempty"`
+	Stream      bool        `json:"stream,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+
+	StopSequences []string  `json:"stop_sequences,omitempty"`
+	Metadata      *Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata 请求元数据
+type Metadata struct {
+	UserID string `json:"user_id,omitempty"` // 对应 OpenAI 的 user 参数
+}
+
+// Message Messages API 消息格式
+type Message struct {
+	Role    string `json:"role"`    // user, assistant
+	Content any    `json:"content"` // 字符串或 []ContentBlock
+}
+
+// Tool 工具定义
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ToolChoice 工具选择策略：auto、any、tool、none
+type ToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"` // type 为 tool 时指定工具名
+}
+
+// MessagesResponse Messages API 响应格式
+type MessagesResponse struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Role       string         `json:"role"`
+	Model      string         `json:"model"`
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      MessagesUsage  `json:"usage"`
+}
+
+// ContentBlock 内容块（text、tool_use、tool_result）
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
+	Source    *ImageSource    `json:"source,omitempty"`      // image
+}
+
+// ImageSource 图片来源：base64 或 url
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// MessagesUsage 使用量
+type MessagesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// StreamEvent SSE 事件（各事件类型共用字段）
+type StreamEvent struct {
+	Type         string            `json:"type"`
+	Message      *MessagesResponse `json:"message,omitempty"`       // message_start
+	Index        int               `json:"index"`                   // content_block_*
+	ContentBlock *ContentBlock     `json:"content_block,omitempty"` // content_block_start
+	Delta        *StreamDelta      `json:"delta,omitempty"`         // content_block_delta, message_delta
+	Usage        *MessagesUsage    `json:"usage,omitempty"`         // message_delta
+	Error        *ErrorDetail      `json:"error,omitempty"`         // error
+}
+
+// StreamDelta 增量内容
+type StreamDelta struct {
+	Type        string `json:"type,omitempty"` // text_delta, input_json_delta
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+// ErrorResponse Anthropic API 错误响应
+type ErrorResponse struct {
+	Type        string      `json:"type"`
+	ErrorDetail ErrorDetail `json:"error"`
+}
+
+// ErrorDetail 错误详情
+type ErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (e *ErrorResponse) Error() string {
+	return e.ErrorDetail.Message
+}
+
+// streamErrorStatus 流中途返回的错误类型对应的 HTTP 状态码（与 Anthropic API 的错误状态码一致）
+func streamErrorStatus(errorType string) int {
+	switch errorType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DoMessagesRequest 执行非流式请求
+func (c *Client) DoMessagesRequest(ctx context.Context, req *MessagesRequest) (*MessagesResponse, error) {
+	req.Stream = false
+
+	httpResp, err := c.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp MessagesResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// DoMessagesStreamRequest 执行流式请求，将 SSE 事件转换为内部流式块
+func (c *Client) DoMessagesStreamRequest(ctx context.Context, req *MessagesRequest, respChan chan<- *adapter.ChatStreamChunk) error {
+	req.Stream = true
+
+	httpResp, err := c.do(ctx, req, true)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	state := &streamState{toolIndex: make(map[int]int)}
+	stopped := false
+
+	// 解析 SSE 流（event: 行仅用于提示，事件类型以 data 中的 type 字段为准）
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		var event StreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue // 跳过无效数据
+		}
+
+		if event.Type == "error" && event.Error != nil {
+			return adapter.NewStreamError(streamErrorStatus(event.Error.Type), event.Error.Type, &ErrorResponse{Type: "error", ErrorDetail: *event.Error})
+		}
+
+		if event.Type == "message_stop" {
+			stopped = true
+			break
+		}
+
+		chunk := state.convert(&event)
+		if chunk == nil {
+			continue
+		}
+
+		select {
+		case respChan <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// 连接在 message_stop 之前关闭，说明上游中途断开
+	if !stopped {
+		return fmt.Errorf("failed to read stream: %w", io.ErrUnexpectedEOF)
+	}
+
+	return nil
+}
+
+// do 发送请求并检查 HTTP 状态码
+func (c *Client) do(ctx context.Context, req *MessagesRequest, stream bool) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", buildMessagesURL(c.baseURL), strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("anthropic-version", c.apiVersion)
+	if c.apiKey != "" {
+		httpReq.Header.Set("x-api-key", c.apiKey)
+	}
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	// TraceID 透传
+	if traceID := getTraceID(ctx); traceID != "" {
+		httpReq.Header.Set("X-Trace-ID", traceID)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	// 检查 HTTP 状态码
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		var errResp ErrorResponse
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.ErrorDetail.Message != "" {
+			return nil, adapter.NewUpstreamError(httpResp, respBody, &errResp, errResp.ErrorDetail.Type)
+		}
+		return nil, adapter.NewUpstreamError(httpResp, respBody, nil, "")
+	}
+
+	return httpResp, nil
+}
+
+// streamState 流式转换状态（message_start 中的 ID、模型和输入 token 需要带到后续事件）
+type streamState struct {
+	id          string
+	model       string
+	inputTokens int
+	toolIndex   map[int]int // 内容块索引 -> tool_calls 索引
+}
+
+// convert 将 SSE 事件转换为内部流式块，不需要下发的事件返回 nil
+func (s *streamState) convert(event *StreamEvent) *adapter.ChatStreamChunk {
+	switch event.Type {
+	case "message_start":
+		if event.Message == nil {
+			return nil
+		}
+		s.id = event.Message.ID
+		s.model = event.Message.Model
+		s.inputTokens = event.Message.Usage.InputTokens
+		return s.chunk(adapter.MessageDelta{Role: "assistant"}, nil)
+
+	case "content_block_start":
+		if event.ContentBlock == nil || event.ContentBlock.Type != "tool_use" {
+			return nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[event.Index] = index
+		return s.chunk(adapter.MessageDelta{ToolCalls: []adapter.ToolCallDelta{{
+			Index:    index,
+			ID:       event.ContentBlock.ID,
+			Type:     "function",
+			Function: adapter.FunctionCall{Name: event.ContentBlock.Name},
+		}}}, nil)
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil
+		}
+		if event.Delta.Type == "input_json_delta" {
+			index, ok := s.toolIndex[event.Index]
+			if !ok || event.Delta.PartialJSON == "" {
+				return nil
+			}
+			return s.chunk(adapter.MessageDelta{ToolCalls: []adapter.ToolCallDelta{{
+				Index:    index,
+				Function: adapter.FunctionCall{Arguments: event.Delta.PartialJSON},
+			}}}, nil)
+		}
+		if event.Delta.Text == "" {
+			return nil
+		}
+		return s.chunk(adapter.MessageDelta{Content: event.Delta.Text}, nil)
+
+	case "message_delta":
+		if event.Delta == nil || event.Delta.StopReason == "" {
+			return nil
+		}
+		finishReason := convertStopReason(event.Delta.StopReason)
+		chunk := s.chunk(adapter.MessageDelta{}, &finishReason)
+		if event.Usage != nil {
+			chunk.Usage = &adapter.Usage{
+				PromptTokens:     s.inputTokens,
+				CompletionTokens: event.Usage.OutputTokens,
+				TotalTokens:      s.inputTokens + event.Usage.OutputTokens,
+			}
+		}
+		return chunk
+	}
+
+	// ping、content_block_stop 等事件无需转换
+	return nil
+}
+
+func (s *streamState) chunk(delta adapter.MessageDelta, finishReason *string) *adapter.ChatStreamChunk {
+	return &adapter.ChatStreamChunk{
+		ID:    s.id,
+		Model: s.model,
+		Choices: []adapter.StreamChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	}
+}
+
+// getTraceID 从 context 获取 TraceID
+func getTraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value("trace_id").(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// ConvertChatRequest 将内部请求格式转换为 Messages API 格式
+// system 消息会被提取到顶层 system 字段，多条 system 消息按顺序拼接；
+// assistant 的 tool_calls 转换为 tool_use 内容块，tool 消息转换为 user 消息中的 tool_result 内容块
+func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any) *MessagesRequest {
+	anthropicReq := &MessagesRequest{
+		Model:    req.Model,
+		Messages: make([]Message, 0, len(req.Messages)),
+	}
+
+	var systemParts []string
+	for _, msg := range req.Messages {
+		switch {
+		case msg.Role == "system":
+			systemParts = append(systemParts, msg.Content)
+
+		case msg.Role == "tool":
+			block := ContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content}
+			// 连续的工具结果需要合并到同一条 user 消息中
+			if n := len(anthropicReq.Messages); n > 0 {
+				if blocks, ok := anthropicReq.Messages[n-1].Content.([]ContentBlock); ok && anthropicReq.Messages[n-1].Role == "user" {
+					anthropicReq.Messages[n-1].Content = append(blocks, block)
+					continue
+				}
+			}
+			anthropicReq.Messages = append(anthropicReq.Messages, Message{Role: "user", Content: []ContentBlock{block}})
+
+		case len(msg.ToolCalls) > 0:
+			blocks := make([]ContentBlock, 0, len(msg.ToolCalls)+1)
+			if msg.Content != "" {
+				blocks = append(blocks, ContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, ContentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: toolInput(call.Function.Arguments),
+				})
+			}
+			anthropicReq.Messages = append(anthropicReq.Messages, Message{Role: msg.Role, Content: blocks})
+
+		case len(msg.Parts) > 0:
+			anthropicReq.Messages = append(anthropicReq.Messages, Message{
+				Role:    msg.Role,
+				Content: convertContentParts(msg.Parts),
+			})
+
+		default:
+			anthropicReq.Messages = append(anthropicReq.Messages, Message{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
+	}
+	anthropicReq.System = strings.Join(systemParts, "\n\n")
+
+	for _, tool := range req.Tools {
+		anthropicReq.Tools = append(anthropicReq.Tools, Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: toolInput(string(tool.Function.Parameters)),
+		})
+	}
+	if req.ToolChoice != nil {
+		anthropicReq.ToolChoice = convertToolChoice(req.ToolChoice)
+	}
+
+	// 优先使用请求参数
+	if req.Temperature != nil {
+		anthropicReq.Temperature = req.Temperature
+	} else if temp, ok := defaultConfig["temperature"].(float64); ok {
+		anthropicReq.Temperature = &temp
+	}
+
+	// max_tokens 为必填项
+	if req.MaxTokens != nil {
+		anthropicReq.MaxTokens = *req.MaxTokens
+	} else if maxTokens, ok := defaultConfig["max_tokens"].(float64); ok {
+		anthropicReq.MaxTokens = int(maxTokens)
+	}
+	if anthropicReq.MaxTokens <= 0 {
+		anthropicReq.MaxTokens = DefaultMaxTokens
+	}
+
+	if req.TopP != nil {
+		anthropicReq.TopP = req.TopP
+	} else if topP, ok := defaultConfig["top_p"].(float64); ok {
+		anthropicReq.TopP = &topP
+	}
+
+	anthropicReq.StopSequences = req.Stop
+	if req.User != "" {
+		anthropicReq.Metadata = &Metadata{UserID: req.User}
+	}
+
+	if topK, ok := defaultConfig["top_k"].(float64); ok {
+		val := int(topK)
+		anthropicReq.TopK = &val
+	}
+
+	return anthropicReq
+}
+
+// convertContentParts 将多模态内容片段转换为内容块
+// Data URL 图片转换为 base64 来源，其他地址转换为 url 来源
+func convertContentParts(parts []adapter.ContentPart) []ContentBlock {
+	blocks := make([]ContentBlock, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case adapter.ContentPartText:
+			blocks = append(blocks, ContentBlock{Type: "text", Text: part.Text})
+		case adapter.ContentPartImageURL:
+			source := &ImageSource{Type: "url", URL: part.ImageURL.URL}
+			if mediaType, data, ok := adapter.ParseDataURL(part.ImageURL.URL); ok {
+				source = &ImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+			blocks = append(blocks, ContentBlock{Type: "image", Source: source})
+		}
+	}
+	return blocks
+}
+
+// convertToolChoice 将 tool_choice 映射为 Anthropic 格式（required 对应 any）
+func convertToolChoice(choice *adapter.ToolChoice) *ToolChoice {
+	switch choice.Type {
+	case adapter.ToolChoiceRequired:
+		return &ToolChoice{Type: "any"}
+	case adapter.ToolChoiceFunction:
+		return &ToolChoice{Type: "tool", Name: choice.FunctionName}
+	default:
+		return &ToolChoice{Type: choice.Type}
+	}
+}
+
+// toolInput 将 JSON 字符串转换为 input 对象，空字符串或非法 JSON 时使用空对象
+func toolInput(arguments string) json.RawMessage {
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(arguments)
+}
+
+// ConvertChatResponse 将 Messages API 响应转换为内部格式
+func ConvertChatResponse(resp *MessagesResponse) *adapter.ChatResponse {
+	var content strings.Builder
+	var toolCalls []adapter.ToolCall
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			arguments := string(block.Input)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			toolCalls = append(toolCalls, adapter.ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: adapter.FunctionCall{Name: block.Name, Arguments: arguments},
+			})
+		}
+	}
+
+	return &adapter.ChatResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
+		Choices: []adapter.Choice{
+			{
+				Index: 0,
+				Message: adapter.Message{
+					Role:      "assistant",
+					Content:   content.String(),
+					ToolCalls: toolCalls,
+				},
+				FinishReason: convertStopReason(resp.StopReason),
+			},
+		},
+		Usage: adapter.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
+
+// convertStopReason 将 stop_reason 映射为 OpenAI finish_reason
+func convertStopReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
@@ -0,0 +1,589 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestConvertChatRequest_SystemHoisting 测试 system 消息提取到顶层
+func TestConvertChatRequest_SystemHoisting(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "system", Content: "Answer briefly."},
+			{Role: "user", Content: "Hello"},
+		},
+	}
+
+	result := ConvertChatRequest(req, nil)
+
+	if result.System != "You are helpful.\n\nAnswer briefly." {
+		t.Errorf("unexpected system prompt: %q", result.System)
+	}
+
+	if len(result.Messages) != 1 || result.Messages[0].Role != "user" {
+		t.Errorf("expected only user message, got %+v", result.Messages)
+	}
+}
+
+// TestConvertChatRequest_MaxTokens 测试 max_tokens 的优先级与默认值
+func TestConvertChatRequest_MaxTokens(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	}
+
+	// 未提供任何配置时使用默认值
+	if result := ConvertChatRequest(req, nil); result.MaxTokens != DefaultMaxTokens {
+		t.Errorf("expected default max_tokens %d, got %d", DefaultMaxTokens, result.MaxTokens)
+	}
+
+	// 从默认配置读取
+	if result := ConvertChatRequest(req, map[string]any{"max_tokens": 1024.0}); result.MaxTokens != 1024 {
+		t.Errorf("expected max_tokens 1024 from config, got %d", result.MaxTokens)
+	}
+
+	// 请求参数优先
+	maxTokens := 256
+	req.MaxTokens = &maxTokens
+	if result := ConvertChatRequest(req, map[string]any{"max_tokens": 1024.0}); result.MaxTokens != 256 {
+		t.Errorf("expected max_tokens 256 from request, got %d", result.MaxTokens)
+	}
+}
+
+// TestConvertChatResponse 测试响应格式转换
+func TestConvertChatResponse(t *testing.T) {
+	resp := &MessagesResponse{
+		ID:    "msg_123",
+		Model: "claude-3-5-sonnet-latest",
+		Content: []ContentBlock{
+			{Type: "text", Text: "Hello"},
+			{Type: "text", Text: " there"},
+		},
+		StopReason: "max_tokens",
+		Usage:      MessagesUsage{InputTokens: 12, OutputTokens: 8},
+	}
+
+	result := ConvertChatResponse(resp)
+
+	if result.Choices[0].Message.Content != "Hello there" {
+		t.Errorf("unexpected content: %q", result.Choices[0].Message.Content)
+	}
+
+	if result.Choices[0].FinishReason != "length" {
+		t.Errorf("expected finish_reason length, got %s", result.Choices[0].FinishReason)
+	}
+
+	if result.Usage.TotalTokens != 20 {
+		t.Errorf("expected total_tokens 20, got %d", result.Usage.TotalTokens)
+	}
+}
+
+// TestAdapter_Chat_Success 测试非流式请求
+func TestAdapter_Chat_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("expected path /v1/messages, got %s", r.URL.Path)
+		}
+
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("expected x-api-key test-key, got %s", r.Header.Get("x-api-key"))
+		}
+
+		if r.Header.Get("anthropic-version") != DefaultAPIVersion {
+			t.Errorf("expected anthropic-version %s, got %s", DefaultAPIVersion, r.Header.Get("anthropic-version"))
+		}
+
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no Authorization header, got %s", r.Header.Get("Authorization"))
+		}
+
+		var body MessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.System != "Be nice." {
+			t.Errorf("expected system 'Be nice.', got %q", body.System)
+		}
+		if body.MaxTokens != DefaultMaxTokens {
+			t.Errorf("expected max_tokens %d, got %d", DefaultMaxTokens, body.MaxTokens)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_test",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-3-5-sonnet-latest",
+			"content": [{"type": "text", "text": "Hi!"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 10, "output_tokens": 3}
+		}`))
+	}))
+	defer server.Close()
+
+	apiKey := "test-key"
+	provider := &model.Provider{
+		Name:    "claude",
+		Type:    "anthropic",
+		BaseURL: server.URL + "/v1",
+		Timeout: 30,
+		APIKey:  &apiKey,
+		Enabled: true,
+	}
+
+	anthropicAdapter, err := NewAdapter(provider)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model: "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{
+			{Role: "system", Content: "Be nice."},
+			{Role: "user", Content: "Hello"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Choices[0].Message.Content != "Hi!" {
+		t.Errorf("expected content 'Hi!', got %s", resp.Choices[0].Message.Content)
+	}
+
+	if resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("expected finish_reason stop, got %s", resp.Choices[0].FinishReason)
+	}
+
+	if resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 3 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestDoMessagesRequest_Error 测试错误响应
+func TestDoMessagesRequest_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "bad-key", "", 30)
+
+	_, err := client.DoMessagesRequest(context.Background(), &MessagesRequest{
+		Model:     "claude-3-5-sonnet-latest",
+		Messages:  []Message{{Role: "user", Content: "Hello"}},
+		MaxTokens: 10,
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("expected error containing 'invalid x-api-key', got %v", err)
+	}
+}
+
+// TestAdapter_ChatStream_Success 测试 SSE 事件映射
+func TestAdapter_ChatStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"stream":true`) {
+			t.Error("expected stream=true in request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		events := []string{
+			"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_stream\",\"type\":\"message\",\"role\":\"assistant\",\"model\":\"claude-3-5-sonnet-latest\",\"content\":[],\"usage\":{\"input_tokens\":25,\"output_tokens\":1}}}",
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}",
+			"event: ping\ndata: {\"type\":\"ping\"}",
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}",
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"!\"}}",
+			"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}",
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\",\"stop_sequence\":null},\"usage\":{\"output_tokens\":15}}",
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}",
+		}
+
+		for _, event := range events {
+			w.Write([]byte(event + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	provider := &model.Provider{
+		Name:    "claude-stream",
+		Type:    "anthropic",
+		BaseURL: server.URL,
+		Timeout: 30,
+		Enabled: true,
+	}
+
+	anthropicAdapter, err := NewAdapter(provider)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	respChan, err := anthropicAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	// message_start + 2 个 content_block_delta + message_delta
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" || chunks[0].ID != "msg_stream" {
+		t.Errorf("unexpected first chunk: %+v", chunks[0])
+	}
+
+	if chunks[1].Choices[0].Delta.Content != "Hello" || chunks[2].Choices[0].Delta.Content != "!" {
+		t.Error("unexpected content deltas")
+	}
+
+	last := chunks[3]
+	if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != "stop" {
+		t.Error("expected last chunk to have finish_reason=stop")
+	}
+
+	if last.Usage == nil || last.Usage.PromptTokens != 25 || last.Usage.CompletionTokens != 15 || last.Usage.TotalTokens != 40 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
+
+// TestConvertChatRequest_Tools 测试工具定义、tool_use 与 tool_result 的转换
+func TestConvertChatRequest_Tools(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{
+			{Role: "user", Content: "Weather in Paris and Rome?"},
+			{Role: "assistant", ToolCalls: []adapter.ToolCall{
+				{ID: "toolu_1", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: "toolu_2", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+			}},
+			{Role: "tool", ToolCallID: "toolu_1", Content: "18C"},
+			{Role: "tool", ToolCallID: "toolu_2", Content: "24C"},
+		},
+		Tools: []adapter.Tool{{Type: "function", Function: adapter.ToolFunction{
+			Name:       "get_weather",
+			Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		}}},
+		ToolChoice: &adapter.ToolChoice{Type: adapter.ToolChoiceRequired},
+	}
+
+	result := ConvertChatRequest(req, nil)
+
+	if len(result.Tools) != 1 || result.Tools[0].Name != "get_weather" || !strings.Contains(string(result.Tools[0].InputSchema), "city") {
+		t.Errorf("unexpected tools: %+v", result.Tools)
+	}
+
+	if result.ToolChoice == nil || result.ToolChoice.Type != "any" {
+		t.Errorf("expected tool_choice any, got %+v", result.ToolChoice)
+	}
+
+	// 连续的 tool 消息合并为一条 user 消息
+	if len(result.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(result.Messages))
+	}
+
+	body, _ := json.Marshal(result.Messages[1:])
+	expected := `[{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}},{"type":"tool_use","id":"toolu_2","name":"get_weather","input":{"city":"Rome"}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"18C"},{"type":"tool_result","tool_use_id":"toolu_2","content":"24C"}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected messages:\n%s", body)
+	}
+}
+
+// TestConvertChatResponse_ToolUse 测试 tool_use 内容块转换为 tool_calls
+func TestConvertChatResponse_ToolUse(t *testing.T) {
+	result := ConvertChatResponse(&MessagesResponse{
+		ID:    "msg_tool",
+		Model: "claude-3-5-sonnet-latest",
+		Content: []ContentBlock{
+			{Type: "text", Text: "Checking."},
+			{Type: "tool_use", ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Paris"}`)},
+		},
+		StopReason: "tool_use",
+	})
+
+	choice := result.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != "Checking." {
+		t.Errorf("unexpected choice: %+v", choice)
+	}
+
+	if len(choice.Message.ToolCalls) != 1 || choice.Message.ToolCalls[0].ID != "toolu_1" || choice.Message.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool_calls: %+v", choice.Message.ToolCalls)
+	}
+}
+
+// TestStreamState_ToolUse 测试流式 tool_use 转换为 tool_calls 增量
+func TestStreamState_ToolUse(t *testing.T) {
+	state := &streamState{toolIndex: make(map[int]int)}
+
+	events := []string{
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+	}
+
+	var deltas []adapter.ToolCallDelta
+	for _, data := range events {
+		var event StreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		if chunk := state.convert(&event); chunk != nil {
+			deltas = append(deltas, chunk.Choices[0].Delta.ToolCalls...)
+		}
+	}
+
+	if len(deltas) != 3 {
+		t.Fatalf("expected 3 tool call deltas, got %d", len(deltas))
+	}
+
+	if deltas[0].Index != 0 || deltas[0].ID != "toolu_1" || deltas[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected first delta: %+v", deltas[0])
+	}
+
+	if deltas[1].Function.Arguments+deltas[2].Function.Arguments != `{"city":"Paris"}` || deltas[2].Index != 0 {
+		t.Errorf("unexpected argument deltas: %+v", deltas[1:])
+	}
+}
+
+// TestConvertChatRequest_Images 测试图片片段转换为 image 内容块
+func TestConvertChatRequest_Images(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Describe these", Parts: []adapter.ContentPart{
+			{Type: adapter.ContentPartText, Text: "Describe these"},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "data:image/png;base64,iVBORw0KGgo="}},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "https://example.com/cat.jpg"}},
+		}}},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).Messages)
+	expected := `[{"role":"user","content":[{"type":"text","text":"Describe these"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}},` +
+		`{"type":"image","source":{"type":"url","url":"https://example.com/cat.jpg"}}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected messages:\n%s", body)
+	}
+}
+
+// TestAdapter_Chat_UnsupportedAudio 测试音频输入在发送请求前被拒绝
+func TestAdapter_Chat_UnsupportedAudio(t *testing.T) {
+	anthropicAdapter, err := NewAdapter(&model.Provider{Name: "claude", Type: "anthropic", BaseURL: "http://127.0.0.1:0", Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	_, err = anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model: "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Parts: []adapter.ContentPart{
+			{Type: adapter.ContentPartInputAudio, InputAudio: &adapter.InputAudio{Data: "UklGRg==", Format: "wav"}},
+		}}},
+	})
+
+	var contentErr *adapter.UnsupportedContentError
+	if !errors.As(err, &contentErr) || contentErr.PartType != adapter.ContentPartInputAudio {
+		t.Errorf("expected UnsupportedContentError for input_audio, got %v", err)
+	}
+}
+
+// TestConvertChatRequest_SamplingParams 测试 top_p、stop 和 user 映射
+func TestConvertChatRequest_SamplingParams(t *testing.T) {
+	topP := 0.5
+	req := ConvertChatRequest(&adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		TopP:     &topP,
+		Stop:     []string{"END", "STOP"},
+		User:     "user-1",
+	}, map[string]any{"top_p": 0.9})
+
+	if req.TopP == nil || *req.TopP != 0.5 {
+		t.Errorf("expected top_p 0.5 from request, got %v", req.TopP)
+	}
+	if len(req.StopSequences) != 2 || req.StopSequences[0] != "END" {
+		t.Errorf("unexpected stop_sequences: %v", req.StopSequences)
+	}
+	if req.Metadata == nil || req.Metadata.UserID != "user-1" {
+		t.Errorf("unexpected metadata: %+v", req.Metadata)
+	}
+}
+
+// TestAdapter_Chat_UnsupportedParameter 测试不支持的参数
+func TestAdapter_Chat_UnsupportedParameter(t *testing.T) {
+	anthropicAdapter, err := NewAdapter(&model.Provider{Name: "claude", Type: "anthropic", BaseURL: "http://127.0.0.1:0", Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	seed := int64(1)
+	_, err = anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		Seed:     &seed,
+	})
+
+	var paramErr *adapter.UnsupportedParameterError
+	if !errors.As(err, &paramErr) || paramErr.Parameter != adapter.ParamSeed {
+		t.Errorf("expected UnsupportedParameterError for seed, got %v", err)
+	}
+
+	_, err = anthropicAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		N:        2,
+	})
+	if !errors.As(err, &paramErr) || paramErr.Parameter != "n" {
+		t.Errorf("expected UnsupportedParameterError for n, got %v", err)
+	}
+}
+
+// TestAdapter_Chat_MultipleChoices 测试 n > 1 时并发请求并合并结果
+func TestAdapter_Chat_MultipleChoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_test",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-3-5-sonnet-latest",
+			"content": [{"type": "text", "text": "Hi!"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 10, "output_tokens": 3}
+		}`))
+	}))
+	defer server.Close()
+
+	anthropicAdapter, err := NewAdapter(&model.Provider{Name: "claude", Type: "anthropic", BaseURL: server.URL, Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		N:        3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.Choices) != 3 {
+		t.Fatalf("expected 3 choices, got %d", len(resp.Choices))
+	}
+	for i, choice := range resp.Choices {
+		if choice.Index != i || choice.Message.Content != "Hi!" {
+			t.Errorf("unexpected choice %d: %+v", i, choice)
+		}
+	}
+	if resp.Usage.PromptTokens != 30 || resp.Usage.CompletionTokens != 9 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAdapter_Chat_MultipleChoicesLimit 测试并发请求数有上限，n 超过 MaxFanOutChoices 时拒绝
+func TestAdapter_Chat_MultipleChoicesLimit(t *testing.T) {
+	var inFlight, peak, total atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		total.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_test",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-3-5-sonnet-latest",
+			"content": [{"type": "text", "text": "Hi!"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 10, "output_tokens": 3}
+		}`))
+	}))
+	defer server.Close()
+
+	anthropicAdapter, err := NewAdapter(&model.Provider{Name: "claude", Type: "anthropic", BaseURL: server.URL, Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		N:        adapter.MaxFanOutChoices,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Choices) != adapter.MaxFanOutChoices {
+		t.Errorf("expected %d choices, got %d", adapter.MaxFanOutChoices, len(resp.Choices))
+	}
+	if p := peak.Load(); p > 4 {
+		t.Errorf("expected at most 4 concurrent requests, got %d", p)
+	}
+
+	total.Store(0)
+	_, err = anthropicAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		N:        adapter.MaxFanOutChoices + 1,
+	})
+	var paramErr *adapter.UnsupportedParameterError
+	if !errors.As(err, &paramErr) || paramErr.Parameter != "n" {
+		t.Errorf("expected UnsupportedParameterError for n, got %v", err)
+	}
+	if n := total.Load(); n != 0 {
+		t.Errorf("expected no upstream requests, got %d", n)
+	}
+}
+
+// TestBuildMessagesURL 测试 buildMessagesURL 函数
+func TestBuildMessagesURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"https://api.anthropic.com/v1", "https://api.anthropic.com/v1/messages"},
+		{"https://api.anthropic.com/v1/", "https://api.anthropic.com/v1/messages"},
+		{"https://api.anthropic.com/v1/messages", "https://api.anthropic.com/v1/messages"},
+	}
+
+	for _, tt := range tests {
+		if result := buildMessagesURL(tt.baseURL); result != tt.expected {
+			t.Errorf("buildMessagesURL(%q) = %q, want %q", tt.baseURL, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/adapter/openai"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// DefaultAPIVersion 默认 Azure OpenAI API 版本
+const DefaultAPIVersion = "2024-10-21"
+
+// 鉴权方式
+const (
+	AuthTypeAPIKey = "api_key" // api-key 请求头
+	AuthTypeEntra  = "entra"   // Microsoft Entra ID 令牌（Authorization: Bearer）
+)
+
+// Adapter Azure OpenAI Adapter
+// 请求/响应格式与 OpenAI 一致，复用 openai 包的转换逻辑，仅 URL 和鉴权方式不同
+type Adapter struct {
+	config      *adapter.ProviderConfig
+	apiVersion  string
+	authType    string
+	deployments map[string]string // 模型名 -> 部署名
+}
+
+// NewAdapter 创建 Azure OpenAI Adapter
+func NewAdapter(provider *model.Provider) (adapter.Provider, error) {
+	config := adapter.NewProviderConfig(provider)
+
+	// 验证配置
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("azure-openai adapter requires base_url")
+	}
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("azure-openai adapter requires api_key")
+	}
+
+	apiVersion := DefaultAPIVersion
+	if v, ok := config.ExtraConfig["api_version"].(string); ok && v != "" {
+		apiVersion = v
+	}
+
+	authType := AuthTypeAPIKey
+	if v, ok := config.ExtraConfig["auth_type"].(string); ok && v != "" {
+		switch v {
+		case AuthTypeAPIKey, AuthTypeEntra:
+			authType = v
+		default:
+			return nil, fmt.Errorf("azure-openai adapter: unsupported auth_type %q (must be %s or %s)", v, AuthTypeAPIKey, AuthTypeEntra)
+		}
+	}
+
+	deployments := make(map[string]string)
+	if raw, ok := config.ExtraConfig["deployments"].(map[string]any); ok {
+		for modelName, v := range raw {
+			deployment, ok := v.(string)
+			if !ok || deployment == "" {
+				return nil, fmt.Errorf("azure-openai adapter: deployment for model %q must be a non-empty string", modelName)
+			}
+			deployments[modelName] = deployment
+		}
+	}
+
+	return &Adapter{
+		config:      config,
+		apiVersion:  apiVersion,
+		authType:    authType,
+		deployments: deployments,
+	}, nil
+}
+
+// Chat 完成对话调用（非流式）
+func (a *Adapter) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	client := a.newClient(req.Model)
+
+	// 转换请求格式
+	openaiReq := openai.ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	// 发送请求
+	resp, err := client.DoChatRequest(ctx, openaiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换响应格式
+	return openai.ConvertChatResponse(resp), nil
+}
+
+// ChatStream 流式对话调用
+func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	client := a.newClient(req.Model)
+
+	// 转换请求格式
+	openaiReq := openai.ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 创建响应 channel
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	// 启动 goroutine 处理流式请求
+	go func() {
+		defer close(respChan)
+
+		// 设置超时
+		streamCtx := ctx
+		if a.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+			defer cancel()
+		}
+
+		// 发送流式请求
+		if err := client.DoChatStreamRequest(streamCtx, openaiReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
+		}
+	}()
+
+	return respChan, nil
+}
+
+// newClient 根据模型对应的部署创建 OpenAI 客户端
+func (a *Adapter) newClient(modelName string) *openai.Client {
+	chatURL := buildChatURL(a.config.BaseURL, a.deployment(modelName), a.apiVersion)
+
+	if a.authType == AuthTypeEntra {
+		return openai.NewClientWithURL(chatURL, a.config.APIKey, nil, a.config.TimeoutSeconds)
+	}
+
+	headers := map[string]string{"api-key": a.config.APIKey}
+	return openai.NewClientWithURL(chatURL, "", headers, a.config.TimeoutSeconds)
+}
+
+// deployment 返回模型对应的部署名，未配置映射时使用模型名本身
+func (a *Adapter) deployment(modelName string) string {
+	if deployment, ok := a.deployments[modelName]; ok {
+		return deployment
+	}
+	return modelName
+}
+
+// buildChatURL 构建 Azure OpenAI 聊天 API URL
+// base_url 为资源地址（如 https://my-resource.openai.azure.com），兼容已包含 /openai 的情况：
+// - https://my-resource.openai.azure.com -> .../openai/deployments/{deployment}/chat/completions?api-version=...
+// - https://my-resource.openai.azure.com/openai -> 同上
+func buildChatURL(baseURL, deployment, apiVersion string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/openai")
+	return baseURL + "/openai/deployments/" + url.PathEscape(deployment) +
+		"/chat/completions?api-version=" + url.QueryEscape(apiVersion)
+}
+
+// Type 返回 Provider 类型
+func (a *Adapter) Type() string {
+	return string(adapter.AdapterTypeAzureOpenAI)
+}
+
+// Name 返回 Provider 实例名称
+func (a *Adapter) Name() string {
+	return a.config.Name
+}
+
+// Timeout 返回超时时间（秒）
+func (a *Adapter) Timeout() int {
+	return a.config.TimeoutSeconds
+}
+
+// Config 返回配置信息
+func (a *Adapter) Config() map[string]any {
+	return a.config.GetConfig()
+}
+
+func init() {
+	adapter.RegisterAdapterType(adapter.AdapterTypeAzureOpenAI, NewAdapter)
+}
@@ -0,0 +1,147 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+const chatResponse = `{
+	"id": "chatcmpl-azure",
+	"object": "chat.completion",
+	"created": 1700000000,
+	"model": "gpt-4o-2024-08-06",
+	"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello from Azure"}, "finish_reason": "stop"}],
+	"usage": {"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8}
+}`
+
+// TestAdapter_Chat_APIKey 测试部署映射、api-version 与 api-key 鉴权
+func TestAdapter_Chat_APIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/prod-gpt4o/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") != "2024-06-01" {
+			t.Errorf("expected api-version 2024-06-01, got %s", r.URL.Query().Get("api-version"))
+		}
+		if r.Header.Get("api-key") != "azure-key" {
+			t.Errorf("expected api-key header, got %q", r.Header.Get("api-key"))
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(chatResponse))
+	}))
+	defer server.Close()
+
+	apiKey := "azure-key"
+	azureAdapter, err := NewAdapter(&model.Provider{
+		Name:    "azure",
+		Type:    "azure-openai",
+		BaseURL: server.URL + "/openai/",
+		Timeout: 30,
+		APIKey:  &apiKey,
+		Enabled: true,
+		ExtraConfig: map[string]any{
+			"api_version": "2024-06-01",
+			"deployments": map[string]any{"gpt-4o": "prod-gpt4o"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := azureAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello from Azure" {
+		t.Errorf("unexpected content: %s", resp.Choices[0].Message.Content)
+	}
+	if resp.Usage.TotalTokens != 8 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAdapter_ChatStream_Entra 测试 Entra ID 鉴权与未映射模型直接作为部署名
+func TestAdapter_ChatStream_Entra(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-mini/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") != DefaultAPIVersion {
+			t.Errorf("expected default api-version, got %s", r.URL.Query().Get("api-version"))
+		}
+		if r.Header.Get("Authorization") != "Bearer entra-token" {
+			t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("api-key") != "" {
+			t.Errorf("expected no api-key header, got %q", r.Header.Get("api-key"))
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"id\":\"1\",\"model\":\"gpt-4o-mini\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hi\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	token := "entra-token"
+	azureAdapter, err := NewAdapter(&model.Provider{
+		Name:        "azure",
+		Type:        "azure-openai",
+		BaseURL:     server.URL,
+		APIKey:      &token,
+		ExtraConfig: map[string]any{"auth_type": "entra"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	respChan, err := azureAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 1 || chunks[0].Choices[0].Delta.Content != "Hi" {
+		t.Errorf("unexpected chunks: %+v", chunks)
+	}
+}
+
+// TestNewAdapter_InvalidConfig 测试配置校验
+func TestNewAdapter_InvalidConfig(t *testing.T) {
+	apiKey := "key"
+	tests := []struct {
+		name     string
+		provider *model.Provider
+	}{
+		{"missing api key", &model.Provider{Name: "azure", BaseURL: "https://r.openai.azure.com"}},
+		{"invalid auth type", &model.Provider{Name: "azure", BaseURL: "https://r.openai.azure.com", APIKey: &apiKey,
+			ExtraConfig: map[string]any{"auth_type": "basic"}}},
+		{"invalid deployment", &model.Provider{Name: "azure", BaseURL: "https://r.openai.azure.com", APIKey: &apiKey,
+			ExtraConfig: map[string]any{"deployments": map[string]any{"gpt-4o": 1}}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewAdapter(tt.provider); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package bedrock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// DefaultRegion 无法从 base_url 解析区域且未配置 region 时使用的默认区域
+const DefaultRegion = "us-east-1"
+
+// Adapter AWS Bedrock Adapter（Converse / ConverseStream API）
+type Adapter struct {
+	config *adapter.ProviderConfig
+	signer *Signer
+}
+
+// NewAdapter 创建 Bedrock Adapter
+// api_key 存放 Secret Access Key，extra_config.access_key_id 存放 Access Key ID，
+// extra_config.session_token 可选（临时凭证）
+func NewAdapter(provider *model.Provider) (adapter.Provider, error) {
+	config := adapter.NewProviderConfig(provider)
+
+	// 验证配置
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("bedrock adapter requires base_url")
+	}
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("bedrock adapter requires api_key (secret access key)")
+	}
+
+	accessKeyID, _ := config.ExtraConfig["access_key_id"].(string)
+	if accessKeyID == "" {
+		return nil, fmt.Errorf("bedrock adapter requires extra_config.access_key_id")
+	}
+	sessionToken, _ := config.ExtraConfig["session_token"].(string)
+
+	region, _ := config.ExtraConfig["region"].(string)
+	if region == "" {
+		region = regionFromURL(config.BaseURL)
+	}
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	return &Adapter{
+		config: config,
+		signer: &Signer{
+			Credentials: Credentials{
+				AccessKeyID:     accessKeyID,
+				SecretAccessKey: config.APIKey,
+				SessionToken:    sessionToken,
+			},
+			Region:  region,
+			Service: SigningService,
+		},
+	}, nil
+}
+
+// Chat 完成对话调用（非流式）
+func (a *Adapter) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	if err := checkContentParts(req.Messages); err != nil {
+		return nil, err
+	}
+	// Converse API 没有惩罚、种子、logprobs 和结构化输出参数
+	if err := adapter.CheckParameters(a.Type(), req); err != nil {
+		return nil, err
+	}
+
+	// Converse API 每次只返回一个结果，n > 1 时并发请求
+	return adapter.FanOutChoices(ctx, a.Type(), req, a.chat)
+}
+
+// chat 发送单次 Converse 请求
+func (a *Adapter) chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	client := NewClient(a.config.BaseURL, a.signer, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	converseReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	// 发送请求
+	resp, err := client.DoConverse(ctx, req.Model, converseReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换响应格式
+	return ConvertChatResponse(resp, req.Model), nil
+}
+
+// ChatStream 流式对话调用
+func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	if err := checkContentParts(req.Messages); err != nil {
+		return nil, err
+	}
+	if err := adapter.CheckParameters(a.Type(), req); err != nil {
+		return nil, err
+	}
+	if err := adapter.CheckStreamChoices(a.Type(), req); err != nil {
+		return nil, err
+	}
+
+	client := NewClient(a.config.BaseURL, a.signer, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	converseReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 创建响应 channel
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	// 启动 goroutine 处理流式请求
+	go func() {
+		defer close(respChan)
+
+		// 设置超时
+		streamCtx := ctx
+		if a.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+			defer cancel()
+		}
+
+		// 发送流式请求
+		if err := client.DoConverseStream(streamCtx, req.Model, converseReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
+		}
+	}()
+
+	return respChan, nil
+}
+
+// Type 返回 Provider 类型
+func (a *Adapter) Type() string {
+	return string(adapter.AdapterTypeBedrock)
+}
+
+// Name 返回 Provider 实例名称
+func (a *Adapter) Name() string {
+	return a.config.Name
+}
+
+// Timeout 返回超时时间（秒）
+func (a *Adapter) Timeout() int {
+	return a.config.TimeoutSeconds
+}
+
+// Config 返回配置信息
+func (a *Adapter) Config() map[string]any {
+	return a.config.GetConfig()
+}
+
+// checkContentParts 仅支持 Data URL 格式的图片，不支持音频
+func checkContentParts(messages []adapter.Message) error {
+	if err := adapter.CheckContentParts(string(adapter.AdapterTypeBedrock), messages, adapter.ContentPartImageURL); err != nil {
+		return err
+	}
+	return adapter.CheckInlineImages(string(adapter.AdapterTypeBedrock), messages)
+}
+
+func init() {
+	adapter.RegisterAdapterType(adapter.AdapterTypeBedrock, NewAdapter)
+}
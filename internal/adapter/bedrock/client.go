@@ -0,0 +1,681 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// SigningService Bedrock Runtime 的 SigV4 服务名
+const SigningService = "bedrock"
+
+// Client Bedrock Runtime Converse API 客户端
+type Client struct {
+	baseURL    string
+	signer     *Signer
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient 创建 Bedrock 客户端
+func NewClient(baseURL string, signer *Signer, timeout int) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		signer:     signer,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		now:        time.Now,
+	}
+}
+
+// buildModelURL 构建 Converse API URL
+// base_url 为 Bedrock Runtime 地址（如 https://bedrock-runtime.us-east-1.amazonaws.com）
+// 模型 ID 与 AWS SDK 一致按 RFC 3986 编码（":" -> %3A，推理配置文件 ARN 中的 "/" -> %2F）
+func buildModelURL(baseURL, modelID, action string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/model/" + uriEncode(modelID) + "/" + action
+}
+
+// regionFromURL 从 Bedrock Runtime 地址解析区域，如 bedrock-runtime.us-west-2.amazonaws.com -> us-west-2
+func regionFromURL(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(u.Hostname(), ".")
+	if len(parts) >= 4 && strings.HasPrefix(parts[0], "bedrock-runtime") && parts[2] == "amazonaws" {
+		return parts[1]
+	}
+	return ""
+}
+
+// ConverseRequest Converse / ConverseStream 请求格式
+type ConverseRequest struct {
+	Messages                     []Message        `json:"messages"`
+	System                       []ContentBlock   `json:"system,omitempty"`
+	InferenceConfig              *InferenceConfig `json:"inferenceConfig,omitempty"`
+	AdditionalModelRequestFields map[string]any   `json:"additionalModelRequestFields,omitempty"`
+	ToolConfig                   *ToolConfig      `json:"toolConfig,omitempty"`
+}
+
+// Message Converse 消息格式
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock Converse 内容块（text、toolUse、toolResult 三选一）
+type ContentBlock struct {
+	Text       string           `json:"text,omitempty"`
+	ToolUse    *ToolUseBlock    `json:"toolUse,omitempty"`
+	ToolResult *ToolResultBlock `json:"toolResult,omitempty"`
+	Image      *ImageBlock      `json:"image,omitempty"`
+}
+
+// ImageBlock 图片内容块
+type ImageBlock struct {
+	Format string      `json:"format"` // png, jpeg, gif, webp
+	Source ImageSource `json:"source"`
+}
+
+// ImageSource 图片数据（base64 编码）
+type ImageSource struct {
+	Bytes string `json:"bytes"`
+}
+
+// ToolUseBlock 模型发起的工具调用
+type ToolUseBlock struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+// ToolResultBlock 工具调用结果
+type ToolResultBlock struct {
+	ToolUseID string         `json:"toolUseId"`
+	Content   []ContentBlock `json:"content"`
+}
+
+// ToolConfig 工具配置
+type ToolConfig struct {
+	Tools      []Tool      `json:"tools"`
+	ToolChoice *ToolChoice `json:"toolChoice,omitempty"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	ToolSpec ToolSpec `json:"toolSpec"`
+}
+
+// ToolSpec 工具规格
+type ToolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema InputSchema `json:"inputSchema"`
+}
+
+// InputSchema 工具参数的 JSON Schema
+type InputSchema struct {
+	JSON json.RawMessage `json:"json"`
+}
+
+// ToolChoice 工具选择策略（auto、any、tool 三选一）
+type ToolChoice struct {
+	Auto *struct{}           `json:"auto,omitempty"`
+	Any  *struct{}           `json:"any,omitempty"`
+	Tool *SpecificToolChoice `json:"tool,omitempty"`
+}
+
+// SpecificToolChoice 指定工具
+type SpecificToolChoice struct {
+	Name string `json:"name"`
+}
+
+// InferenceConfig 推理参数
+type InferenceConfig struct {
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+// ConverseResponse Converse 响应格式
+type ConverseResponse struct {
+	Output     ConverseOutput `json:"output"`
+	StopReason string         `json:"stopReason"`
+	Usage      TokenUsage     `json:"usage"`
+}
+
+// ConverseOutput Converse 输出
+type ConverseOutput struct {
+	Message Message `json:"message"`
+}
+
+// TokenUsage Token 使用量
+type TokenUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// ConverseStream 事件载荷
+type (
+	// MessageStartEvent messageStart 事件
+	MessageStartEvent struct {
+		Role string `json:"role"`
+	}
+
+	// ContentBlockStartEvent contentBlockStart 事件（仅工具调用块会发送）
+	ContentBlockStartEvent struct {
+		ContentBlockIndex int `json:"contentBlockIndex"`
+		Start             struct {
+			ToolUse *struct {
+				ToolUseID string `json:"toolUseId"`
+				Name      string `json:"name"`
+			} `json:"toolUse,omitempty"`
+		} `json:"start"`
+	}
+
+	// ContentBlockDeltaEvent contentBlockDelta 事件
+	ContentBlockDeltaEvent struct {
+		ContentBlockIndex int `json:"contentBlockIndex"`
+		Delta             struct {
+			Text    string `json:"text"`
+			ToolUse *struct {
+				Input string `json:"input"` // 参数 JSON 片段
+			} `json:"toolUse,omitempty"`
+		} `json:"delta"`
+	}
+
+	// MessageStopEvent messageStop 事件
+	MessageStopEvent struct {
+		StopReason string `json:"stopReason"`
+	}
+
+	// MetadataEvent metadata 事件（位于 messageStop 之后）
+	MetadataEvent struct {
+		Usage TokenUsage `json:"usage"`
+	}
+)
+
+// ErrorResponse Bedrock 错误响应
+type ErrorResponse struct {
+	Type    string `json:"-"` // 来自 x-amzn-ErrorType 请求头或 :exception-type 事件头
+	Message string `json:"message"`
+}
+
+func (e *ErrorResponse) Error() string {
+	if e.Type == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// exceptionStatus 流中途返回的异常类型对应的 HTTP 状态码（与 Bedrock API 的错误状态码一致）
+func exceptionStatus(exceptionType string) int {
+	switch exceptionType {
+	case "ValidationException":
+		return http.StatusBadRequest
+	case "AccessDeniedException":
+		return http.StatusForbidden
+	case "ResourceNotFoundException":
+		return http.StatusNotFound
+	case "ModelTimeoutException":
+		return http.StatusRequestTimeout
+	case "ThrottlingException":
+		return http.StatusTooManyRequests
+	case "ServiceUnavailableException":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DoConverse 执行 Converse 请求（非流式）
+func (c *Client) DoConverse(ctx context.Context, modelID string, req *ConverseRequest) (*ConverseResponse, error) {
+	httpResp, err := c.do(ctx, buildModelURL(c.baseURL, modelID, "converse"), req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp ConverseResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// DoConverseStream 执行 ConverseStream 请求，解码 event-stream 响应
+func (c *Client) DoConverseStream(ctx context.Context, modelID string, req *ConverseRequest, respChan chan<- *adapter.ChatStreamChunk) error {
+	httpResp, err := c.do(ctx, buildModelURL(c.baseURL, modelID, "converse-stream"), req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	state := &streamState{model: modelID, toolIndex: make(map[int]int)}
+	decoder := NewEventStreamDecoder(httpResp.Body)
+	for {
+		msg, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+
+		chunk, err := state.convert(msg)
+		if err != nil {
+			return err
+		}
+		if chunk == nil {
+			continue
+		}
+
+		select {
+		case respChan <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// 未收到 metadata 事件时补发结束块
+	if chunk := state.flush(); chunk != nil {
+		select {
+		case respChan <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// do 签名并发送请求，检查 HTTP 状态码
+func (c *Client) do(ctx context.Context, endpoint string, req *ConverseRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// TraceID 透传（不参与签名）
+	if traceID := getTraceID(ctx); traceID != "" {
+		httpReq.Header.Set("X-Trace-ID", traceID)
+	}
+
+	c.signer.Sign(httpReq, body, c.now())
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	// 检查 HTTP 状态码
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		var errResp ErrorResponse
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Message != "" {
+			// x-amzn-ErrorType 形如 ValidationException:http://internal.amazon.com/coral/...
+			errResp.Type, _, _ = strings.Cut(httpResp.Header.Get("X-Amzn-Errortype"), ":")
+			return nil, adapter.NewUpstreamError(httpResp, respBody, &errResp, errResp.Type)
+		}
+		return nil, adapter.NewUpstreamError(httpResp, respBody, nil, "")
+	}
+
+	return httpResp, nil
+}
+
+// getTraceID 从 context 获取 TraceID
+func getTraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value("trace_id").(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// streamState ConverseStream 事件转换状态
+// messageStop 携带 stopReason，metadata 携带 usage，两者合并为一个结束块
+type streamState struct {
+	model      string
+	stopReason *string
+	done       bool
+	toolIndex  map[int]int // 内容块索引 -> tool_calls 索引
+}
+
+// convert 将 event-stream 消息转换为内部流式块，无需输出时返回 nil
+func (s *streamState) convert(msg *EventMessage) (*adapter.ChatStreamChunk, error) {
+	if msg.Header(":message-type") == "exception" {
+		errResp := &ErrorResponse{Type: msg.Header(":exception-type")}
+		if err := json.Unmarshal(msg.Payload, errResp); err != nil || errResp.Message == "" {
+			errResp.Message = string(msg.Payload)
+		}
+		return nil, adapter.NewStreamError(exceptionStatus(errResp.Type), errResp.Type, errResp)
+	}
+
+	switch msg.Header(":event-type") {
+	case "messageStart":
+		var event MessageStartEvent
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			return nil, nil
+		}
+		return s.chunk(adapter.MessageDelta{Role: event.Role}, nil, nil), nil
+
+	case "contentBlockStart":
+		var event ContentBlockStartEvent
+		if err := json.Unmarshal(msg.Payload, &event); err != nil || event.Start.ToolUse == nil {
+			return nil, nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[event.ContentBlockIndex] = index
+		return s.chunk(adapter.MessageDelta{ToolCalls: []adapter.ToolCallDelta{{
+			Index:    index,
+			ID:       event.Start.ToolUse.ToolUseID,
+			Type:     "function",
+			Function: adapter.FunctionCall{Name: event.Start.ToolUse.Name},
+		}}}, nil, nil), nil
+
+	case "contentBlockDelta":
+		var event ContentBlockDeltaEvent
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			return nil, nil
+		}
+		if event.Delta.ToolUse != nil {
+			index, ok := s.toolIndex[event.ContentBlockIndex]
+			if !ok || event.Delta.ToolUse.Input == "" {
+				return nil, nil
+			}
+			return s.chunk(adapter.MessageDelta{ToolCalls: []adapter.ToolCallDelta{{
+				Index:    index,
+				Function: adapter.FunctionCall{Arguments: event.Delta.ToolUse.Input},
+			}}}, nil, nil), nil
+		}
+		if event.Delta.Text == "" {
+			return nil, nil
+		}
+		return s.chunk(adapter.MessageDelta{Content: event.Delta.Text}, nil, nil), nil
+
+	case "messageStop":
+		var event MessageStopEvent
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			return nil, nil
+		}
+		finishReason := convertStopReason(event.StopReason)
+		s.stopReason = &finishReason
+		return nil, nil
+
+	case "metadata":
+		var event MetadataEvent
+		if err := json.Unmarshal(msg.Payload, &event); err != nil {
+			return nil, nil
+		}
+		usage := convertUsage(event.Usage)
+		s.done = true
+		return s.chunk(adapter.MessageDelta{}, s.stopReason, &usage), nil
+	}
+
+	return nil, nil
+}
+
+// flush 流结束但未收到 metadata 时返回携带 finish_reason 的结束块
+func (s *streamState) flush() *adapter.ChatStreamChunk {
+	if s.done || s.stopReason == nil {
+		return nil
+	}
+	s.done = true
+	return s.chunk(adapter.MessageDelta{}, s.stopReason, nil)
+}
+
+func (s *streamState) chunk(delta adapter.MessageDelta, finishReason *string, usage *adapter.Usage) *adapter.ChatStreamChunk {
+	return &adapter.ChatStreamChunk{
+		Model: s.model,
+		Choices: []adapter.StreamChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+		Usage: usage,
+	}
+}
+
+// ConvertChatRequest 将内部请求格式转换为 Converse 格式
+// system 消息转换为顶层 system 内容块，相邻的同角色消息合并为一条（Converse 要求角色交替）；
+// assistant 的 tool_calls 转换为 toolUse 内容块，tool 消息转换为 user 消息中的 toolResult 内容块
+func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any) *ConverseRequest {
+	converseReq := &ConverseRequest{
+		Messages: make([]Message, 0, len(req.Messages)),
+	}
+
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			converseReq.System = append(converseReq.System, ContentBlock{Text: msg.Content})
+			continue
+		}
+
+		role, blocks := convertMessage(msg)
+
+		if n := len(converseReq.Messages); n > 0 && converseReq.Messages[n-1].Role == role {
+			converseReq.Messages[n-1].Content = append(converseReq.Messages[n-1].Content, blocks...)
+			continue
+		}
+
+		converseReq.Messages = append(converseReq.Messages, Message{
+			Role:    role,
+			Content: blocks,
+		})
+	}
+
+	// Converse 不支持 tool_choice none，此时不下发工具
+	if len(req.Tools) > 0 && (req.ToolChoice == nil || req.ToolChoice.Type != adapter.ToolChoiceNone) {
+		converseReq.ToolConfig = convertTools(req.Tools, req.ToolChoice)
+	}
+
+	config := &InferenceConfig{}
+
+	// 应用默认配置（如果请求中未指定）
+	if req.Temperature != nil {
+		config.Temperature = req.Temperature
+	} else if temp, ok := defaultConfig["temperature"].(float64); ok {
+		config.Temperature = &temp
+	}
+
+	if req.MaxTokens != nil {
+		config.MaxTokens = req.MaxTokens
+	} else if maxTokens, ok := defaultConfig["max_tokens"].(float64); ok {
+		mt := int(maxTokens)
+		config.MaxTokens = &mt
+	}
+
+	if req.TopP != nil {
+		config.TopP = req.TopP
+	} else if topP, ok := defaultConfig["top_p"].(float64); ok {
+		config.TopP = &topP
+	}
+
+	config.StopSequences = req.Stop
+
+	if config.Temperature != nil || config.MaxTokens != nil || config.TopP != nil || len(config.StopSequences) > 0 {
+		converseReq.InferenceConfig = config
+	}
+
+	// 模型特有参数（如 Claude 的 top_k）原样透传
+	if fields, ok := defaultConfig["additional_model_request_fields"].(map[string]any); ok {
+		converseReq.AdditionalModelRequestFields = fields
+	}
+
+	return converseReq
+}
+
+// convertMessage 将单条消息转换为 Converse 角色与内容块
+func convertMessage(msg adapter.Message) (string, []ContentBlock) {
+	if msg.Role == "tool" {
+		return "user", []ContentBlock{{ToolResult: &ToolResultBlock{
+			ToolUseID: msg.ToolCallID,
+			Content:   []ContentBlock{{Text: msg.Content}},
+		}}}
+	}
+
+	var blocks []ContentBlock
+	switch {
+	case len(msg.Parts) > 0:
+		blocks = convertContentParts(msg.Parts)
+	case msg.Content != "" || len(msg.ToolCalls) == 0:
+		blocks = append(blocks, ContentBlock{Text: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		blocks = append(blocks, ContentBlock{ToolUse: &ToolUseBlock{
+			ToolUseID: call.ID,
+			Name:      call.Function.Name,
+			Input:     input,
+		}})
+	}
+	return msg.Role, blocks
+}
+
+// convertContentParts 将多模态内容片段转换为内容块（图片仅支持 Data URL）
+func convertContentParts(parts []adapter.ContentPart) []ContentBlock {
+	blocks := make([]ContentBlock, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case adapter.ContentPartText:
+			blocks = append(blocks, ContentBlock{Text: part.Text})
+		case adapter.ContentPartImageURL:
+			mediaType, data, ok := adapter.ParseDataURL(part.ImageURL.URL)
+			if !ok {
+				continue
+			}
+			blocks = append(blocks, ContentBlock{Image: &ImageBlock{
+				Format: strings.TrimPrefix(mediaType, "image/"),
+				Source: ImageSource{Bytes: data},
+			}})
+		}
+	}
+	return blocks
+}
+
+// convertTools 转换工具定义与选择策略
+func convertTools(tools []adapter.Tool, choice *adapter.ToolChoice) *ToolConfig {
+	config := &ToolConfig{Tools: make([]Tool, len(tools))}
+	for i, tool := range tools {
+		schema := tool.Function.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		config.Tools[i] = Tool{ToolSpec: ToolSpec{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: InputSchema{JSON: schema},
+		}}
+	}
+
+	if choice != nil {
+		switch choice.Type {
+		case adapter.ToolChoiceRequired:
+			config.ToolChoice = &ToolChoice{Any: &struct{}{}}
+		case adapter.ToolChoiceFunction:
+			config.ToolChoice = &ToolChoice{Tool: &SpecificToolChoice{Name: choice.FunctionName}}
+		default:
+			config.ToolChoice = &ToolChoice{Auto: &struct{}{}}
+		}
+	}
+	return config
+}
+
+// ConvertChatResponse 将 Converse 响应转换为内部格式
+func ConvertChatResponse(resp *ConverseResponse, modelID string) *adapter.ChatResponse {
+	var content strings.Builder
+	var toolCalls []adapter.ToolCall
+	for _, block := range resp.Output.Message.Content {
+		content.WriteString(block.Text)
+		if block.ToolUse != nil {
+			arguments := string(block.ToolUse.Input)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			toolCalls = append(toolCalls, adapter.ToolCall{
+				ID:       block.ToolUse.ToolUseID,
+				Type:     "function",
+				Function: adapter.FunctionCall{Name: block.ToolUse.Name, Arguments: arguments},
+			})
+		}
+	}
+
+	role := resp.Output.Message.Role
+	if role == "" {
+		role = "assistant"
+	}
+
+	return &adapter.ChatResponse{
+		Model: modelID,
+		Choices: []adapter.Choice{
+			{
+				Index: 0,
+				Message: adapter.Message{
+					Role:      role,
+					Content:   content.String(),
+					ToolCalls: toolCalls,
+				},
+				FinishReason: convertStopReason(resp.StopReason),
+			},
+		},
+		Usage: convertUsage(resp.Usage),
+	}
+}
+
+// convertUsage 转换 Token 使用量
+func convertUsage(usage TokenUsage) adapter.Usage {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.InputTokens + usage.OutputTokens
+	}
+	return adapter.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      total,
+	}
+}
+
+// convertStopReason 将 Converse stopReason 映射为 OpenAI finish_reason
+func convertStopReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence", "":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
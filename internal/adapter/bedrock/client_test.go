@@ -0,0 +1,370 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// encodeEvent 按 event-stream 格式编码消息（仅支持字符串类型头部）
+func encodeEvent(headers map[string]string, payload string) []byte {
+	var headerBuf bytes.Buffer
+	for name, value := range headers {
+		headerBuf.WriteByte(byte(len(name)))
+		headerBuf.WriteString(name)
+		headerBuf.WriteByte(headerTypeString)
+		binary.Write(&headerBuf, binary.BigEndian, uint16(len(value)))
+		headerBuf.WriteString(value)
+	}
+
+	totalLength := uint32(minMessageLength + headerBuf.Len() + len(payload))
+
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, totalLength)
+	binary.Write(&msg, binary.BigEndian, uint32(headerBuf.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(headerBuf.Bytes())
+	msg.WriteString(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+
+	return msg.Bytes()
+}
+
+func event(eventType, payload string) []byte {
+	return encodeEvent(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, payload)
+}
+
+func newTestAdapter(t *testing.T, baseURL string) adapter.Provider {
+	secret := "secret"
+	bedrockAdapter, err := NewAdapter(&model.Provider{
+		Name:    "bedrock",
+		Type:    "bedrock",
+		BaseURL: baseURL,
+		Timeout: 30,
+		APIKey:  &secret,
+		Enabled: true,
+		ExtraConfig: map[string]any{
+			"access_key_id": "AKIDTEST",
+			"region":        "us-west-2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	return bedrockAdapter
+}
+
+// TestEventStreamDecoder_Checksum 测试 CRC 校验失败
+func TestEventStreamDecoder_Checksum(t *testing.T) {
+	data := event("messageStart", `{"role":"assistant"}`)
+
+	msg, err := NewEventStreamDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Header(":event-type") != "messageStart" || string(msg.Payload) != `{"role":"assistant"}` {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	data[len(data)-6] ^= 0xFF // 篡改 payload
+	if _, err := NewEventStreamDecoder(bytes.NewReader(data)).Decode(); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+}
+
+// TestConvertChatRequest 测试 system 提取与同角色消息合并
+func TestConvertChatRequest(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []adapter.Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Hi"},
+			{Role: "user", Content: "What is Go?"},
+		},
+	}
+
+	result := ConvertChatRequest(req, map[string]any{
+		"max_tokens":                      256.0,
+		"additional_model_request_fields": map[string]any{"top_k": 50.0},
+	})
+
+	if len(result.System) != 1 || result.System[0].Text != "Be brief." {
+		t.Errorf("unexpected system: %+v", result.System)
+	}
+	if len(result.Messages) != 1 || len(result.Messages[0].Content) != 2 {
+		t.Errorf("expected merged user message, got %+v", result.Messages)
+	}
+	if result.InferenceConfig == nil || *result.InferenceConfig.MaxTokens != 256 {
+		t.Errorf("unexpected inferenceConfig: %+v", result.InferenceConfig)
+	}
+	if result.AdditionalModelRequestFields["top_k"] != 50.0 {
+		t.Errorf("unexpected additional fields: %+v", result.AdditionalModelRequestFields)
+	}
+}
+
+// TestAdapter_Chat_Success 测试 Converse 请求签名与响应转换
+func TestAdapter_Chat_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || !strings.Contains(auth, "/us-west-2/bedrock/aws4_request") {
+			t.Errorf("unexpected Authorization header: %s", auth)
+		}
+
+		var body ConverseRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.Messages[0].Content[0].Text != "Hello" {
+			t.Errorf("unexpected messages: %+v", body.Messages)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"output": {"message": {"role": "assistant", "content": [{"text": "Hello from Bedrock"}]}},
+			"stopReason": "max_tokens",
+			"usage": {"inputTokens": 10, "outputTokens": 4, "totalTokens": 14},
+			"metrics": {"latencyMs": 120}
+		}`))
+	}))
+	defer server.Close()
+
+	resp, err := newTestAdapter(t, server.URL).Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello from Bedrock" || resp.Choices[0].FinishReason != "length" {
+		t.Errorf("unexpected choice: %+v", resp.Choices[0])
+	}
+	if resp.Usage.PromptTokens != 10 || resp.Usage.TotalTokens != 14 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAdapter_Chat_Error 测试错误响应解析
+func TestAdapter_Chat_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amzn-ErrorType", "ValidationException:http://internal.amazon.com/coral/com.amazon.bedrock/")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"The provided model identifier is invalid."}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestAdapter(t, server.URL).Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "missing",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err == nil || err.Error() != "ValidationException: The provided model identifier is invalid." {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestAdapter_ChatStream_Success 测试 ConverseStream event-stream 解码
+func TestAdapter_ChatStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/converse-stream") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Write(event("messageStart", `{"role":"assistant"}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"lo"}}`))
+		w.Write(event("contentBlockStop", `{"contentBlockIndex":0}`))
+		w.Write(event("messageStop", `{"stopReason":"end_turn"}`))
+		w.Write(event("metadata", `{"usage":{"inputTokens":7,"outputTokens":2,"totalTokens":9},"metrics":{"latencyMs":80}}`))
+	}))
+	defer server.Close()
+
+	respChan, err := newTestAdapter(t, server.URL).ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "amazon.nova-lite-v1:0",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("expected role on first chunk, got %+v", chunks[0].Choices[0])
+	}
+	if chunks[1].Choices[0].Delta.Content+chunks[2].Choices[0].Delta.Content != "Hello" {
+		t.Error("unexpected streamed content")
+	}
+
+	last := chunks[3]
+	if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != "stop" {
+		t.Error("expected last chunk to have finish_reason=stop")
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 9 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
+
+// TestConvertChatRequest_Tools 测试 toolConfig、toolUse 与 toolResult 的转换
+func TestConvertChatRequest_Tools(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		Messages: []adapter.Message{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []adapter.ToolCall{
+				{ID: "tooluse_1", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallID: "tooluse_1", Content: "18C"},
+		},
+		Tools: []adapter.Tool{{Type: "function", Function: adapter.ToolFunction{
+			Name:       "get_weather",
+			Parameters: json.RawMessage(`{"type":"object"}`),
+		}}},
+		ToolChoice: &adapter.ToolChoice{Type: adapter.ToolChoiceRequired},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil))
+	expected := `{"messages":[{"role":"user","content":[{"text":"Weather in Paris?"}]},` +
+		`{"role":"assistant","content":[{"toolUse":{"toolUseId":"tooluse_1","name":"get_weather","input":{"city":"Paris"}}}]},` +
+		`{"role":"user","content":[{"toolResult":{"toolUseId":"tooluse_1","content":[{"text":"18C"}]}}]}],` +
+		`"toolConfig":{"tools":[{"toolSpec":{"name":"get_weather","inputSchema":{"json":{"type":"object"}}}}],"toolChoice":{"any":{}}}}`
+	if string(body) != expected {
+		t.Errorf("unexpected request:\n%s", body)
+	}
+}
+
+// TestAdapter_ChatStream_ToolUse 测试流式 toolUse 转换为 tool_calls 增量
+func TestAdapter_ChatStream_ToolUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Write(event("messageStart", `{"role":"assistant"}`))
+		w.Write(event("contentBlockStart", `{"contentBlockIndex":0,"start":{"toolUse":{"toolUseId":"tooluse_1","name":"get_weather"}}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"toolUse":{"input":"{\"city\":"}}}`))
+		w.Write(event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"toolUse":{"input":"\"Paris\"}"}}}`))
+		w.Write(event("contentBlockStop", `{"contentBlockIndex":0}`))
+		w.Write(event("messageStop", `{"stopReason":"tool_use"}`))
+		w.Write(event("metadata", `{"usage":{"inputTokens":7,"outputTokens":2,"totalTokens":9}}`))
+	}))
+	defer server.Close()
+
+	respChan, err := newTestAdapter(t, server.URL).ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		Messages: []adapter.Message{{Role: "user", Content: "Weather in Paris?"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 chunks, got %d", len(chunks))
+	}
+
+	start := chunks[1].Choices[0].Delta.ToolCalls
+	if len(start) != 1 || start[0].ID != "tooluse_1" || start[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected tool call start: %+v", start)
+	}
+
+	arguments := chunks[2].Choices[0].Delta.ToolCalls[0].Function.Arguments + chunks[3].Choices[0].Delta.ToolCalls[0].Function.Arguments
+	if arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected streamed arguments: %s", arguments)
+	}
+
+	if last := chunks[4]; last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != "tool_calls" {
+		t.Error("expected last chunk to have finish_reason=tool_calls")
+	}
+}
+
+// TestConvertChatRequest_Images 测试 Data URL 图片转换为 image 内容块
+func TestConvertChatRequest_Images(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "amazon.nova-lite-v1:0",
+		Messages: []adapter.Message{{Role: "user", Content: "Describe", Parts: []adapter.ContentPart{
+			{Type: adapter.ContentPartText, Text: "Describe"},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "data:image/jpeg;base64,/9j/4AAQ"}},
+		}}},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).Messages)
+	expected := `[{"role":"user","content":[{"text":"Describe"},{"image":{"format":"jpeg","source":{"bytes":"/9j/4AAQ"}}}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected messages:\n%s", body)
+	}
+
+	// 远程图片地址不支持
+	req.Messages[0].Parts[1].ImageURL.URL = "https://example.com/cat.jpg"
+	_, err := newTestAdapter(t, "http://127.0.0.1:0").Chat(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "data URLs") {
+		t.Errorf("expected unsupported remote image error, got %v", err)
+	}
+}
+
+// TestConvertChatRequest_SamplingParams 测试 top_p、stop 映射与不支持的参数
+func TestConvertChatRequest_SamplingParams(t *testing.T) {
+	topP := 0.5
+	req := &adapter.ChatRequest{
+		Model:    "amazon.nova-lite-v1:0",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		TopP:     &topP,
+		Stop:     []string{"END"},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, map[string]any{"top_p": 0.9}).InferenceConfig)
+	if string(body) != `{"topP":0.5,"stopSequences":["END"]}` {
+		t.Errorf("unexpected inferenceConfig: %s", body)
+	}
+
+	req.ResponseFormat = &adapter.ResponseFormat{Type: adapter.ResponseFormatJSONObject}
+	_, err := newTestAdapter(t, "http://127.0.0.1:0").Chat(context.Background(), req)
+	var paramErr *adapter.UnsupportedParameterError
+	if !errors.As(err, &paramErr) || paramErr.Parameter != adapter.ParamResponseFormat {
+		t.Errorf("expected UnsupportedParameterError for response_format, got %v", err)
+	}
+}
+
+// TestRegionFromURL 测试从 base_url 解析区域
+func TestRegionFromURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"https://bedrock-runtime.us-west-2.amazonaws.com", "us-west-2"},
+		{"https://bedrock-runtime-fips.us-east-1.amazonaws.com/", "us-east-1"},
+		{"http://127.0.0.1:4566", ""},
+	}
+
+	for _, tt := range tests {
+		if result := regionFromURL(tt.baseURL); result != tt.expected {
+			t.Errorf("regionFromURL(%q) = %q, want %q", tt.baseURL, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// AWS event-stream 二进制帧格式：
+//
+//	[total_length:4][headers_length:4][prelude_crc:4][headers][payload][message_crc:4]
+//
+// 所有整数为大端序，CRC 为 CRC32 (IEEE)
+const (
+	preludeLength    = 8
+	preludeCRCLength = 4
+	messageCRCLength = 4
+	minMessageLength = preludeLength + preludeCRCLength + messageCRCLength
+	maxMessageLength = 16 * 1024 * 1024
+)
+
+// 头部值类型
+const (
+	headerTypeBoolTrue  = 0
+	headerTypeBoolFalse = 1
+	headerTypeByte      = 2
+	headerTypeShort     = 3
+	headerTypeInt       = 4
+	headerTypeLong      = 5
+	headerTypeBytes     = 6
+	headerTypeString    = 7
+	headerTypeTimestamp = 8
+	headerTypeUUID      = 9
+)
+
+// EventMessage 解码后的 event-stream 消息
+// Headers 中字符串类型的值为 string，其余类型按对应 Go 类型存储
+type EventMessage struct {
+	Headers map[string]any
+	Payload []byte
+}
+
+// Header 返回字符串类型的头部值
+func (m *EventMessage) Header(name string) string {
+	if v, ok := m.Headers[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// EventStreamDecoder event-stream 解码器
+type EventStreamDecoder struct {
+	r io.Reader
+}
+
+// NewEventStreamDecoder 创建 event-stream 解码器
+func NewEventStreamDecoder(r io.Reader) *EventStreamDecoder {
+	return &EventStreamDecoder{r: r}
+}
+
+// Decode 读取并解码下一条消息，流结束时返回 io.EOF
+func (d *EventStreamDecoder) Decode() (*EventMessage, error) {
+	prelude := make([]byte, preludeLength+preludeCRCLength)
+	if _, err := io.ReadFull(d.r, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event-stream prelude")
+		}
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	preludeCRC := binary.BigEndian.Uint32(prelude[8:12])
+
+	if crc32.ChecksumIEEE(prelude[:preludeLength]) != preludeCRC {
+		return nil, fmt.Errorf("event-stream prelude checksum mismatch")
+	}
+	if totalLength < minMessageLength || totalLength > maxMessageLength {
+		return nil, fmt.Errorf("invalid event-stream message length %d", totalLength)
+	}
+	if headersLength > totalLength-minMessageLength {
+		return nil, fmt.Errorf("invalid event-stream headers length %d", headersLength)
+	}
+
+	rest := make([]byte, totalLength-uint32(len(prelude)))
+	if _, err := io.ReadFull(d.r, rest); err != nil {
+		return nil, fmt.Errorf("truncated event-stream message: %w", err)
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(rest[:len(rest)-messageCRCLength])
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-messageCRCLength:]) {
+		return nil, fmt.Errorf("event-stream message checksum mismatch")
+	}
+
+	headers, err := decodeHeaders(rest[:headersLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventMessage{
+		Headers: headers,
+		Payload: rest[headersLength : len(rest)-messageCRCLength],
+	}, nil
+}
+
+// decodeHeaders 解码头部区域
+func decodeHeaders(data []byte) (map[string]any, error) {
+	headers := make(map[string]any)
+
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, fmt.Errorf("truncated event-stream header")
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+
+		var value any
+		var size int
+		switch valueType {
+		case headerTypeBoolTrue:
+			value = true
+		case headerTypeBoolFalse:
+			value = false
+		case headerTypeByte:
+			size = 1
+		case headerTypeShort:
+			size = 2
+		case headerTypeInt:
+			size = 4
+		case headerTypeLong, headerTypeTimestamp:
+			size = 8
+		case headerTypeUUID:
+			size = 16
+		case headerTypeBytes, headerTypeString:
+			if len(data) < 2 {
+				return nil, fmt.Errorf("truncated event-stream header %q", name)
+			}
+			size = int(binary.BigEndian.Uint16(data[:2]))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("unknown event-stream header type %d", valueType)
+		}
+
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated event-stream header %q", name)
+		}
+		raw := data[:size]
+		data = data[size:]
+
+		switch valueType {
+		case headerTypeByte:
+			value = int8(raw[0])
+		case headerTypeShort:
+			value = int16(binary.BigEndian.Uint16(raw))
+		case headerTypeInt:
+			value = int32(binary.BigEndian.Uint32(raw))
+		case headerTypeLong, headerTypeTimestamp:
+			value = int64(binary.BigEndian.Uint64(raw))
+		case headerTypeBytes, headerTypeUUID:
+			value = append([]byte(nil), raw...)
+		case headerTypeString:
+			value = string(raw)
+		}
+
+		headers[name] = value
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,67 @@
+package bedrock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSigner_GetVanilla 使用 AWS SigV4 测试套件的 get-vanilla 用例验证签名
+func TestSigner_GetVanilla(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+
+	signer := &Signer{
+		Credentials: Credentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		Region:  "us-east-1",
+		Service: "service",
+	}
+	signer.Sign(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("unexpected Authorization header:\n got: %s\nwant: %s", got, expected)
+	}
+
+	if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+		t.Errorf("unexpected X-Amz-Date: %s", req.Header.Get("X-Amz-Date"))
+	}
+}
+
+// TestSigner_SessionToken 测试临时凭证参与签名
+func TestSigner_SessionToken(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://bedrock-runtime.us-west-2.amazonaws.com/model/a%3A0/converse", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace-ID", "trace-1")
+
+	signer := &Signer{
+		Credentials: Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"},
+		Region:      "us-west-2",
+		Service:     "bedrock",
+	}
+	signer.Sign(req, []byte(`{}`), time.Now())
+
+	if req.Header.Get("X-Amz-Security-Token") != "token" {
+		t.Error("expected X-Amz-Security-Token header")
+	}
+
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("unexpected signed headers: %s", req.Header.Get("Authorization"))
+	}
+}
+
+// TestCanonicalURI 测试路径二次编码
+func TestCanonicalURI(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse", nil)
+
+	expected := "/model/anthropic.claude-3-haiku-20240307-v1%253A0/converse"
+	if got := canonicalURI(req); got != expected {
+		t.Errorf("canonicalURI() = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,83 @@
+package adapter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UnsupportedContentError Provider 不支持的内容片段
+type UnsupportedContentError struct {
+	ProviderType string
+	PartType     string
+	Reason       string
+}
+
+func (e *UnsupportedContentError) Error() string {
+	msg := fmt.Sprintf("provider type %s does not support %s content", e.ProviderType, e.PartType)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// CheckContentParts 检查消息中的内容片段是否都在支持的类型中（文本片段始终支持）
+func CheckContentParts(providerType string, messages []Message, supported ...string) error {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type == ContentPartText || slices.Contains(supported, part.Type) {
+				continue
+			}
+			return &UnsupportedContentError{ProviderType: providerType, PartType: part.Type}
+		}
+	}
+	return nil
+}
+
+// CheckInlineImages 检查图片片段是否都是 Data URL（用于不支持远程图片地址的 Provider）
+func CheckInlineImages(providerType string, messages []Message) error {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if part.Type != ContentPartImageURL {
+				continue
+			}
+			if _, _, ok := ParseDataURL(part.ImageURL.URL); !ok {
+				return &UnsupportedContentError{
+					ProviderType: providerType,
+					PartType:     part.Type,
+					Reason:       "only base64 data URLs are supported",
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ParseDataURL 解析 data:<media_type>;base64,<data> 格式的 Data URL
+func ParseDataURL(url string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", "", false
+	}
+
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+
+	mediaType, found = strings.CutSuffix(meta, ";base64")
+	if !found || mediaType == "" {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+// AudioMediaType 将 input_audio.format 转换为 MIME 类型
+func AudioMediaType(format string) string {
+	switch format {
+	case "mp3":
+		return "audio/mpeg"
+	default:
+		return "audio/" + format
+	}
+}
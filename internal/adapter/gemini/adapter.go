@@ -0,0 +1,120 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// Adapter Google Gemini Adapter
+type Adapter struct {
+	config *adapter.ProviderConfig
+}
+
+// NewAdapter 创建 Gemini Adapter
+func NewAdapter(provider *model.Provider) (adapter.Provider, error) {
+	config := adapter.NewProviderConfig(provider)
+
+	// 验证配置
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("gemini adapter requires base_url")
+	}
+
+	return &Adapter{config: config}, nil
+}
+
+// Chat 完成对话调用（非流式）
+func (a *Adapter) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	if err := checkParameters(req); err != nil {
+		return nil, err
+	}
+
+	client := NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	geminiReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	// 发送请求
+	resp, err := client.DoGenerateContent(ctx, req.Model, geminiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换响应格式
+	return ConvertChatResponse(resp, req.Model), nil
+}
+
+// ChatStream 流式对话调用
+func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	if err := checkParameters(req); err != nil {
+		return nil, err
+	}
+
+	client := NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	geminiReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 创建响应 channel
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	// 启动 goroutine 处理流式请求
+	go func() {
+		defer close(respChan)
+
+		// 设置超时
+		streamCtx := ctx
+		if a.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+			defer cancel()
+		}
+
+		// 发送流式请求
+		if err := client.DoStreamGenerateContent(streamCtx, req.Model, geminiReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
+		}
+	}()
+
+	return respChan, nil
+}
+
+// checkParameters 检查 Gemini 支持的可选参数（不支持 logit_bias 和 OpenAI 格式的 logprobs）
+func checkParameters(req *adapter.ChatRequest) error {
+	return adapter.CheckParameters(string(adapter.AdapterTypeGemini), req,
+		adapter.ParamPresencePenalty, adapter.ParamFrequencyPenalty, adapter.ParamSeed, adapter.ParamResponseFormat)
+}
+
+// Type 返回 Provider 类型
+func (a *Adapter) Type() string {
+	return string(adapter.AdapterTypeGemini)
+}
+
+// Name 返回 Provider 实例名称
+func (a *Adapter) Name() string {
+	return a.config.Name
+}
+
+// Timeout 返回超时时间（秒）
+func (a *Adapter) Timeout() int {
+	return a.config.TimeoutSeconds
+}
+
+// Config 返回配置信息
+func (a *Adapter) Config() map[string]any {
+	return a.config.GetConfig()
+}
+
+func init() {
+	adapter.RegisterAdapterType(adapter.AdapterTypeGemini, NewAdapter)
+}
@@ -0,0 +1,704 @@
+package gemini
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// Client Gemini API 客户端
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient 创建 Gemini 客户端
+func NewClient(baseURL, apiKey string, timeout int) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// buildModelURL 构建模型方法 URL
+//   - https://generativelanguage.googleapis.com/v1beta + gemini-1.5-pro + generateContent
+//     -> https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent
+//
+// 模型名已包含 models/ 或 tunedModels/ 前缀时不再重复添加
+func buildModelURL(baseURL, modelName, method string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	if !strings.HasPrefix(modelName, "models/") && !strings.HasPrefix(modelName, "tunedModels/") {
+		modelName = "models/" + modelName
+	}
+
+	return baseURL + "/" + modelName + ":" + method
+}
+
+// GenerateContentRequest generateContent 请求格式
+type GenerateContentRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+	SafetySettings    []SafetySetting   `json:"safetySettings,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+}
+
+// Content 对话内容
+type Content struct {
+	Role  string `json:"role,omitempty"` // user, model
+	Parts []Part `json:"parts"`
+}
+
+// Part 内容片段
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+}
+
+// Blob 内联的 base64 数据（图片、音频）
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// FileData 通过 URI 引用的文件
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// FunctionCall 模型发起的函数调用
+type FunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// FunctionResponse 函数调用结果
+type FunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+}
+
+// FunctionDeclaration 函数声明
+type FunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolConfig 工具调用配置
+type ToolConfig struct {
+	FunctionCallingConfig FunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+// FunctionCallingConfig 函数调用模式：AUTO、ANY、NONE
+type FunctionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GenerationConfig 生成参数
+type GenerationConfig struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	TopK             *int     `json:"topK,omitempty"`
+	MaxOutputTokens  *int     `json:"maxOutputTokens,omitempty"`
+	CandidateCount   int      `json:"candidateCount,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`   // application/json 时输出 JSON
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"` // 约束输出的 JSON Schema
+}
+
+// SafetySetting 安全设置
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// GenerateContentResponse generateContent 响应格式（流式时每个事件一个）
+type GenerateContentResponse struct {
+	Candidates     []Candidate     `json:"candidates"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
+}
+
+// Candidate 候选结果
+type Candidate struct {
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+	Index        int     `json:"index"`
+}
+
+// PromptFeedback 输入被拦截时的反馈
+type PromptFeedback struct {
+	BlockReason string `json:"blockReason,omitempty"`
+}
+
+// UsageMetadata 使用量
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// ErrorResponse Gemini API 错误响应
+type ErrorResponse struct {
+	ErrorDetail ErrorDetail `json:"error"`
+}
+
+// ErrorDetail 错误详情
+type ErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+func (e *ErrorResponse) Error() string {
+	return e.ErrorDetail.Message
+}
+
+// DoGenerateContent 执行非流式请求
+func (c *Client) DoGenerateContent(ctx context.Context, modelName string, req *GenerateContentRequest) (*GenerateContentResponse, error) {
+	httpResp, err := c.do(ctx, buildModelURL(c.baseURL, modelName, "generateContent"), req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp GenerateContentResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// DoStreamGenerateContent 执行流式请求（streamGenerateContent?alt=sse）
+func (c *Client) DoStreamGenerateContent(ctx context.Context, modelName string, req *GenerateContentRequest, respChan chan<- *adapter.ChatStreamChunk) error {
+	url := buildModelURL(c.baseURL, modelName, "streamGenerateContent") + "?alt=sse"
+
+	httpResp, err := c.do(ctx, url, req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	state := &streamState{first: true}
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		var resp GenerateContentResponse
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			continue // 跳过无效数据
+		}
+
+		chunk := state.convert(&resp, modelName)
+
+		select {
+		case respChan <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return nil
+}
+
+// do 发送请求并检查 HTTP 状态码
+func (c *Client) do(ctx context.Context, url string, req *GenerateContentRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("x-goog-api-key", c.apiKey)
+	}
+
+	// TraceID 透传
+	if traceID := getTraceID(ctx); traceID != "" {
+		httpReq.Header.Set("X-Trace-ID", traceID)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	// 检查 HTTP 状态码
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		var errResp ErrorResponse
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.ErrorDetail.Message != "" {
+			return nil, adapter.NewUpstreamError(httpResp, respBody, &errResp, errResp.ErrorDetail.Status)
+		}
+		return nil, adapter.NewUpstreamError(httpResp, respBody, nil, "")
+	}
+
+	return httpResp, nil
+}
+
+// getTraceID 从 context 获取 TraceID
+func getTraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value("trace_id").(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// ConvertChatRequest 将内部请求格式转换为 Gemini 格式
+// system 消息合并为 systemInstruction，assistant 映射为 model，相邻同角色消息合并为一个 content；
+// tool_calls 转换为 functionCall，tool 消息转换为 functionResponse（函数名按 tool_call_id 查找）
+func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any) *GenerateContentRequest {
+	geminiReq := &GenerateContentRequest{
+		Contents: make([]Content, 0, len(req.Messages)),
+	}
+
+	var systemParts []Part
+	toolNames := make(map[string]string) // tool_call_id -> 函数名
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, Part{Text: msg.Content})
+			continue
+		}
+
+		role := convertRole(msg.Role)
+		parts := convertParts(msg, toolNames)
+
+		if n := len(geminiReq.Contents); n > 0 && geminiReq.Contents[n-1].Role == role {
+			geminiReq.Contents[n-1].Parts = append(geminiReq.Contents[n-1].Parts, parts...)
+			continue
+		}
+		geminiReq.Contents = append(geminiReq.Contents, Content{Role: role, Parts: parts})
+	}
+
+	if len(systemParts) > 0 {
+		geminiReq.SystemInstruction = &Content{Parts: systemParts}
+	}
+
+	// 生成参数，请求参数优先
+	config := &GenerationConfig{}
+	if req.Temperature != nil {
+		config.Temperature = req.Temperature
+	} else if temp, ok := defaultConfig["temperature"].(float64); ok {
+		config.Temperature = &temp
+	}
+
+	if req.MaxTokens != nil {
+		config.MaxOutputTokens = req.MaxTokens
+	} else if maxTokens, ok := defaultConfig["max_tokens"].(float64); ok {
+		val := int(maxTokens)
+		config.MaxOutputTokens = &val
+	}
+
+	if req.TopP != nil {
+		config.TopP = req.TopP
+	} else if topP, ok := defaultConfig["top_p"].(float64); ok {
+		config.TopP = &topP
+	}
+
+	if topK, ok := defaultConfig["top_k"].(float64); ok {
+		val := int(topK)
+		config.TopK = &val
+	}
+
+	if req.N > 1 {
+		config.CandidateCount = req.N
+	}
+	config.StopSequences = req.Stop
+	config.PresencePenalty = req.PresencePenalty
+	config.FrequencyPenalty = req.FrequencyPenalty
+	config.Seed = req.Seed
+
+	if format := req.ResponseFormat; format != nil && format.Type != adapter.ResponseFormatText {
+		config.ResponseMimeType = "application/json"
+		if format.JSONSchema != nil {
+			config.ResponseJSONSchema = format.JSONSchema.Schema
+		}
+	}
+
+	if !reflect.ValueOf(*config).IsZero() {
+		geminiReq.GenerationConfig = config
+	}
+
+	geminiReq.SafetySettings = parseSafetySettings(defaultConfig["safety_settings"])
+
+	if len(req.Tools) > 0 {
+		declarations := make([]FunctionDeclaration, len(req.Tools))
+		for i, tool := range req.Tools {
+			declarations[i] = FunctionDeclaration{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			}
+		}
+		geminiReq.Tools = []Tool{{FunctionDeclarations: declarations}}
+	}
+	if req.ToolChoice != nil {
+		geminiReq.ToolConfig = convertToolChoice(req.ToolChoice)
+	}
+
+	return geminiReq
+}
+
+// convertParts 将单条消息转换为内容片段
+func convertParts(msg adapter.Message, toolNames map[string]string) []Part {
+	if msg.Role == "tool" {
+		return []Part{{FunctionResponse: &FunctionResponse{
+			Name:     toolNames[msg.ToolCallID],
+			Response: functionResponse(msg.Content),
+		}}}
+	}
+
+	var parts []Part
+	switch {
+	case len(msg.Parts) > 0:
+		parts = convertContentParts(msg.Parts)
+	case msg.Content != "" || len(msg.ToolCalls) == 0:
+		parts = append(parts, Part{Text: msg.Content})
+	}
+	for _, call := range msg.ToolCalls {
+		toolNames[call.ID] = call.Function.Name
+		args := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(args) {
+			args = json.RawMessage("{}")
+		}
+		parts = append(parts, Part{FunctionCall: &FunctionCall{Name: call.Function.Name, Args: args}})
+	}
+	return parts
+}
+
+// convertContentParts 将多模态内容片段转换为 Gemini 片段
+// Data URL 图片与音频转换为 inlineData，其他图片地址转换为 fileData（MIME 类型按扩展名推断）
+func convertContentParts(contentParts []adapter.ContentPart) []Part {
+	parts := make([]Part, 0, len(contentParts))
+	for _, part := range contentParts {
+		switch part.Type {
+		case adapter.ContentPartText:
+			parts = append(parts, Part{Text: part.Text})
+		case adapter.ContentPartImageURL:
+			if mimeType, data, ok := adapter.ParseDataURL(part.ImageURL.URL); ok {
+				parts = append(parts, Part{InlineData: &Blob{MimeType: mimeType, Data: data}})
+				continue
+			}
+			parts = append(parts, Part{FileData: &FileData{
+				MimeType: guessMimeType(part.ImageURL.URL),
+				FileURI:  part.ImageURL.URL,
+			}})
+		case adapter.ContentPartInputAudio:
+			parts = append(parts, Part{InlineData: &Blob{
+				MimeType: adapter.AudioMediaType(part.InputAudio.Format),
+				Data:     part.InputAudio.Data,
+			}})
+		}
+	}
+	return parts
+}
+
+// guessMimeType 按 URL 路径的扩展名推断 MIME 类型，无法推断时返回空字符串
+func guessMimeType(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return mime.TypeByExtension(path.Ext(u.Path))
+}
+
+// functionResponse functionResponse.response 必须为 JSON 对象，其他内容包装为 {"content": ...}
+func functionResponse(content string) json.RawMessage {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return json.RawMessage(content)
+	}
+	wrapped, _ := json.Marshal(map[string]string{"content": content})
+	return wrapped
+}
+
+// convertToolChoice 将 tool_choice 映射为 functionCallingConfig
+func convertToolChoice(choice *adapter.ToolChoice) *ToolConfig {
+	config := FunctionCallingConfig{}
+	switch choice.Type {
+	case adapter.ToolChoiceNone:
+		config.Mode = "NONE"
+	case adapter.ToolChoiceRequired:
+		config.Mode = "ANY"
+	case adapter.ToolChoiceFunction:
+		config.Mode = "ANY"
+		config.AllowedFunctionNames = []string{choice.FunctionName}
+	default:
+		config.Mode = "AUTO"
+	}
+	return &ToolConfig{FunctionCallingConfig: config}
+}
+
+// convertRole 将 OpenAI 角色映射为 Gemini 角色
+func convertRole(role string) string {
+	if role == "assistant" {
+		return "model"
+	}
+	return "user"
+}
+
+// parseSafetySettings 解析 extra_config.safety_settings
+// 格式：[{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"}]
+func parseSafetySettings(raw any) []SafetySetting {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+
+	settings := make([]SafetySetting, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		category, _ := m["category"].(string)
+		threshold, _ := m["threshold"].(string)
+		if category == "" || threshold == "" {
+			continue
+		}
+		settings = append(settings, SafetySetting{Category: category, Threshold: threshold})
+	}
+	return settings
+}
+
+// ConvertChatResponse 将 Gemini 响应转换为内部格式
+// 输入被安全策略拦截时返回空内容且 finish_reason 为 content_filter 的选项
+func ConvertChatResponse(resp *GenerateContentResponse, modelName string) *adapter.ChatResponse {
+	result := &adapter.ChatResponse{
+		ID:    resp.ResponseID,
+		Model: responseModel(resp, modelName),
+		Usage: convertUsage(resp.UsageMetadata),
+	}
+
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			result.Choices = []adapter.Choice{
+				{
+					Index:        0,
+					Message:      adapter.Message{Role: "assistant"},
+					FinishReason: "content_filter",
+				},
+			}
+		}
+		return result
+	}
+
+	result.Choices = make([]adapter.Choice, len(resp.Candidates))
+	for i, candidate := range resp.Candidates {
+		toolCalls := extractToolCalls(candidate.Content.Parts)
+		finishReason := convertFinishReason(candidate.FinishReason)
+		if len(toolCalls) > 0 && finishReason == "stop" {
+			finishReason = "tool_calls"
+		}
+
+		result.Choices[i] = adapter.Choice{
+			Index: candidate.Index,
+			Message: adapter.Message{
+				Role:      "assistant",
+				Content:   joinParts(candidate.Content.Parts),
+				ToolCalls: toolCalls,
+			},
+			FinishReason: finishReason,
+		}
+	}
+
+	return result
+}
+
+// extractToolCalls 提取 functionCall 片段，Gemini 未返回 ID 时生成一个
+func extractToolCalls(parts []Part) []adapter.ToolCall {
+	var toolCalls []adapter.ToolCall
+	for _, p := range parts {
+		if p.FunctionCall == nil {
+			continue
+		}
+
+		id := p.FunctionCall.ID
+		if id == "" {
+			id = "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+		}
+		args := string(p.FunctionCall.Args)
+		if args == "" {
+			args = "{}"
+		}
+
+		toolCalls = append(toolCalls, adapter.ToolCall{
+			ID:       id,
+			Type:     "function",
+			Function: adapter.FunctionCall{Name: p.FunctionCall.Name, Arguments: args},
+		})
+	}
+	return toolCalls
+}
+
+// streamState 流式转换状态
+type streamState struct {
+	first     bool
+	toolCalls int // 已下发的 tool_calls 数量，用作增量索引
+}
+
+// convert 将流式事件转换为内部格式
+// Gemini 在单个事件中返回完整的 functionCall，转换为一次性包含全部参数的增量
+func (s *streamState) convert(resp *GenerateContentResponse, modelName string) *adapter.ChatStreamChunk {
+	first := s.first
+	s.first = false
+
+	chunk := &adapter.ChatStreamChunk{
+		ID:    resp.ResponseID,
+		Model: responseModel(resp, modelName),
+	}
+
+	if len(resp.Candidates) == 0 {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			finishReason := "content_filter"
+			chunk.Choices = []adapter.StreamChoice{{Index: 0, FinishReason: &finishReason}}
+			usage := convertUsage(resp.UsageMetadata)
+			chunk.Usage = &usage
+		}
+		return chunk
+	}
+
+	chunk.Choices = make([]adapter.StreamChoice, len(resp.Candidates))
+	for i, candidate := range resp.Candidates {
+		delta := adapter.MessageDelta{Content: joinParts(candidate.Content.Parts)}
+		if first {
+			delta.Role = "assistant"
+		}
+		for _, call := range extractToolCalls(candidate.Content.Parts) {
+			delta.ToolCalls = append(delta.ToolCalls, adapter.ToolCallDelta{
+				Index:    s.toolCalls,
+				ID:       call.ID,
+				Type:     call.Type,
+				Function: call.Function,
+			})
+			s.toolCalls++
+		}
+		chunk.Choices[i] = adapter.StreamChoice{Index: candidate.Index, Delta: delta}
+
+		if candidate.FinishReason != "" {
+			finishReason := convertFinishReason(candidate.FinishReason)
+			if s.toolCalls > 0 && finishReason == "stop" {
+				finishReason = "tool_calls"
+			}
+			chunk.Choices[i].FinishReason = &finishReason
+
+			// usageMetadata 为累计值，仅在结束时下发
+			usage := convertUsage(resp.UsageMetadata)
+			chunk.Usage = &usage
+		}
+	}
+
+	return chunk
+}
+
+// responseModel 优先使用响应中的 modelVersion
+func responseModel(resp *GenerateContentResponse, modelName string) string {
+	if resp.ModelVersion != "" {
+		return resp.ModelVersion
+	}
+	return modelName
+}
+
+// joinParts 拼接文本片段
+func joinParts(parts []Part) string {
+	var sb strings.Builder
+	for _, p := range parts {
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
+
+// convertUsage usageMetadata 映射为 Usage
+func convertUsage(meta *UsageMetadata) adapter.Usage {
+	if meta == nil {
+		return adapter.Usage{}
+	}
+
+	completion := meta.CandidatesTokenCount
+	total := meta.TotalTokenCount
+	if total == 0 {
+		total = meta.PromptTokenCount + completion
+	}
+
+	return adapter.Usage{
+		PromptTokens:     meta.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
+
+// convertFinishReason 将 finishReason 映射为 OpenAI finish_reason
+func convertFinishReason(reason string) string {
+	switch reason {
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	case "":
+		return ""
+	default:
+		return strings.ToLower(reason)
+	}
+}
@@ -0,0 +1,396 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestConvertChatRequest_Roles 测试角色映射与 systemInstruction
+func TestConvertChatRequest_Roles(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "gemini-1.5-pro",
+		Messages: []adapter.Message{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "user", Content: "Hi"},
+			{Role: "assistant", Content: "Hello!"},
+			{Role: "user", Content: "How are you?"},
+			{Role: "user", Content: "Answer briefly."},
+		},
+	}
+
+	defaultConfig := map[string]any{
+		"safety_settings": []any{
+			map[string]any{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"},
+		},
+	}
+
+	result := ConvertChatRequest(req, defaultConfig)
+
+	if result.SystemInstruction == nil || result.SystemInstruction.Parts[0].Text != "You are helpful." {
+		t.Errorf("unexpected systemInstruction: %+v", result.SystemInstruction)
+	}
+
+	// 相邻的两条 user 消息合并为一个 content
+	if len(result.Contents) != 3 {
+		t.Fatalf("expected 3 contents, got %d", len(result.Contents))
+	}
+
+	if result.Contents[1].Role != "model" {
+		t.Errorf("expected assistant mapped to model, got %s", result.Contents[1].Role)
+	}
+
+	if len(result.Contents[2].Parts) != 2 {
+		t.Errorf("expected merged user content with 2 parts, got %d", len(result.Contents[2].Parts))
+	}
+
+	if len(result.SafetySettings) != 1 || result.SafetySettings[0].Threshold != "BLOCK_ONLY_HIGH" {
+		t.Errorf("unexpected safety settings: %+v", result.SafetySettings)
+	}
+
+	if result.GenerationConfig != nil {
+		t.Errorf("expected no generationConfig, got %+v", result.GenerationConfig)
+	}
+}
+
+// TestConvertChatResponse_PromptBlocked 测试输入被安全策略拦截
+func TestConvertChatResponse_PromptBlocked(t *testing.T) {
+	resp := &GenerateContentResponse{
+		PromptFeedback: &PromptFeedback{BlockReason: "SAFETY"},
+		UsageMetadata:  &UsageMetadata{PromptTokenCount: 8, TotalTokenCount: 8},
+	}
+
+	result := ConvertChatResponse(resp, "gemini-1.5-pro")
+
+	if len(result.Choices) != 1 || result.Choices[0].FinishReason != "content_filter" {
+		t.Fatalf("expected content_filter choice, got %+v", result.Choices)
+	}
+
+	if result.Usage.PromptTokens != 8 {
+		t.Errorf("expected prompt_tokens 8, got %d", result.Usage.PromptTokens)
+	}
+}
+
+// TestAdapter_Chat_Success 测试非流式请求
+func TestAdapter_Chat_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-1.5-pro:generateContent" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("expected x-goog-api-key test-key, got %s", r.Header.Get("x-goog-api-key"))
+		}
+
+		var body GenerateContentRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body.GenerationConfig == nil || *body.GenerationConfig.MaxOutputTokens != 100 {
+			t.Errorf("expected maxOutputTokens 100, got %+v", body.GenerationConfig)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"candidates": [{
+				"content": {"role": "model", "parts": [{"text": "Hello from Gemini"}]},
+				"finishReason": "STOP",
+				"index": 0
+			}],
+			"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 4, "totalTokenCount": 9},
+			"modelVersion": "gemini-1.5-pro-002",
+			"responseId": "resp-1"
+		}`))
+	}))
+	defer server.Close()
+
+	apiKey := "test-key"
+	geminiAdapter, err := NewAdapter(&model.Provider{
+		Name:    "gemini",
+		Type:    "gemini",
+		BaseURL: server.URL + "/v1beta",
+		Timeout: 30,
+		APIKey:  &apiKey,
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	maxTokens := 100
+	resp, err := geminiAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:     "gemini-1.5-pro",
+		Messages:  []adapter.Message{{Role: "user", Content: "Hello"}},
+		MaxTokens: &maxTokens,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello from Gemini" || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("unexpected choice: %+v", resp.Choices[0])
+	}
+
+	if resp.Model != "gemini-1.5-pro-002" {
+		t.Errorf("expected model gemini-1.5-pro-002, got %s", resp.Model)
+	}
+
+	if resp.Usage.TotalTokens != 9 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAdapter_Chat_Error 测试错误响应
+func TestAdapter_Chat_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`))
+	}))
+	defer server.Close()
+
+	geminiAdapter, _ := NewAdapter(&model.Provider{Name: "gemini", Type: "gemini", BaseURL: server.URL})
+
+	_, err := geminiAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "gemini-1.5-pro",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "API key not valid") {
+		t.Errorf("expected API key error, got %v", err)
+	}
+}
+
+// TestAdapter_ChatStream_Safety 测试流式响应与安全拦截映射
+func TestAdapter_ChatStream_Safety(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		events := []string{
+			`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Once"}]},"index":0}],"usageMetadata":{"promptTokenCount":6,"candidatesTokenCount":1,"totalTokenCount":7}}`,
+			`data: {"candidates":[{"content":{"role":"model","parts":[{"text":" upon"}]},"index":0}],"usageMetadata":{"promptTokenCount":6,"candidatesTokenCount":2,"totalTokenCount":8}}`,
+			`data: {"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"SAFETY","index":0}],"usageMetadata":{"promptTokenCount":6,"candidatesTokenCount":2,"totalTokenCount":8}}`,
+		}
+
+		for _, event := range events {
+			w.Write([]byte(event + "\r\n\r\n"))
+		}
+	}))
+	defer server.Close()
+
+	geminiAdapter, _ := NewAdapter(&model.Provider{Name: "gemini", Type: "gemini", BaseURL: server.URL})
+
+	respChan, err := geminiAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapter.Message{{Role: "user", Content: "Tell me a story"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" || chunks[0].Choices[0].Delta.Content != "Once" {
+		t.Errorf("unexpected first chunk: %+v", chunks[0].Choices[0])
+	}
+
+	if chunks[0].Usage != nil {
+		t.Error("expected usage only on final chunk")
+	}
+
+	last := chunks[2]
+	if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != "content_filter" {
+		t.Error("expected final chunk to have finish_reason=content_filter")
+	}
+
+	if last.Usage == nil || last.Usage.TotalTokens != 8 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
+
+// TestConvertChatRequest_Tools 测试函数声明、functionCall 与 functionResponse 的转换
+func TestConvertChatRequest_Tools(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "gemini-1.5-pro",
+		Messages: []adapter.Message{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []adapter.ToolCall{
+				{ID: "call_1", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+		},
+		Tools: []adapter.Tool{{Type: "function", Function: adapter.ToolFunction{
+			Name:       "get_weather",
+			Parameters: json.RawMessage(`{"type":"object"}`),
+		}}},
+		ToolChoice: &adapter.ToolChoice{Type: adapter.ToolChoiceFunction, FunctionName: "get_weather"},
+	}
+
+	result := ConvertChatRequest(req, nil)
+
+	if len(result.Tools) != 1 || len(result.Tools[0].FunctionDeclarations) != 1 || result.Tools[0].FunctionDeclarations[0].Name != "get_weather" {
+		t.Errorf("unexpected tools: %+v", result.Tools)
+	}
+
+	config := result.ToolConfig
+	if config == nil || config.FunctionCallingConfig.Mode != "ANY" || len(config.FunctionCallingConfig.AllowedFunctionNames) != 1 {
+		t.Errorf("unexpected toolConfig: %+v", config)
+	}
+
+	body, _ := json.Marshal(result.Contents[1:])
+	expected := `[{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]},` +
+		`{"role":"user","parts":[{"functionResponse":{"name":"get_weather","response":{"content":"18C"}}}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected contents:\n%s", body)
+	}
+}
+
+// TestConvertChatResponse_FunctionCall 测试 functionCall 转换为 tool_calls
+func TestConvertChatResponse_FunctionCall(t *testing.T) {
+	var resp GenerateContentResponse
+	data := `{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]},"finishReason":"STOP","index":0}]}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	result := ConvertChatResponse(&resp, "gemini-1.5-pro")
+
+	choice := result.Choices[0]
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %s", choice.FinishReason)
+	}
+
+	if len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(choice.Message.ToolCalls))
+	}
+
+	call := choice.Message.ToolCalls[0]
+	if !strings.HasPrefix(call.ID, "call_") || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+}
+
+// TestConvertChatRequest_Multimodal 测试图片与音频片段转换为 inlineData / fileData
+func TestConvertChatRequest_Multimodal(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "gemini-1.5-pro",
+		Messages: []adapter.Message{{Role: "user", Content: "What is this?", Parts: []adapter.ContentPart{
+			{Type: adapter.ContentPartText, Text: "What is this?"},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "data:image/jpeg;base64,/9j/4AAQ"}},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "https://storage.googleapis.com/bucket/cat.png?x=1"}},
+			{Type: adapter.ContentPartInputAudio, InputAudio: &adapter.InputAudio{Data: "SUQz", Format: "mp3"}},
+		}}},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).Contents)
+	expected := `[{"role":"user","parts":[{"text":"What is this?"},` +
+		`{"inlineData":{"mimeType":"image/jpeg","data":"/9j/4AAQ"}},` +
+		`{"fileData":{"mimeType":"image/png","fileUri":"https://storage.googleapis.com/bucket/cat.png?x=1"}},` +
+		`{"inlineData":{"mimeType":"audio/mpeg","data":"SUQz"}}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected contents:\n%s", body)
+	}
+}
+
+// TestConvertChatRequest_SamplingParams 测试采样参数与结构化输出映射
+func TestConvertChatRequest_SamplingParams(t *testing.T) {
+	topP := 0.5
+	penalty := 0.3
+	seed := int64(42)
+	req := &adapter.ChatRequest{
+		Model:            "gemini-1.5-pro",
+		Messages:         []adapter.Message{{Role: "user", Content: "Hi"}},
+		TopP:             &topP,
+		N:                2,
+		Stop:             []string{"END"},
+		PresencePenalty:  &penalty,
+		FrequencyPenalty: &penalty,
+		Seed:             &seed,
+		ResponseFormat: &adapter.ResponseFormat{
+			Type:       adapter.ResponseFormatJSONSchema,
+			JSONSchema: &adapter.JSONSchema{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`)},
+		},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).GenerationConfig)
+	expected := `{"topP":0.5,"candidateCount":2,"stopSequences":["END"],"presencePenalty":0.3,"frequencyPenalty":0.3,"seed":42,` +
+		`"responseMimeType":"application/json","responseJsonSchema":{"type":"object"}}`
+	if string(body) != expected {
+		t.Errorf("unexpected generationConfig:\n%s", body)
+	}
+
+	// 未设置任何参数时不发送 generationConfig
+	if cfg := ConvertChatRequest(&adapter.ChatRequest{Messages: req.Messages}, nil).GenerationConfig; cfg != nil {
+		t.Errorf("expected nil generationConfig, got %+v", cfg)
+	}
+}
+
+// TestConvertChatResponse_MultipleCandidates 测试多个候选结果
+func TestConvertChatResponse_MultipleCandidates(t *testing.T) {
+	var resp GenerateContentResponse
+	data := `{"candidates":[` +
+		`{"index":0,"content":{"role":"model","parts":[{"text":"a"}]},"finishReason":"STOP"},` +
+		`{"index":1,"content":{"role":"model","parts":[{"text":"b"}]},"finishReason":"STOP"}]}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	result := ConvertChatResponse(&resp, "gemini-1.5-pro")
+	if len(result.Choices) != 2 || result.Choices[1].Index != 1 || result.Choices[1].Message.Content != "b" {
+		t.Errorf("unexpected choices: %+v", result.Choices)
+	}
+}
+
+// TestAdapter_Chat_UnsupportedParameter 测试不支持的参数
+func TestAdapter_Chat_UnsupportedParameter(t *testing.T) {
+	geminiAdapter, err := NewAdapter(&model.Provider{Name: "gemini", Type: "gemini", BaseURL: "http://127.0.0.1:0", Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	_, err = geminiAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:     "gemini-1.5-pro",
+		Messages:  []adapter.Message{{Role: "user", Content: "Hi"}},
+		LogitBias: map[string]float64{"1": 10},
+	})
+
+	var paramErr *adapter.UnsupportedParameterError
+	if !errors.As(err, &paramErr) || paramErr.Parameter != adapter.ParamLogitBias {
+		t.Errorf("expected UnsupportedParameterError for logit_bias, got %v", err)
+	}
+}
+
+// TestBuildModelURL 测试 buildModelURL 函数
+func TestBuildModelURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		model    string
+		expected string
+	}{
+		{"https://generativelanguage.googleapis.com/v1beta", "gemini-1.5-pro", "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent"},
+		{"https://generativelanguage.googleapis.com/v1beta/", "models/gemini-1.5-pro", "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent"},
+		{"https://generativelanguage.googleapis.com/v1beta", "tunedModels/my-model", "https://generativelanguage.googleapis.com/v1beta/tunedModels/my-model:generateContent"},
+	}
+
+	for _, tt := range tests {
+		if result := buildModelURL(tt.baseURL, tt.model, "generateContent"); result != tt.expected {
+			t.Errorf("buildModelURL(%q, %q) = %q, want %q", tt.baseURL, tt.model, result, tt.expected)
+		}
+	}
+}
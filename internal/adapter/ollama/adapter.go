@@ -0,0 +1,150 @@
+package ollama
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// Adapter Ollama Adapter（原生 /api/chat 协议）
+type Adapter struct {
+	config *adapter.ProviderConfig
+}
+
+// NewAdapter 创建 Ollama Adapter
+func NewAdapter(provider *model.Provider) (adapter.Provider, error) {
+	config := adapter.NewProviderConfig(provider)
+
+	// 验证配置
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("ollama adapter requires base_url")
+	}
+
+	// Ollama 不需要 API Key
+	return &Adapter{config: config}, nil
+}
+
+// Chat 完成对话调用（非流式）
+func (a *Adapter) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	if err := checkContentParts(req.Messages); err != nil {
+		return nil, err
+	}
+	if err := checkParameters(req); err != nil {
+		return nil, err
+	}
+
+	// /api/chat 每次只返回一个结果，n > 1 时并发请求
+	return adapter.FanOutChoices(ctx, a.Type(), req, a.chat)
+}
+
+// chat 发送单次 /api/chat 请求
+func (a *Adapter) chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	client := NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	ollamaReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	// 发送请求
+	resp, err := client.DoChatRequest(ctx, ollamaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换响应格式
+	return ConvertChatResponse(resp), nil
+}
+
+// ChatStream 流式对话调用
+func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	if err := checkContentParts(req.Messages); err != nil {
+		return nil, err
+	}
+	if err := checkParameters(req); err != nil {
+		return nil, err
+	}
+	if err := adapter.CheckStreamChoices(a.Type(), req); err != nil {
+		return nil, err
+	}
+
+	client := NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+
+	// 转换请求格式
+	ollamaReq := ConvertChatRequest(req, a.config.ExtraConfig)
+
+	// 创建响应 channel
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	// 启动 goroutine 处理流式请求
+	go func() {
+		defer close(respChan)
+
+		// 设置超时
+		streamCtx := ctx
+		if a.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+			defer cancel()
+		}
+
+		// 发送流式请求
+		if err := client.DoChatStreamRequest(streamCtx, ollamaReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
+		}
+	}()
+
+	return respChan, nil
+}
+
+// ListModels 查询 Ollama 已安装的模型（/api/tags）
+func (a *Adapter) ListModels(ctx context.Context) ([]string, error) {
+	client := NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+	return client.ListModels(ctx)
+}
+
+// Type 返回 Provider 类型
+func (a *Adapter) Type() string {
+	return string(adapter.AdapterTypeOllama)
+}
+
+// Name 返回 Provider 实例名称
+func (a *Adapter) Name() string {
+	return a.config.Name
+}
+
+// Timeout 返回超时时间（秒）
+func (a *Adapter) Timeout() int {
+	return a.config.TimeoutSeconds
+}
+
+// Config 返回配置信息
+func (a *Adapter) Config() map[string]any {
+	return a.config.GetConfig()
+}
+
+// checkContentParts 仅支持 Data URL 格式的图片，不支持音频
+func checkContentParts(messages []adapter.Message) error {
+	if err := adapter.CheckContentParts(string(adapter.AdapterTypeOllama), messages, adapter.ContentPartImageURL); err != nil {
+		return err
+	}
+	return adapter.CheckInlineImages(string(adapter.AdapterTypeOllama), messages)
+}
+
+// checkParameters 检查 Ollama 支持的可选参数（不支持 logit_bias 和 logprobs）
+func checkParameters(req *adapter.ChatRequest) error {
+	return adapter.CheckParameters(string(adapter.AdapterTypeOllama), req,
+		adapter.ParamPresencePenalty, adapter.ParamFrequencyPenalty, adapter.ParamSeed, adapter.ParamResponseFormat)
+}
+
+func init() {
+	adapter.RegisterAdapterType(adapter.AdapterTypeOllama, NewAdapter)
+}
@@ -0,0 +1,368 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestConvertChatRequest_Options 测试 extra_config 到 options 的映射
+func TestConvertChatRequest_Options(t *testing.T) {
+	defaultConfig := map[string]any{
+		"num_ctx":     8192.0,
+		"temperature": 0.6,
+		"max_tokens":  512.0,
+		"keep_alive":  "10m",
+		"options": map[string]any{
+			"top_k": 20.0,
+		},
+	}
+
+	temp := 0.2
+	req := &adapter.ChatRequest{
+		Model:       "llama3.1:8b",
+		Messages:    []adapter.Message{{Role: "user", Content: "Hello"}},
+		Temperature: &temp,
+	}
+
+	result := ConvertChatRequest(req, defaultConfig)
+
+	if result.Options["num_ctx"] != 8192.0 {
+		t.Errorf("expected num_ctx 8192, got %v", result.Options["num_ctx"])
+	}
+	if result.Options["top_k"] != 20.0 {
+		t.Errorf("expected top_k 20 from options, got %v", result.Options["top_k"])
+	}
+	if result.Options["num_predict"] != 512.0 {
+		t.Errorf("expected num_predict 512 from max_tokens, got %v", result.Options["num_predict"])
+	}
+	// 请求参数优先
+	if result.Options["temperature"] != 0.2 {
+		t.Errorf("expected temperature 0.2 from request, got %v", result.Options["temperature"])
+	}
+	if result.KeepAlive != "10m" {
+		t.Errorf("expected keep_alive 10m, got %v", result.KeepAlive)
+	}
+}
+
+// TestConvertChatRequest_SamplingParams 测试采样参数与 format 映射
+func TestConvertChatRequest_SamplingParams(t *testing.T) {
+	topP := 0.5
+	seed := int64(42)
+	req := &adapter.ChatRequest{
+		Model:          "llama3.1:8b",
+		Messages:       []adapter.Message{{Role: "user", Content: "Hello"}},
+		TopP:           &topP,
+		Stop:           []string{"END"},
+		Seed:           &seed,
+		ResponseFormat: &adapter.ResponseFormat{Type: adapter.ResponseFormatJSONObject},
+	}
+
+	result := ConvertChatRequest(req, map[string]any{"top_p": 0.9})
+	if result.Options["top_p"] != 0.5 {
+		t.Errorf("expected top_p 0.5 from request, got %v", result.Options["top_p"])
+	}
+	if stop, _ := result.Options["stop"].([]string); len(stop) != 1 || stop[0] != "END" {
+		t.Errorf("unexpected stop: %v", result.Options["stop"])
+	}
+	if result.Options["seed"] != int64(42) {
+		t.Errorf("expected seed 42, got %v", result.Options["seed"])
+	}
+	if result.Format != "json" {
+		t.Errorf("expected format json, got %v", result.Format)
+	}
+
+	req.ResponseFormat = &adapter.ResponseFormat{
+		Type:       adapter.ResponseFormatJSONSchema,
+		JSONSchema: &adapter.JSONSchema{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`)},
+	}
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).Format)
+	if string(body) != `{"type":"object"}` {
+		t.Errorf("expected schema format, got %s", body)
+	}
+}
+
+// TestAdapter_Chat_MultipleChoices 测试 n > 1 时并发请求并合并结果
+func TestAdapter_Chat_MultipleChoices(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"llama3.1:8b","message":{"role":"assistant","content":"Hi"},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":2}`))
+	}))
+	defer server.Close()
+
+	ollamaAdapter, err := NewAdapter(&model.Provider{Name: "ollama-dev", Type: "ollama", BaseURL: server.URL, Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := ollamaAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		N:        2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 upstream requests, got %d", requests.Load())
+	}
+	if len(resp.Choices) != 2 || resp.Choices[1].Index != 1 {
+		t.Errorf("unexpected choices: %+v", resp.Choices)
+	}
+	if resp.Usage.TotalTokens != 14 {
+		t.Errorf("expected total_tokens 14, got %d", resp.Usage.TotalTokens)
+	}
+
+	// 不支持的参数
+	_, err = ollamaAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+		Logprobs: true,
+	})
+	var paramErr *adapter.UnsupportedParameterError
+	if !errors.As(err, &paramErr) || paramErr.Parameter != adapter.ParamLogprobs {
+		t.Errorf("expected UnsupportedParameterError for logprobs, got %v", err)
+	}
+}
+
+// TestAdapter_Chat_Success 测试非流式请求
+func TestAdapter_Chat_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("expected path /api/chat, got %s", r.URL.Path)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if body["stream"] != false {
+			t.Errorf("expected explicit stream=false, got %v", body["stream"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"model": "llama3.1:8b",
+			"created_at": "2024-07-22T20:33:28.123648Z",
+			"message": {"role": "assistant", "content": "Hello from Ollama!"},
+			"done": true,
+			"done_reason": "stop",
+			"prompt_eval_count": 26,
+			"eval_count": 7
+		}`))
+	}))
+	defer server.Close()
+
+	provider := &model.Provider{
+		Name:    "ollama-dev",
+		Type:    "ollama",
+		BaseURL: server.URL,
+		Timeout: 30,
+		Enabled: true,
+	}
+
+	ollamaAdapter, err := NewAdapter(provider)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	resp, err := ollamaAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello from Ollama!" {
+		t.Errorf("unexpected content: %s", resp.Choices[0].Message.Content)
+	}
+
+	if resp.Usage.PromptTokens != 26 || resp.Usage.CompletionTokens != 7 || resp.Usage.TotalTokens != 33 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAdapter_Chat_Error 测试模型不存在等错误
+func TestAdapter_Chat_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	ollamaAdapter, _ := NewAdapter(&model.Provider{Name: "ollama-dev", Type: "ollama", BaseURL: server.URL})
+
+	_, err := ollamaAdapter.Chat(context.Background(), &adapter.ChatRequest{
+		Model:    "missing",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// TestAdapter_ChatStream_Success 测试 NDJSON 流式响应
+func TestAdapter_ChatStream_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+
+		lines := []string{
+			`{"model":"llama3.1:8b","created_at":"2024-07-22T20:33:28Z","message":{"role":"assistant","content":"Hel"},"done":false}`,
+			`{"model":"llama3.1:8b","created_at":"2024-07-22T20:33:28Z","message":{"role":"assistant","content":"lo"},"done":false}`,
+			`{"model":"llama3.1:8b","created_at":"2024-07-22T20:33:29Z","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":12,"eval_count":2}`,
+		}
+
+		for _, line := range lines {
+			w.Write([]byte(line + "\n"))
+		}
+	}))
+	defer server.Close()
+
+	ollamaAdapter, _ := NewAdapter(&model.Provider{Name: "ollama-dev", Type: "ollama", BaseURL: server.URL + "/api"})
+
+	respChan, err := ollamaAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" || chunks[1].Choices[0].Delta.Role != "" {
+		t.Error("expected role only on first chunk")
+	}
+
+	last := chunks[2]
+	if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != "length" {
+		t.Error("expected last chunk to have finish_reason=length")
+	}
+
+	if last.Usage == nil || last.Usage.TotalTokens != 14 {
+		t.Errorf("unexpected usage: %+v", last.Usage)
+	}
+}
+
+// TestConvertChatRequest_Tools 测试工具调用参数在 JSON 字符串与对象之间的转换
+func TestConvertChatRequest_Tools(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "llama3.1:8b",
+		Messages: []adapter.Message{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []adapter.ToolCall{
+				{ID: "call_1", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+		},
+		Tools: []adapter.Tool{{Type: "function", Function: adapter.ToolFunction{Name: "get_weather"}}},
+	}
+
+	result := ConvertChatRequest(req, nil)
+
+	if len(result.Tools) != 1 {
+		t.Errorf("expected tools to pass through, got %+v", result.Tools)
+	}
+
+	body, _ := json.Marshal(result.Messages[1:])
+	expected := `[{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},` +
+		`{"role":"tool","content":"18C","tool_name":"get_weather"}]`
+	if string(body) != expected {
+		t.Errorf("unexpected messages:\n%s", body)
+	}
+
+	// tool_choice 为 none 时不下发工具
+	req.ToolChoice = &adapter.ToolChoice{Type: adapter.ToolChoiceNone}
+	if result := ConvertChatRequest(req, nil); result.Tools != nil {
+		t.Errorf("expected no tools with tool_choice none, got %+v", result.Tools)
+	}
+}
+
+// TestConvertChatRequest_Images 测试 Data URL 图片转换为 images 字段
+func TestConvertChatRequest_Images(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "llava:7b",
+		Messages: []adapter.Message{{Role: "user", Content: "Describe", Parts: []adapter.ContentPart{
+			{Type: adapter.ContentPartText, Text: "Describe"},
+			{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "data:image/png;base64,iVBORw0KGgo="}},
+		}}},
+	}
+
+	result := ConvertChatRequest(req, nil)
+	if result.Messages[0].Content != "Describe" || len(result.Messages[0].Images) != 1 || result.Messages[0].Images[0] != "iVBORw0KGgo=" {
+		t.Errorf("unexpected message: %+v", result.Messages[0])
+	}
+}
+
+// TestConvertChatResponse_ToolCalls 测试工具调用响应转换
+func TestConvertChatResponse_ToolCalls(t *testing.T) {
+	var resp ChatResponse
+	data := `{"model":"llama3.1:8b","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":true,"done_reason":"stop"}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	result := ConvertChatResponse(&resp)
+
+	choice := result.Choices[0]
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %s", choice.FinishReason)
+	}
+
+	if len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(choice.Message.ToolCalls))
+	}
+
+	call := choice.Message.ToolCalls[0]
+	if !strings.HasPrefix(call.ID, "call_") || call.Type != "function" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call: %+v", call)
+	}
+}
+
+// TestAdapter_ListModels 测试 /api/tags 模型列表
+func TestAdapter_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models":[{"name":"llama3.1:8b","model":"llama3.1:8b"},{"name":"qwen2.5:7b","model":"qwen2.5:7b"}]}`))
+	}))
+	defer server.Close()
+
+	ollamaAdapter, _ := NewAdapter(&model.Provider{Name: "ollama-dev", Type: "ollama", BaseURL: server.URL})
+
+	lister, ok := ollamaAdapter.(adapter.ModelLister)
+	if !ok {
+		t.Fatal("expected ollama adapter to implement adapter.ModelLister")
+	}
+
+	models, err := lister.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 || models[0] != "llama3.1:8b" || models[1] != "qwen2.5:7b" {
+		t.Errorf("unexpected models: %v", models)
+	}
+}
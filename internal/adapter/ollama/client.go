@@ -0,0 +1,514 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// optionKeys 允许从 extra_config 顶层映射到 Ollama options 的参数
+var optionKeys = []string{
+	"num_ctx",
+	"num_predict",
+	"num_keep",
+	"temperature",
+	"top_p",
+	"top_k",
+	"min_p",
+	"seed",
+	"repeat_penalty",
+	"repeat_last_n",
+	"presence_penalty",
+	"frequency_penalty",
+	"mirostat",
+	"mirostat_eta",
+	"mirostat_tau",
+}
+
+// Client Ollama 原生 API 客户端
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient 创建 Ollama 客户端
+func NewClient(baseURL, apiKey string, timeout int) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// buildURL 构建 API URL，base_url 为 Ollama 服务地址（如 http://localhost:11434）
+// 兼容 base_url 已包含 /api 的情况：
+// - http://localhost:11434 -> http://localhost:11434/api/chat
+// - http://localhost:11434/api -> http://localhost:11434/api/chat
+func buildURL(baseURL, path string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/api")
+	return baseURL + "/api/" + path
+}
+
+// ChatRequest /api/chat 请求格式
+type ChatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []Message      `json:"messages"`
+	Stream    bool           `json:"stream"` // Ollama 默认流式，必须显式传 false
+	Options   map[string]any `json:"options,omitempty"`
+	KeepAlive any            `json:"keep_alive,omitempty"`
+	Tools     []adapter.Tool `json:"tools,omitempty"`  // 与 OpenAI 格式一致
+	Format    any            `json:"format,omitempty"` // "json" 或 JSON Schema
+}
+
+// Message /api/chat 消息格式
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"` // base64 编码的图片（不含 Data URL 前缀）
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"` // tool 消息对应的函数名
+}
+
+// ToolCall 工具调用（Ollama 不返回调用 ID，参数为 JSON 对象）
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction 函数调用
+type ToolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ChatResponse /api/chat 响应格式（流式时每行一个）
+type ChatResponse struct {
+	Model           string  `json:"model"`
+	CreatedAt       string  `json:"created_at"`
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason,omitempty"`
+	PromptEvalCount int     `json:"prompt_eval_count,omitempty"`
+	EvalCount       int     `json:"eval_count,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// TagsResponse /api/tags 响应格式
+type TagsResponse struct {
+	Models []ModelTag `json:"models"`
+}
+
+// ModelTag 已安装模型
+type ModelTag struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
+}
+
+// ErrorResponse Ollama 错误响应
+type ErrorResponse struct {
+	Message string `json:"error"`
+}
+
+func (e *ErrorResponse) Error() string {
+	return e.Message
+}
+
+// DoChatRequest 执行非流式聊天请求
+func (c *Client) DoChatRequest(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	req.Stream = false
+
+	httpResp, err := c.doChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var resp ChatResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, adapter.NewStreamError(http.StatusInternalServerError, "", &ErrorResponse{Message: resp.Error})
+	}
+
+	return &resp, nil
+}
+
+// DoChatStreamRequest 执行流式聊天请求，解析 NDJSON 响应
+func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, respChan chan<- *adapter.ChatStreamChunk) error {
+	req.Stream = true
+
+	httpResp, err := c.doChat(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	state := &streamState{first: true}
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var resp ChatResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			continue // 跳过无效数据
+		}
+
+		if resp.Error != "" {
+			return adapter.NewStreamError(http.StatusInternalServerError, "", &ErrorResponse{Message: resp.Error})
+		}
+
+		chunk := state.convert(&resp)
+
+		select {
+		case respChan <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if resp.Done {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return nil
+}
+
+// ListModels 通过 /api/tags 查询已安装的模型
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", buildURL(c.baseURL, "tags"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(ctx, httpReq)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseError(httpResp, respBody)
+	}
+
+	var tags TagsResponse
+	if err := json.Unmarshal(respBody, &tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		name := m.Name
+		if name == "" {
+			name = m.Model
+		}
+		models = append(models, name)
+	}
+	return models, nil
+}
+
+// doChat 发送 /api/chat 请求并检查 HTTP 状态码
+func (c *Client) doChat(ctx context.Context, req *ChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", buildURL(c.baseURL, "chat"), strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.setHeaders(ctx, httpReq)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		respBody, _ := io.ReadAll(httpResp.Body)
+		return nil, parseError(httpResp, respBody)
+	}
+
+	return httpResp, nil
+}
+
+// setHeaders 设置通用请求头（Ollama 本身无鉴权，API Key 用于前置反向代理）
+func (c *Client) setHeaders(ctx context.Context, httpReq *http.Request) {
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	// TraceID 透传
+	if traceID := getTraceID(ctx); traceID != "" {
+		httpReq.Header.Set("X-Trace-ID", traceID)
+	}
+}
+
+// parseError 解析错误响应
+func parseError(httpResp *http.Response, body []byte) error {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+		return adapter.NewUpstreamError(httpResp, body, &errResp, "")
+	}
+	return adapter.NewUpstreamError(httpResp, body, nil, "")
+}
+
+// getTraceID 从 context 获取 TraceID
+func getTraceID(ctx context.Context) string {
+	if traceID, ok := ctx.Value("trace_id").(string); ok {
+		return traceID
+	}
+	return ""
+}
+
+// ConvertChatRequest 将内部请求格式转换为 Ollama 格式
+// extra_config.options 中的内容原样透传，extra_config 顶层的常用参数（num_ctx、temperature 等）
+// 也会映射到 options，请求级参数优先
+// Ollama 不支持 tool_choice，none 时不下发 tools，其他取值按 auto 处理
+func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any) *ChatRequest {
+	ollamaReq := &ChatRequest{
+		Model:    req.Model,
+		Messages: make([]Message, len(req.Messages)),
+	}
+
+	toolNames := make(map[string]string) // tool_call_id -> 函数名
+	for i, msg := range req.Messages {
+		ollamaReq.Messages[i] = Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		}
+		for _, part := range msg.Parts {
+			if part.Type != adapter.ContentPartImageURL {
+				continue
+			}
+			if _, data, ok := adapter.ParseDataURL(part.ImageURL.URL); ok {
+				ollamaReq.Messages[i].Images = append(ollamaReq.Messages[i].Images, data)
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+			args := json.RawMessage(call.Function.Arguments)
+			if !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			ollamaReq.Messages[i].ToolCalls = append(ollamaReq.Messages[i].ToolCalls, ToolCall{
+				Function: ToolCallFunction{Name: call.Function.Name, Arguments: args},
+			})
+		}
+		if msg.Role == "tool" {
+			ollamaReq.Messages[i].ToolName = toolNames[msg.ToolCallID]
+		}
+	}
+
+	if req.ToolChoice == nil || req.ToolChoice.Type != adapter.ToolChoiceNone {
+		ollamaReq.Tools = req.Tools
+	}
+
+	options := make(map[string]any)
+	if raw, ok := defaultConfig["options"].(map[string]any); ok {
+		for k, v := range raw {
+			options[k] = v
+		}
+	}
+	for _, key := range optionKeys {
+		if v, ok := defaultConfig[key]; ok {
+			options[key] = v
+		}
+	}
+	if maxTokens, ok := defaultConfig["max_tokens"]; ok {
+		if _, set := options["num_predict"]; !set {
+			options["num_predict"] = maxTokens
+		}
+	}
+
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens != nil {
+		options["num_predict"] = *req.MaxTokens
+	}
+	if req.TopP != nil {
+		options["top_p"] = *req.TopP
+	}
+	if len(req.Stop) > 0 {
+		options["stop"] = req.Stop
+	}
+	if req.PresencePenalty != nil {
+		options["presence_penalty"] = *req.PresencePenalty
+	}
+	if req.FrequencyPenalty != nil {
+		options["frequency_penalty"] = *req.FrequencyPenalty
+	}
+	if req.Seed != nil {
+		options["seed"] = *req.Seed
+	}
+
+	if len(options) > 0 {
+		ollamaReq.Options = options
+	}
+
+	if keepAlive, ok := defaultConfig["keep_alive"]; ok {
+		ollamaReq.KeepAlive = keepAlive
+	}
+
+	if format := req.ResponseFormat; format != nil && format.Type != adapter.ResponseFormatText {
+		ollamaReq.Format = "json"
+		if format.JSONSchema != nil && len(format.JSONSchema.Schema) > 0 {
+			ollamaReq.Format = format.JSONSchema.Schema
+		}
+	}
+
+	return ollamaReq
+}
+
+// ConvertChatResponse 将 Ollama 响应转换为内部格式
+func ConvertChatResponse(resp *ChatResponse) *adapter.ChatResponse {
+	role := resp.Message.Role
+	if role == "" {
+		role = "assistant"
+	}
+
+	toolCalls := convertToolCalls(resp.Message.ToolCalls)
+	finishReason := convertDoneReason(resp.DoneReason)
+	if len(toolCalls) > 0 && finishReason == "stop" {
+		finishReason = "tool_calls"
+	}
+
+	return &adapter.ChatResponse{
+		Model: resp.Model,
+		Choices: []adapter.Choice{
+			{
+				Index: 0,
+				Message: adapter.Message{
+					Role:      role,
+					Content:   resp.Message.Content,
+					ToolCalls: toolCalls,
+				},
+				FinishReason: finishReason,
+			},
+		},
+		Usage: convertUsage(resp),
+	}
+}
+
+// convertToolCalls 转换工具调用，生成调用 ID 并将参数对象序列化为 JSON 字符串
+func convertToolCalls(calls []ToolCall) []adapter.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	result := make([]adapter.ToolCall, len(calls))
+	for i, call := range calls {
+		args := string(call.Function.Arguments)
+		if args == "" || args == "null" {
+			args = "{}"
+		}
+		result[i] = adapter.ToolCall{
+			ID:       "call_" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+			Type:     "function",
+			Function: adapter.FunctionCall{Name: call.Function.Name, Arguments: args},
+		}
+	}
+	return result
+}
+
+// streamState 流式转换状态
+type streamState struct {
+	first     bool
+	toolCalls int // 已下发的 tool_calls 数量，用作增量索引
+}
+
+// convert 将 Ollama 流式响应行转换为内部格式
+// Ollama 在单行中返回完整的工具调用，转换为一次性包含全部参数的增量
+func (s *streamState) convert(resp *ChatResponse) *adapter.ChatStreamChunk {
+	delta := adapter.MessageDelta{Content: resp.Message.Content}
+	if s.first {
+		delta.Role = "assistant"
+		s.first = false
+	}
+	for _, call := range convertToolCalls(resp.Message.ToolCalls) {
+		delta.ToolCalls = append(delta.ToolCalls, adapter.ToolCallDelta{
+			Index:    s.toolCalls,
+			ID:       call.ID,
+			Type:     call.Type,
+			Function: call.Function,
+		})
+		s.toolCalls++
+	}
+
+	chunk := &adapter.ChatStreamChunk{
+		Model: resp.Model,
+		Choices: []adapter.StreamChoice{
+			{
+				Index: 0,
+				Delta: delta,
+			},
+		},
+	}
+
+	if resp.Done {
+		finishReason := convertDoneReason(resp.DoneReason)
+		if s.toolCalls > 0 && finishReason == "stop" {
+			finishReason = "tool_calls"
+		}
+		chunk.Choices[0].FinishReason = &finishReason
+		usage := convertUsage(resp)
+		chunk.Usage = &usage
+	}
+
+	return chunk
+}
+
+// convertUsage prompt_eval_count/eval_count 映射为 Usage
+func convertUsage(resp *ChatResponse) adapter.Usage {
+	return adapter.Usage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+// convertDoneReason 将 done_reason 映射为 OpenAI finish_reason
+func convertDoneReason(reason string) string {
+	switch reason {
+	case "length":
+		return "length"
+	case "", "stop", "unload", "load":
+		return "stop"
+	default:
+		return reason
+	}
+}
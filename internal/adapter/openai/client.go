@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/lucheng0127/courier/internal/adapter"
 )
@@ -16,15 +17,54 @@ import (
 type Client struct {
 	baseURL    string
 	apiKey     string
+	chatURL    string            // 完整的聊天 API URL，为空时由 baseURL 构建
+	headers    map[string]string // 额外请求头
 	httpClient *http.Client
 }
 
 // NewClient 创建 OpenAI 客户端
+// timeout 为单次请求（含读取流式响应）的超时时间（秒），0 表示不超时
 func NewClient(baseURL, apiKey string, timeout int) *Client {
 	return &Client{
 		baseURL:    baseURL,
 		apiKey:     apiKey,
-		httpClient: &http.Client{},
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// NewClientWithURL 创建使用完整聊天 URL 和自定义请求头的客户端
+// 供 URL 规则或鉴权方式与 OpenAI 不同的兼容服务（如 Azure OpenAI）使用
+// apiKey 非空时仍以 Authorization: Bearer 发送
+func NewClientWithURL(chatURL, apiKey string, headers map[string]string, timeout int) *Client {
+	return &Client{
+		apiKey:     apiKey,
+		chatURL:    chatURL,
+		headers:    headers,
+		httpClient: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// chatEndpoint 返回聊天 API URL
+func (c *Client) chatEndpoint() string {
+	if c.chatURL != "" {
+		return c.chatURL
+	}
+	return buildChatURL(c.baseURL)
+}
+
+// setHeaders 设置鉴权、额外请求头和 TraceID
+func (c *Client) setHeaders(ctx context.Context, httpReq *http.Request) {
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	// TraceID 透传
+	if traceID := getTraceID(ctx); traceID != "" {
+		httpReq.Header.Set("X-Trace-ID", traceID)
 	}
 }
 
@@ -47,20 +87,58 @@ func buildChatURL(baseURL string) string {
 	return baseURL + "/chat/completions"
 }
 
+// buildModelsURL 构建模型列表 API URL（与 buildChatURL 使用相同的 base_url 规则）
+// - https://api.openai.com/v1 -> https://api.openai.com/v1/models
+// - https://api.openai.com/v1/chat/completions -> https://api.openai.com/v1/models
+func buildModelsURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/chat/completions")
+	return baseURL + "/models"
+}
+
+// buildEmbeddingsURL 构建 Embeddings API URL（与 buildChatURL 使用相同的 base_url 规则）
+func buildEmbeddingsURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/chat/completions")
+	return baseURL + "/embeddings"
+}
+
 // ChatRequest OpenAI API 请求格式
 type ChatRequest struct {
-	Model       string              `json:"model"`
-	Messages    []ChatMessage       `json:"messages"`
-	Temperature *float64            `json:"temperature,omitempty"`
-	MaxTokens   *int                `json:"max_tokens,omitempty"`
-	TopP        *float64            `json:"top_p,omitempty"`
-	Stream      bool                `json:"stream,omitempty"`
+	Model         string         `json:"model"`
+	Messages      []ChatMessage  `json:"messages"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Tools         []adapter.Tool `json:"tools,omitempty"`
+	ToolChoice    any            `json:"tool_choice,omitempty"` // 字符串或 {"type":"function","function":{"name":...}}
+
+	N                int                     `json:"n,omitempty"`
+	Stop             []string                `json:"stop,omitempty"`
+	PresencePenalty  *float64                `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64                `json:"frequency_penalty,omitempty"`
+	Seed             *int64                  `json:"seed,omitempty"`
+	LogitBias        map[string]float64      `json:"logit_bias,omitempty"`
+	Logprobs         bool                    `json:"logprobs,omitempty"`
+	TopLogprobs      *int                    `json:"top_logprobs,omitempty"`
+	User             string                  `json:"user,omitempty"`
+	ResponseFormat   *adapter.ResponseFormat `json:"response_format,omitempty"` // 与 OpenAI 格式一致
+}
+
+// StreamOptions 流式请求选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 在流结束前返回一个只包含 usage 的块
 }
 
 // ChatMessage OpenAI API 消息格式
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string             `json:"role"`
+	Content    any                `json:"content"` // 字符串或 []adapter.ContentPart
+	Name       string             `json:"name,omitempty"`
+	ToolCalls  []adapter.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string             `json:"tool_call_id,omitempty"`
 }
 
 // ChatResponse OpenAI API 响应格式
@@ -75,9 +153,10 @@ type ChatResponse struct {
 
 // ChatChoice OpenAI API 选择项
 type ChatChoice struct {
-	Index        int         `json:"index"`
-	Message      ChatMessage `json:"message"`
-	FinishReason string      `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Message      ChatMessage     `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+	Logprobs     json.RawMessage `json:"logprobs,omitempty"`
 }
 
 // ChatUsage OpenAI API 使用量
@@ -89,24 +168,28 @@ type ChatUsage struct {
 
 // StreamChunk OpenAI SSE 流式响应块
 type StreamChunk struct {
-	ID      string            `json:"id"`
-	Object  string            `json:"object"`
-	Created int64             `json:"created"`
-	Model   string            `json:"model"`
-	Choices []StreamChoice    `json:"choices"`
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []StreamChoice `json:"choices"`
+	Usage   *ChatUsage     `json:"usage,omitempty"` // 仅 include_usage 时的最后一个块
+	Error   *ErrorDetail   `json:"error,omitempty"` // 上游在流中途返回的错误
 }
 
 // StreamChoice 流式选择项
 type StreamChoice struct {
-	Index        int            `json:"index"`
-	Delta        StreamDelta   `json:"delta"`
-	FinishReason *string       `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Delta        StreamDelta     `json:"delta"`
+	FinishReason *string         `json:"finish_reason"`
+	Logprobs     json.RawMessage `json:"logprobs,omitempty"`
 }
 
 // StreamDelta 流式增量
 type StreamDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string                  `json:"role,omitempty"`
+	Content   string                  `json:"content,omitempty"`
+	ToolCalls []adapter.ToolCallDelta `json:"tool_calls,omitempty"`
 }
 
 // ErrorResponse OpenAI API 错误响应
@@ -125,6 +208,37 @@ func (e *ErrorResponse) Error() string {
 	return e.ErrorDetail.Message
 }
 
+// upstreamError 将非 200 响应转换为 adapter.UpstreamError
+func upstreamError(httpResp *http.Response, respBody []byte) error {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.ErrorDetail.Message != "" {
+		code := errResp.ErrorDetail.Code
+		if code == "" {
+			code = errResp.ErrorDetail.Type
+		}
+		return adapter.NewUpstreamError(httpResp, respBody, &errResp, code)
+	}
+	return adapter.NewUpstreamError(httpResp, respBody, nil, "")
+}
+
+// streamError 将流中途返回的错误转换为 adapter.UpstreamError，按错误码推断状态码
+// 流已经以 200 开始，无法识别的错误按上游服务端错误处理
+func streamError(detail *ErrorDetail) error {
+	code := detail.Code
+	if code == "" {
+		code = detail.Type
+	}
+
+	status := http.StatusInternalServerError
+	switch code {
+	case "rate_limit_exceeded", "insufficient_quota":
+		status = http.StatusTooManyRequests
+	case "invalid_request_error", "context_length_exceeded":
+		status = http.StatusBadRequest
+	}
+	return adapter.NewStreamError(status, code, &ErrorResponse{ErrorDetail: *detail})
+}
+
 // DoChatRequest 执行非流式聊天请求（导出供其他 Adapter 使用）
 func (c *Client) DoChatRequest(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	req.Stream = false
@@ -134,21 +248,14 @@ func (c *Client) DoChatRequest(ctx context.Context, req *ChatRequest) (*ChatResp
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", buildChatURL(c.baseURL), strings.NewReader(string(body)))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.chatEndpoint(), strings.NewReader(string(body)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// 设置请求头
 	httpReq.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
-
-	// TraceID 透传
-	if traceID := getTraceID(ctx); traceID != "" {
-		httpReq.Header.Set("X-Trace-ID", traceID)
-	}
+	c.setHeaders(ctx, httpReq)
 
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -163,11 +270,7 @@ func (c *Client) DoChatRequest(ctx context.Context, req *ChatRequest) (*ChatResp
 
 	// 检查 HTTP 状态码
 	if httpResp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.ErrorDetail.Message != "" {
-			return nil, &errResp
-		}
-		return nil, fmt.Errorf("request failed with status %d: %s", httpResp.StatusCode, string(respBody))
+		return nil, upstreamError(httpResp, respBody)
 	}
 
 	var resp ChatResponse
@@ -181,13 +284,14 @@ func (c *Client) DoChatRequest(ctx context.Context, req *ChatRequest) (*ChatResp
 // DoChatStreamRequest 执行流式聊天请求（导出供其他 Adapter 使用）
 func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, respChan chan<- *adapter.ChatStreamChunk) error {
 	req.Stream = true
+	req.StreamOptions = &StreamOptions{IncludeUsage: true}
 
 	body, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", buildChatURL(c.baseURL), strings.NewReader(string(body)))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.chatEndpoint(), strings.NewReader(string(body)))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -195,14 +299,7 @@ func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, resp
 	// 设置请求头
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
-	if c.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
-
-	// TraceID 透传
-	if traceID := getTraceID(ctx); traceID != "" {
-		httpReq.Header.Set("X-Trace-ID", traceID)
-	}
+	c.setHeaders(ctx, httpReq)
 
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
@@ -213,14 +310,11 @@ func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, resp
 	// 检查 HTTP 状态码
 	if httpResp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(httpResp.Body)
-		var errResp ErrorResponse
-		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.ErrorDetail.Message != "" {
-			return &errResp
-		}
-		return fmt.Errorf("request failed with status %d: %s", httpResp.StatusCode, string(respBody))
+		return upstreamError(httpResp, respBody)
 	}
 
 	// 解析 SSE 流
+	done := false
 	scanner := bufio.NewScanner(httpResp.Body)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -239,6 +333,7 @@ func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, resp
 
 		// 检查结束标记
 		if data == "[DONE]" {
+			done = true
 			break
 		}
 
@@ -247,6 +342,9 @@ func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, resp
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			continue // 跳过无效数据
 		}
+		if chunk.Error != nil {
+			return streamError(chunk.Error)
+		}
 
 		// 转换为内部格式并发送
 		internalChunk := convertStreamChunk(&chunk)
@@ -261,9 +359,134 @@ func (c *Client) DoChatStreamRequest(ctx context.Context, req *ChatRequest, resp
 		return fmt.Errorf("failed to read stream: %w", err)
 	}
 
+	// 连接在 [DONE] 之前关闭，说明上游中途断开
+	if !done {
+		return fmt.Errorf("failed to read stream: %w", io.ErrUnexpectedEOF)
+	}
+
 	return nil
 }
 
+// ModelsResponse /models 响应格式
+type ModelsResponse struct {
+	Object string      `json:"object"`
+	Data   []ModelInfo `json:"data"`
+}
+
+// ModelInfo /models 中的模型
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ListModels 通过 GET /models 查询上游可用模型（导出供其他 Adapter 使用）
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", buildModelsURL(c.baseURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(ctx, httpReq)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// 检查 HTTP 状态码
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, upstreamError(httpResp, respBody)
+	}
+
+	var resp ModelsResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]string, 0, len(resp.Data))
+	for _, m := range resp.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// EmbeddingRequest OpenAI Embeddings 请求格式
+type EmbeddingRequest struct {
+	Model          string `json:"model"`
+	Input          any    `json:"input"` // []string 或 [][]int
+	Dimensions     *int   `json:"dimensions,omitempty"`
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// EmbeddingResponse OpenAI Embeddings 响应格式
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData 单条向量
+type EmbeddingData struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// EmbeddingUsage Embeddings 使用量
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// DoEmbeddingRequest 执行 Embeddings 请求（导出供其他 Adapter 使用）
+func (c *Client) DoEmbeddingRequest(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", buildEmbeddingsURL(c.baseURL), strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.setHeaders(ctx, httpReq)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// 检查 HTTP 状态码
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, upstreamError(httpResp, respBody)
+	}
+
+	var resp EmbeddingResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &resp, nil
+}
+
 // getTraceID 从 context 获取 TraceID
 func getTraceID(ctx context.Context) string {
 	// 从 context 中获取 TraceID
@@ -281,14 +504,26 @@ func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any)
 		Messages: make([]ChatMessage, len(req.Messages)),
 	}
 
-	// 转换消息
+	// 转换消息，多模态内容片段与 OpenAI 格式一致，直接透传
 	for i, msg := range req.Messages {
 		openaiReq.Messages[i] = ChatMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
+			Role:       msg.Role,
+			Content:    msg.Content,
+			Name:       msg.Name,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+		}
+		if len(msg.Parts) > 0 {
+			openaiReq.Messages[i].Content = msg.Parts
 		}
 	}
 
+	// 工具定义与 OpenAI 格式一致，直接透传
+	openaiReq.Tools = req.Tools
+	if req.ToolChoice != nil {
+		openaiReq.ToolChoice = ConvertToolChoice(req.ToolChoice)
+	}
+
 	// 优先使用请求参数
 	if req.Temperature != nil {
 		openaiReq.Temperature = req.Temperature
@@ -303,13 +538,57 @@ func ConvertChatRequest(req *adapter.ChatRequest, defaultConfig map[string]any)
 		openaiReq.MaxTokens = &val
 	}
 
-	if topP, ok := defaultConfig["top_p"].(float64); ok {
+	if req.TopP != nil {
+		openaiReq.TopP = req.TopP
+	} else if topP, ok := defaultConfig["top_p"].(float64); ok {
 		openaiReq.TopP = &topP
 	}
 
+	// 其余采样参数与 OpenAI 格式一致，直接透传
+	openaiReq.N = req.N
+	openaiReq.Stop = req.Stop
+	openaiReq.PresencePenalty = req.PresencePenalty
+	openaiReq.FrequencyPenalty = req.FrequencyPenalty
+	openaiReq.Seed = req.Seed
+	openaiReq.LogitBias = req.LogitBias
+	openaiReq.Logprobs = req.Logprobs
+	openaiReq.TopLogprobs = req.TopLogprobs
+	openaiReq.User = req.User
+	openaiReq.ResponseFormat = req.ResponseFormat
+
 	return openaiReq
 }
 
+// contentText 提取响应消息中的文本（content 可能为 null 或片段数组）
+func contentText(content any) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []any:
+		var sb strings.Builder
+		for _, item := range v {
+			if part, ok := item.(map[string]any); ok && part["type"] == adapter.ContentPartText {
+				text, _ := part["text"].(string)
+				sb.WriteString(text)
+			}
+		}
+		return sb.String()
+	default:
+		return ""
+	}
+}
+
+// ConvertToolChoice 将内部 tool_choice 转换为 OpenAI 格式（导出供其他 Adapter 使用）
+func ConvertToolChoice(choice *adapter.ToolChoice) any {
+	if choice.Type != adapter.ToolChoiceFunction {
+		return choice.Type
+	}
+	return map[string]any{
+		"type":     "function",
+		"function": map[string]string{"name": choice.FunctionName},
+	}
+}
+
 // ConvertChatResponse 将 OpenAI 响应格式转换为内部格式（导出供其他 Adapter 使用）
 func ConvertChatResponse(resp *ChatResponse) *adapter.ChatResponse {
 	choices := make([]adapter.Choice, len(resp.Choices))
@@ -317,10 +596,12 @@ func ConvertChatResponse(resp *ChatResponse) *adapter.ChatResponse {
 		choices[i] = adapter.Choice{
 			Index: c.Index,
 			Message: adapter.Message{
-				Role:    c.Message.Role,
-				Content: c.Message.Content,
+				Role:      c.Message.Role,
+				Content:   contentText(c.Message.Content),
+				ToolCalls: c.Message.ToolCalls,
 			},
 			FinishReason: c.FinishReason,
+			Logprobs:     c.Logprobs,
 		}
 	}
 
@@ -343,16 +624,64 @@ func convertStreamChunk(chunk *StreamChunk) *adapter.ChatStreamChunk {
 		choices[i] = adapter.StreamChoice{
 			Index: c.Index,
 			Delta: adapter.MessageDelta{
-				Role:    c.Delta.Role,
-				Content: c.Delta.Content,
+				Role:      c.Delta.Role,
+				Content:   c.Delta.Content,
+				ToolCalls: c.Delta.ToolCalls,
 			},
 			FinishReason: c.FinishReason,
+			Logprobs:     c.Logprobs,
 		}
 	}
 
-	return &adapter.ChatStreamChunk{
+	result := &adapter.ChatStreamChunk{
 		ID:      chunk.ID,
 		Model:   chunk.Model,
 		Choices: choices,
 	}
+	if chunk.Usage != nil {
+		result.Usage = &adapter.Usage{
+			PromptTokens:     chunk.Usage.PromptTokens,
+			CompletionTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:      chunk.Usage.TotalTokens,
+		}
+	}
+	return result
+}
+
+// ConvertEmbeddingRequest 将内部 Embedding 请求转换为 OpenAI 格式（导出供其他 Adapter 使用）
+// 始终请求浮点格式，base64 编码由网关按客户端要求完成
+func ConvertEmbeddingRequest(req *adapter.EmbeddingRequest) *EmbeddingRequest {
+	embeddingReq := &EmbeddingRequest{
+		Model:      req.Model,
+		Dimensions: req.Dimensions,
+		User:       req.User,
+	}
+
+	if len(req.Tokens) > 0 {
+		embeddingReq.Input = req.Tokens
+	} else {
+		embeddingReq.Input = req.Input
+	}
+
+	return embeddingReq
+}
+
+// ConvertEmbeddingResponse 将 OpenAI Embeddings 响应转换为内部格式（导出供其他 Adapter 使用）
+func ConvertEmbeddingResponse(resp *EmbeddingResponse) *adapter.EmbeddingResponse {
+	data := make([]adapter.Embedding, len(resp.Data))
+	for i, d := range resp.Data {
+		data[i] = adapter.Embedding{
+			Index:     d.Index,
+			Embedding: d.Embedding,
+		}
+	}
+
+	return &adapter.EmbeddingResponse{
+		Model: resp.Model,
+		Data:  data,
+		Usage: adapter.Usage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		},
+	}
 }
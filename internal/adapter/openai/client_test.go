@@ -3,12 +3,15 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
 )
 
 // TestConvertChatRequest 测试请求格式转换
@@ -219,6 +222,70 @@ func TestDoChatRequest_Error(t *testing.T) {
 	}
 }
 
+// TestDoChatRequest_Timeout 测试上游超过 Provider 超时时间未响应时返回错误
+func TestDoChatRequest_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := NewClient(server.URL, "test-key", 1)
+
+	req := &ChatRequest{
+		Model:    "gpt-4",
+		Messages: []ChatMessage{{Role: "user", Content: "Hello"}},
+	}
+
+	start := time.Now()
+	_, err := client.DoChatRequest(context.Background(), req)
+	if err == nil {
+		t.Fatal("expected timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected request to time out after 1s, took %s", elapsed)
+	}
+}
+
+// TestDoChatRequest_RateLimited 测试限流错误转换为 UpstreamError
+func TestDoChatRequest_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]string{
+				"message": "Rate limit reached for gpt-4 on 5000 tokens per min",
+				"type":    "requests",
+				"code":    "rate_limit_exceeded",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", 30)
+	_, err := client.DoChatRequest(context.Background(), &ChatRequest{
+		Model:    "gpt-4",
+		Messages: []ChatMessage{{Role: "user", Content: "Hello"}},
+	})
+
+	var upErr *adapter.UpstreamError
+	if !errors.As(err, &upErr) {
+		t.Fatalf("expected *adapter.UpstreamError, got %T: %v", err, err)
+	}
+	if upErr.StatusCode != http.StatusTooManyRequests || upErr.Code != "rate_limit_exceeded" || upErr.RetryAfter != 7*time.Second {
+		t.Errorf("unexpected upstream error: %+v", upErr)
+	}
+	if !upErr.RateLimited() || !upErr.Retryable() {
+		t.Error("expected rate limited error to be retryable")
+	}
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.ErrorDetail.Code != "rate_limit_exceeded" {
+		t.Errorf("expected wrapped *ErrorResponse, got %v", err)
+	}
+}
+
 // TestDoChatRequest_NoAPIKey 测试无 API Key 的请求
 func TestDoChatRequest_NoAPIKey(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -318,6 +385,278 @@ func TestDoChatStreamRequest_Success(t *testing.T) {
 	}
 }
 
+// TestDoChatStreamRequest_Usage 测试流式请求携带 include_usage 并解析最后的 usage 块
+func TestDoChatStreamRequest_Usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if opts, _ := body["stream_options"].(map[string]any); opts["include_usage"] != true {
+			t.Errorf("expected stream_options.include_usage, got %v", body["stream_options"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"id":"c","model":"gpt-4","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":"stop"}]}` + "\n\n"))
+		w.Write([]byte(`data: {"id":"c","model":"gpt-4","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}` + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1", "test-key", 30)
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+	if err := client.DoChatStreamRequest(context.Background(), &ChatRequest{Model: "gpt-4"}, respChan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(respChan)
+
+	var last *adapter.ChatStreamChunk
+	for chunk := range respChan {
+		last = chunk
+	}
+	if last == nil || last.Usage == nil || last.Usage.TotalTokens != 10 || len(last.Choices) != 0 {
+		t.Errorf("expected final usage chunk, got %+v", last)
+	}
+}
+
+// TestDoChatStreamRequest_Errors 测试流中途的错误事件与连接提前关闭
+func TestDoChatStreamRequest_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantErr    string
+		wantStatus int // 错误事件转换为 UpstreamError 的状态码，0 表示不是 UpstreamError
+	}{
+		{
+			name:       "error event",
+			body:       `data: {"error":{"message":"The server had an error","type":"server_error"}}` + "\n\n",
+			wantErr:    "The server had an error",
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "rate limit event",
+			body:       `data: {"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}` + "\n\n",
+			wantErr:    "Rate limit reached",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:    "missing done",
+			body:    `data: {"id":"c","model":"gpt-4","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n",
+			wantErr: "unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL+"/v1", "test-key", 30)
+			err := client.DoChatStreamRequest(context.Background(), &ChatRequest{Model: "gpt-4"}, make(chan *adapter.ChatStreamChunk, 10))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			var upErr *adapter.UpstreamError
+			if errors.As(err, &upErr) != (tt.wantStatus != 0) || (upErr != nil && upErr.StatusCode != tt.wantStatus) {
+				t.Errorf("expected upstream status %d, got %v", tt.wantStatus, upErr)
+			}
+		})
+	}
+}
+
+// TestAdapter_ChatStream_Error 测试流式错误作为最后一个数据块返回
+func TestAdapter_ChatStream_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests"}}`))
+	}))
+	defer server.Close()
+
+	openaiAdapter, err := NewAdapter(&model.Provider{Name: "openai", Type: "openai", BaseURL: server.URL + "/v1", Timeout: 30, Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+
+	chunks, err := openaiAdapter.ChatStream(context.Background(), &adapter.ChatRequest{
+		Model:    "gpt-4",
+		Messages: []adapter.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var last *adapter.ChatStreamChunk
+	for chunk := range chunks {
+		last = chunk
+	}
+	if last == nil || last.Err == nil || last.Err.Error() != "Rate limit reached" {
+		t.Errorf("expected terminal error chunk, got %+v", last)
+	}
+}
+
+// TestConvertChatRequest_Tools 测试工具定义、tool_choice 与工具消息的透传
+func TestConvertChatRequest_Tools(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "gpt-4o",
+		Messages: []adapter.Message{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []adapter.ToolCall{
+				{ID: "call_1", Type: "function", Function: adapter.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+		},
+		Tools: []adapter.Tool{{Type: "function", Function: adapter.ToolFunction{
+			Name:       "get_weather",
+			Parameters: json.RawMessage(`{"type":"object"}`),
+		}}},
+		ToolChoice: &adapter.ToolChoice{Type: adapter.ToolChoiceFunction, FunctionName: "get_weather"},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil))
+	expected := `{"model":"gpt-4o","messages":[{"role":"user","content":"Weather in Paris?"},` +
+		`{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},` +
+		`{"role":"tool","content":"18C","tool_call_id":"call_1"}],` +
+		`"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}],` +
+		`"tool_choice":{"function":{"name":"get_weather"},"type":"function"}}`
+	if string(body) != expected {
+		t.Errorf("unexpected request:\n%s", body)
+	}
+
+	req.ToolChoice = &adapter.ToolChoice{Type: adapter.ToolChoiceRequired}
+	if result := ConvertChatRequest(req, nil); result.ToolChoice != "required" {
+		t.Errorf("expected tool_choice required, got %v", result.ToolChoice)
+	}
+}
+
+// TestConvertChatRequest_ContentParts 测试多模态内容片段透传
+func TestConvertChatRequest_ContentParts(t *testing.T) {
+	req := &adapter.ChatRequest{
+		Model: "gpt-4o",
+		Messages: []adapter.Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "What is this?", Parts: []adapter.ContentPart{
+				{Type: adapter.ContentPartText, Text: "What is this?"},
+				{Type: adapter.ContentPartImageURL, ImageURL: &adapter.ImageURL{URL: "https://example.com/cat.jpg", Detail: "low"}},
+			}},
+		},
+	}
+
+	body, _ := json.Marshal(ConvertChatRequest(req, nil).Messages)
+	expected := `[{"role":"system","content":"Be brief."},` +
+		`{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg","detail":"low"}}]}]`
+	if string(body) != expected {
+		t.Errorf("unexpected messages:\n%s", body)
+	}
+}
+
+// TestConvertChatRequest_SamplingParams 测试采样参数透传
+func TestConvertChatRequest_SamplingParams(t *testing.T) {
+	topP := 0.5
+	penalty := 0.3
+	seed := int64(42)
+	topLogprobs := 2
+	req := &adapter.ChatRequest{
+		Model:            "gpt-4o",
+		Messages:         []adapter.Message{{Role: "user", Content: "Hi"}},
+		TopP:             &topP,
+		N:                2,
+		Stop:             []string{"END"},
+		PresencePenalty:  &penalty,
+		FrequencyPenalty: &penalty,
+		Seed:             &seed,
+		LogitBias:        map[string]float64{"50256": -100},
+		Logprobs:         true,
+		TopLogprobs:      &topLogprobs,
+		User:             "user-1",
+		ResponseFormat:   &adapter.ResponseFormat{Type: adapter.ResponseFormatJSONObject},
+	}
+
+	// 请求参数优先于默认配置
+	result := ConvertChatRequest(req, map[string]any{"top_p": 0.9})
+	result.Messages = nil
+
+	body, _ := json.Marshal(result)
+	expected := `{"model":"gpt-4o","messages":null,"top_p":0.5,"n":2,"stop":["END"],` +
+		`"presence_penalty":0.3,"frequency_penalty":0.3,"seed":42,"logit_bias":{"50256":-100},` +
+		`"logprobs":true,"top_logprobs":2,"user":"user-1","response_format":{"type":"json_object"}}`
+	if string(body) != expected {
+		t.Errorf("unexpected request:\n%s", body)
+	}
+}
+
+// TestConvertChatResponse_Logprobs 测试 logprobs 透传
+func TestConvertChatResponse_Logprobs(t *testing.T) {
+	var resp ChatResponse
+	data := `{"id":"x","model":"gpt-4o","choices":[` +
+		`{"index":0,"message":{"role":"assistant","content":"a"},"finish_reason":"stop","logprobs":{"content":[]}},` +
+		`{"index":1,"message":{"role":"assistant","content":"b"},"finish_reason":"stop"}]}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	result := ConvertChatResponse(&resp)
+	if len(result.Choices) != 2 {
+		t.Fatalf("expected 2 choices, got %d", len(result.Choices))
+	}
+	if string(result.Choices[0].Logprobs) != `{"content":[]}` {
+		t.Errorf("unexpected logprobs: %s", result.Choices[0].Logprobs)
+	}
+	if result.Choices[1].Logprobs != nil || result.Choices[1].Message.Content != "b" {
+		t.Errorf("unexpected second choice: %+v", result.Choices[1])
+	}
+}
+
+// TestDoChatStreamRequest_ToolCalls 测试流式工具调用增量
+func TestDoChatStreamRequest_ToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		chunks := []string{
+			`data: {"id":"chunk-1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`data: {"id":"chunk-2","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":\"Paris\"}"}}]}}]}`,
+			`data: {"id":"chunk-3","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`data: [DONE]`,
+		}
+
+		for _, chunk := range chunks {
+			w.Write([]byte(chunk + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1", "test-key", 30)
+	respChan := make(chan *adapter.ChatStreamChunk, 10)
+
+	err := client.DoChatStreamRequest(context.Background(), &ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []ChatMessage{{Role: "user", Content: "Weather in Paris?"}},
+	}, respChan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(respChan)
+
+	var chunks []*adapter.ChatStreamChunk
+	for chunk := range respChan {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+
+	first := chunks[0].Choices[0].Delta.ToolCalls
+	if len(first) != 1 || first[0].ID != "call_1" || first[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected first tool call delta: %+v", first)
+	}
+
+	second := chunks[1].Choices[0].Delta.ToolCalls
+	if len(second) != 1 || second[0].Index != 0 || second[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected argument delta: %+v", second)
+	}
+}
+
 // TestDoChatStreamRequest_ContextCancel 测试 context 取消
 func TestDoChatStreamRequest_ContextCancel(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -420,3 +759,72 @@ func TestBuildChatURL(t *testing.T) {
 		})
 	}
 }
+
+// TestListModels 测试上游模型列表查询
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("expected Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"},{"id":"gpt-4o-mini","object":"model","created":1721172741,"owned_by":"system"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1/chat/completions", "test-key", 30)
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(models) != 2 || models[0] != "gpt-4o" || models[1] != "gpt-4o-mini" {
+		t.Errorf("unexpected models: %v", models)
+	}
+}
+
+// TestDoEmbeddingRequest 测试 Embeddings 请求
+func TestDoEmbeddingRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if _, ok := body["encoding_format"]; ok {
+			t.Error("expected encoding_format to be omitted (float)")
+		}
+		if input, ok := body["input"].([]any); !ok || len(input) != 2 {
+			t.Errorf("unexpected input: %v", body["input"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]},{"object":"embedding","index":1,"embedding":[0.3,0.4]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":6,"total_tokens":6}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1", "test-key", 30)
+
+	resp, err := client.DoEmbeddingRequest(context.Background(), ConvertEmbeddingRequest(&adapter.EmbeddingRequest{
+		Model: "text-embedding-3-small",
+		Input: []string{"hello", "world"},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := ConvertEmbeddingResponse(resp)
+	if len(result.Data) != 2 || result.Data[1].Embedding[1] != 0.4 {
+		t.Errorf("unexpected data: %+v", result.Data)
+	}
+	if result.Usage.PromptTokens != 6 {
+		t.Errorf("unexpected usage: %+v", result.Usage)
+	}
+}
@@ -0,0 +1,162 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// UnsupportedParameterError Provider 不支持的请求参数
+type UnsupportedParameterError struct {
+	ProviderType string
+	Parameter    string
+	Reason       string
+}
+
+func (e *UnsupportedParameterError) Error() string {
+	msg := fmt.Sprintf("provider type %s does not support parameter %s", e.ProviderType, e.Parameter)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// 可选参数名（OpenAI 参数名），用于 CheckParameters
+const (
+	ParamPresencePenalty  = "presence_penalty"
+	ParamFrequencyPenalty = "frequency_penalty"
+	ParamSeed             = "seed"
+	ParamLogitBias        = "logit_bias"
+	ParamLogprobs         = "logprobs"
+	ParamResponseFormat   = "response_format"
+)
+
+const (
+	// MaxFanOutChoices 不支持 n 参数的 Provider（由网关并发请求）允许的最大 n
+	MaxFanOutChoices = 8
+	// fanOutConcurrency 同一请求并发发往上游的最大请求数
+	fanOutConcurrency = 4
+)
+
+// setParameters 返回请求中设置了的可选参数
+// top_p、stop、user 所有 Provider 都能处理（user 在不支持时忽略），不在检查范围内；
+// response_format 为 text 时等同于未设置
+func setParameters(req *ChatRequest) []string {
+	var params []string
+	if req.PresencePenalty != nil {
+		params = append(params, ParamPresencePenalty)
+	}
+	if req.FrequencyPenalty != nil {
+		params = append(params, ParamFrequencyPenalty)
+	}
+	if req.Seed != nil {
+		params = append(params, ParamSeed)
+	}
+	if len(req.LogitBias) > 0 {
+		params = append(params, ParamLogitBias)
+	}
+	if req.Logprobs || req.TopLogprobs != nil {
+		params = append(params, ParamLogprobs)
+	}
+	if req.ResponseFormat != nil && req.ResponseFormat.Type != ResponseFormatText {
+		params = append(params, ParamResponseFormat)
+	}
+	return params
+}
+
+// CheckParameters 检查请求中设置的可选参数是否都在支持的参数中
+func CheckParameters(providerType string, req *ChatRequest, supported ...string) error {
+	for _, param := range setParameters(req) {
+		if !slices.Contains(supported, param) {
+			return &UnsupportedParameterError{ProviderType: providerType, Parameter: param}
+		}
+	}
+	return nil
+}
+
+// CheckStreamChoices 不支持 n 参数的 Provider 在流式请求中无法返回多个选项
+func CheckStreamChoices(providerType string, req *ChatRequest) error {
+	if req.N > 1 {
+		return &UnsupportedParameterError{
+			ProviderType: providerType,
+			Parameter:    "n",
+			Reason:       "n > 1 is not supported for streaming requests",
+		}
+	}
+	return nil
+}
+
+// FanOutChoices 为不支持 n 参数的 Provider 并发发起 n 次请求，并将结果合并为多个选项
+// n 超过 MaxFanOutChoices 时返回 UnsupportedParameterError，同时最多 fanOutConcurrency 个请求；
+// 任一请求失败时取消其余请求并返回错误；usage 为所有请求的合计（与上游实际计费一致）
+func FanOutChoices(ctx context.Context, providerType string, req *ChatRequest, chat func(ctx context.Context, req *ChatRequest) (*ChatResponse, error)) (*ChatResponse, error) {
+	if req.N <= 1 {
+		return chat(ctx, req)
+	}
+	if req.N > MaxFanOutChoices {
+		return nil, &UnsupportedParameterError{
+			ProviderType: providerType,
+			Parameter:    "n",
+			Reason:       fmt.Sprintf("n > %d is not supported", MaxFanOutChoices),
+		}
+	}
+
+	single := *req
+	single.N = 1
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*ChatResponse, req.N)
+	sem := make(chan struct{}, fanOutConcurrency)
+	var (
+		firstErr error
+		once     sync.Once
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < req.N; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				// 已有请求失败，不再发起
+				return
+			}
+			resp, err := chat(ctx, &single)
+			if err != nil {
+				// 只保留最先失败的错误，其余请求因取消产生的错误忽略
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		// 调用方取消时部分请求没有发起
+		return nil, err
+	}
+
+	merged := &ChatResponse{ID: responses[0].ID, Model: responses[0].Model}
+	for _, resp := range responses {
+		for _, choice := range resp.Choices {
+			choice.Index = len(merged.Choices)
+			merged.Choices = append(merged.Choices, choice)
+		}
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	return merged, nil
+}
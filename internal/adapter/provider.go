@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"context"
+	"encoding/json"
 )
 
 // Provider LLM Provider 接口
@@ -25,19 +26,170 @@ type Provider interface {
 	Config() map[string]any
 }
 
+// ModelLister 可选接口：支持从上游查询已安装/可用模型的 Provider 实现该接口
+type ModelLister interface {
+	// ListModels 返回上游可用的模型名称列表
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// EmbeddingProvider 可选接口：支持 Embeddings 的 Provider 实现该接口
+type EmbeddingProvider interface {
+	// Embed 生成文本向量
+	Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// EmbeddingRequest Embedding 请求，Input 与 Tokens 二选一
+type EmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input,omitempty"`  // 文本输入
+	Tokens     [][]int  `json:"tokens,omitempty"` // 已分词的 token ID 输入
+	Dimensions *int     `json:"dimensions,omitempty"`
+	User       string   `json:"user,omitempty"`
+}
+
+// EmbeddingResponse Embedding 响应，向量始终为浮点格式
+type EmbeddingResponse struct {
+	Model string      `json:"model"`
+	Data  []Embedding `json:"data"`
+	Usage Usage       `json:"usage"`
+}
+
+// Embedding 单条输入的向量
+type Embedding struct {
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
 // ChatRequest 聊天请求
 type ChatRequest struct {
-	Messages    []Message `json:"messages"`
-	Model       string    `json:"model"`
-	Temperature *float64  `json:"temperature,omitempty"`
-	MaxTokens   *int      `json:"max_tokens,omitempty"`
-	// 其他可选参数...
+	Messages    []Message   `json:"messages"`
+	Model       string      `json:"model"`
+	Temperature *float64    `json:"temperature,omitempty"`
+	MaxTokens   *int        `json:"max_tokens,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+
+	// 采样参数，未设置时使用 Provider 默认值（extra_config）或上游默认值
+	TopP             *float64           `json:"top_p,omitempty"`
+	N                int                `json:"n,omitempty"` // 0 与 1 等价
+	Stop             []string           `json:"stop,omitempty"`
+	PresencePenalty  *float64           `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64           `json:"frequency_penalty,omitempty"`
+	Seed             *int64             `json:"seed,omitempty"`
+	LogitBias        map[string]float64 `json:"logit_bias,omitempty"`
+	Logprobs         bool               `json:"logprobs,omitempty"`
+	TopLogprobs      *int               `json:"top_logprobs,omitempty"`
+	User             string             `json:"user,omitempty"`
+	ResponseFormat   *ResponseFormat    `json:"response_format,omitempty"`
+}
+
+// 响应格式类型
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 响应格式（与 OpenAI 格式一致）
+type ResponseFormat struct {
+	Type       string      `json:"type"` // text, json_object, json_schema
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema json_schema 响应格式的 Schema 定义
+type JSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
 }
 
 // Message 聊天消息
+// 多模态消息的 Content 为所有文本片段的拼接，Parts 保留完整的片段顺序
 type Message struct {
-	Role    string `json:"role"`    // system, user, assistant
-	Content string `json:"content"`
+	Role       string        `json:"role"` // system, user, assistant, tool
+	Content    string        `json:"content"`
+	Parts      []ContentPart `json:"parts,omitempty"` // 多模态内容片段，纯文本消息为空
+	Name       string        `json:"name,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // assistant 消息发起的工具调用
+	ToolCallID string        `json:"tool_call_id,omitempty"` // tool 消息对应的工具调用 ID
+}
+
+// 内容片段类型
+const (
+	ContentPartText       = "text"
+	ContentPartImageURL   = "image_url"
+	ContentPartInputAudio = "input_audio"
+)
+
+// ContentPart 内容片段（与 OpenAI 格式一致）
+type ContentPart struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+// ImageURL 图片地址（http(s) URL 或 Data URL）
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// InputAudio base64 编码的音频
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"` // wav, mp3
+}
+
+// Tool 工具定义（目前仅支持 function）
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction 函数定义
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"` // JSON Schema
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// 工具选择策略
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+	ToolChoiceFunction = "function"
+)
+
+// ToolChoice 工具选择策略
+type ToolChoice struct {
+	Type         string `json:"type"`                    // auto, none, required, function
+	FunctionName string `json:"function_name,omitempty"` // Type 为 function 时指定的函数名
+}
+
+// ToolCall 工具调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // function
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 函数调用
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"` // JSON 字符串
+}
+
+// ToolCallDelta 流式工具调用增量
+// 同一工具调用的首个增量携带 ID、Type 和函数名，后续增量只携带参数片段，通过 Index 关联
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
 }
 
 // ChatResponse 聊天响应（非流式）
@@ -50,9 +202,10 @@ type ChatResponse struct {
 
 // Choice 响应选项
 type Choice struct {
-	Index        int         `json:"index"`
-	Message      Message     `json:"message"`
-	FinishReason string      `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Message      Message         `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+	Logprobs     json.RawMessage `json:"logprobs,omitempty"` // 上游返回的 logprobs（OpenAI 格式）
 }
 
 // ChatStreamChunk 流式响应块
@@ -60,19 +213,23 @@ type ChatStreamChunk struct {
 	ID      string         `json:"id"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"` // 部分 Provider 在流结束时返回使用量
+	Err     error          `json:"-"`               // 非空时表示流因错误终止，为 channel 中的最后一个块
 }
 
 // StreamChoice 流式选项
 type StreamChoice struct {
-	Index        int          `json:"index"`
-	Delta        MessageDelta `json:"delta"`
-	FinishReason *string      `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Delta        MessageDelta    `json:"delta"`
+	FinishReason *string         `json:"finish_reason"`
+	Logprobs     json.RawMessage `json:"logprobs,omitempty"`
 }
 
 // MessageDelta 消息增量
 type MessageDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
 }
 
 // Usage 使用情况
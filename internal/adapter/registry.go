@@ -11,10 +11,13 @@ import (
 type AdapterType string
 
 const (
-	AdapterTypeOpenAI    AdapterType = "openai"
-	AdapterTypeAnthropic AdapterType = "anthropic"
-	AdapterTypeVLLM      AdapterType = "vllm"
-	AdapterTypeOllama    AdapterType = "ollama"
+	AdapterTypeOpenAI      AdapterType = "openai"
+	AdapterTypeAnthropic   AdapterType = "anthropic"
+	AdapterTypeVLLM        AdapterType = "vllm"
+	AdapterTypeOllama      AdapterType = "ollama"
+	AdapterTypeGemini      AdapterType = "gemini"
+	AdapterTypeAzureOpenAI AdapterType = "azure-openai"
+	AdapterTypeBedrock     AdapterType = "bedrock"
 )
 
 // AdapterFactory Adapter 工厂函数
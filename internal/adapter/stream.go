@@ -0,0 +1,53 @@
+package adapter
+
+import "context"
+
+// SendStreamError 将流式请求的终止错误作为最后一个数据块发送给调用方
+// 调用方已取消时直接丢弃
+func SendStreamError(ctx context.Context, ch chan<- *ChatStreamChunk, err error) {
+	select {
+	case ch <- &ChatStreamChunk{Err: err}:
+	case <-ctx.Done():
+	}
+}
+
+// PrimeStream 等待流式响应的第一个数据块
+// 第一个数据块携带错误（如上游返回 4xx/5xx）时返回该错误，便于在开始向客户端输出之前重试或 Fallback；
+// 否则返回一个从第一个数据块开始、内容与原 channel 一致的新 channel
+func PrimeStream(ctx context.Context, chunks <-chan *ChatStreamChunk) (<-chan *ChatStreamChunk, error) {
+	var first *ChatStreamChunk
+	select {
+	case chunk, ok := <-chunks:
+		if !ok {
+			// 上游没有返回任何数据块，视为空响应
+			out := make(chan *ChatStreamChunk)
+			close(out)
+			return out, nil
+		}
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		first = chunk
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	out := make(chan *ChatStreamChunk, cap(chunks))
+	go func() {
+		defer close(out)
+
+		select {
+		case out <- first:
+		case <-ctx.Done():
+			return
+		}
+		for chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// UpstreamError 上游 Provider 返回的 HTTP 错误
+type UpstreamError struct {
+	StatusCode int           // HTTP 状态码
+	Code       string        // 上游错误码或错误类型，如 rate_limit_exceeded、overloaded_error、ThrottlingException
+	Message    string        // 上游错误信息
+	RetryAfter time.Duration // 上游要求的重试等待时间（Retry-After），未返回时为 0
+	Err        error         // 解析后的上游错误响应，无法解析时为 nil
+}
+
+// NewUpstreamError 根据上游响应创建 UpstreamError
+// cause 为解析后的上游错误响应（可为 nil），code 为上游错误码
+func NewUpstreamError(resp *http.Response, body []byte, cause error, code string) *UpstreamError {
+	e := &UpstreamError{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		Message:    string(body),
+		RetryAfter: ParseRetryAfter(resp.Header),
+		Err:        cause,
+	}
+	if cause != nil {
+		e.Message = cause.Error()
+	}
+	return e
+}
+
+// NewStreamError 上游在返回 200 之后（流式响应中途或响应体中）返回的错误
+// statusCode 为 Adapter 按上游错误码推断的 HTTP 状态码，与 HTTP 错误按同样的规则重试和 Fallback
+func NewStreamError(statusCode int, code string, cause error) *UpstreamError {
+	return &UpstreamError{
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    cause.Error(),
+		Err:        cause,
+	}
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimited 上游是否限流（429，或 Anthropic 的 529 overloaded）
+func (e *UpstreamError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == 529
+}
+
+// Retryable 错误是否可以重试或 Fallback：请求超时、限流和 5xx
+func (e *UpstreamError) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.RateLimited() || e.StatusCode >= 500
+}
+
+// ParseRetryAfter 解析 Retry-After（秒数或 HTTP 日期），同时支持 OpenAI、Azure 返回的 retry-after-ms
+// 未返回或无法解析时为 0
+func ParseRetryAfter(header http.Header) time.Duration {
+	if v := header.Get("Retry-After-Ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
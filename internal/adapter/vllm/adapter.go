@@ -76,17 +76,40 @@ func (a *Adapter) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-c
 		}
 
 		// 发送流式请求
-		err := client.DoChatStreamRequest(streamCtx, openaiReq, respChan)
-		if err != nil {
-			// 错误处理：可以选择发送错误块或忽略
-			// 这里简单地记录错误后退出
-			_ = err
+		if err := client.DoChatStreamRequest(streamCtx, openaiReq, respChan); err != nil {
+			// 错误作为最后一个数据块传递，由 Controller 返回给客户端
+			adapter.SendStreamError(ctx, respChan, err)
 		}
 	}()
 
 	return respChan, nil
 }
 
+// Embed 生成文本向量（/embeddings）
+func (a *Adapter) Embed(ctx context.Context, req *adapter.EmbeddingRequest) (*adapter.EmbeddingResponse, error) {
+	client := openai.NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+
+	// 设置超时
+	if a.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.config.Timeout)
+		defer cancel()
+	}
+
+	resp, err := client.DoEmbeddingRequest(ctx, openai.ConvertEmbeddingRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return openai.ConvertEmbeddingResponse(resp), nil
+}
+
+// ListModels 通过上游 GET /models 查询可用模型
+func (a *Adapter) ListModels(ctx context.Context) ([]string, error) {
+	client := openai.NewClient(a.config.BaseURL, a.config.APIKey, a.config.TimeoutSeconds)
+	return client.ListModels(ctx)
+}
+
 // Type 返回 Provider 类型
 func (a *Adapter) Type() string {
 	return string(adapter.AdapterTypeVLLM)
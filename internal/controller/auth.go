@@ -11,21 +11,29 @@ import (
 
 // AuthController 认证控制器
 type AuthController struct {
-	authSvc *service.AuthService
+	authSvc          *service.AuthService
+	rateLimitBackend service.RateLimitBackend
 }
 
 // NewAuthController 创建 Auth Controller
 func NewAuthController(authSvc *service.AuthService) *AuthController {
 	return &AuthController{
-		authSvc: authSvc,
+		authSvc:          authSvc,
+		rateLimitBackend: service.NewMemoryRateLimitBackend(),
 	}
 }
 
+// SetRateLimitBackend 设置注册速率限制使用的令牌桶存储，默认为进程内存储
+// 需要在 RegisterRoutes 之前调用
+func (c *AuthController) SetRateLimitBackend(backend service.RateLimitBackend) {
+	c.rateLimitBackend = backend
+}
+
 // RegisterRoutes 注册路由
 func (c *AuthController) RegisterRoutes(r *gin.RouterGroup) {
 	auth := r.Group("/auth")
 	{
-		auth.POST("/register", middleware.RegisterRateLimit(), c.Register)
+		auth.POST("/register", middleware.RegisterRateLimit(c.rateLimitBackend), c.Register)
 		auth.POST("/login", c.Login)
 		auth.POST("/refresh", c.RefreshToken)
 	}
@@ -300,6 +300,14 @@ func (m *MockUserRepositoryForController) DeleteAPIKey(ctx context.Context, id i
 	return nil
 }
 
+func (m *MockUserRepositoryForController) UpdateUserRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	return nil
+}
+
+func (m *MockUserRepositoryForController) UpdateAPIKeyRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	return nil
+}
+
 // MockJWTServiceForController 用于控制器测试
 type MockJWTServiceForController struct{}
 
@@ -3,8 +3,13 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,16 +25,19 @@ import (
 
 // ChatController Chat API 控制器
 type ChatController struct {
-	router       *service.RouterService
-	retrySvc     *service.RetryService
-	usageService *service.UsageService
+	router        *service.RouterService
+	retrySvc      *service.RetryService
+	usageService  *service.UsageService
+	cache         service.ResponseCache     // 为 nil 时不缓存
+	semanticCache *service.SemanticCache    // 为 nil 时不使用语义缓存
+	rateLimiter   *service.RateLimitService // 为 nil 时不限制
 }
 
 // NewChatController 创建 Chat 控制器
 func NewChatController(router *service.RouterService, usageService *service.UsageService) *ChatController {
 	return &ChatController{
 		router:       router,
-		retrySvc:     service.NewRetryService(),
+		retrySvc:     service.NewRetryServiceWithBreakers(router.Breakers()),
 		usageService: usageService,
 	}
 }
@@ -37,6 +45,7 @@ func NewChatController(router *service.RouterService, usageService *service.Usag
 // RegisterRoutes 注册路由
 func (c *ChatController) RegisterRoutes(r *gin.RouterGroup) {
 	r.POST("/chat/completions", c.ChatCompletions)
+	r.POST("/embeddings", c.Embeddings)
 }
 
 // ChatCompletions Chat Completions 端点
@@ -51,12 +60,15 @@ func (c *ChatController) ChatCompletions(ctx *gin.Context) {
 		logger.L.Warn("Invalid request format",
 			zap.String("trace_id", traceID),
 			zap.Error(err))
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"message": err.Error(),
-				"type":    "invalid_request_error",
-			},
-		})
+		c.handleBindError(ctx, err)
+		return
+	}
+
+	if err := validateMessages(req.Messages); err != nil {
+		logger.L.Warn("Invalid messages",
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		c.invalidRequest(ctx, err.Error())
 		return
 	}
 
@@ -67,11 +79,55 @@ func (c *ChatController) ChatCompletions(ctx *gin.Context) {
 		return
 	}
 
+	// 检查调用方的 RPM/TPM 限制
+	if !c.checkRateLimit(ctx, &req) {
+		return
+	}
+
 	// 生成请求 ID
 	requestID := "chatcmpl-" + uuid.New().String()
 
-	// 获取 Fallback 模型列表
-	fallbackModels := c.getFallbackModels(ctx, modelInfo)
+	// 结果确定的非流式请求先查询响应缓存
+	cacheLookup, cacheStore := cacheDirectives(ctx)
+	var cacheKey string
+	var cacheable bool
+	if c.cache != nil {
+		cacheKey, cacheable = service.ResponseCacheKey(&req)
+	}
+	if cacheable {
+		if cacheLookup {
+			if resp := c.cachedResponse(ctx, cacheKey, requestID); resp != nil {
+				ctx.Header(cacheStatusHeader, "hit")
+				ctx.Set(cacheHitKey, true)
+				c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, cacheHitResult(resp), nil, time.Since(startTime).Milliseconds())
+				ctx.JSON(http.StatusOK, resp)
+				return
+			}
+		}
+		ctx.Header(cacheStatusHeader, "miss")
+	}
+
+	// 开启语义缓存时按最后一条用户消息的相似度查询
+	var semanticQuery *service.SemanticQuery
+	if cacheLookup || cacheStore {
+		semanticQuery = c.prepareSemanticQuery(ctx, &req, modelInfo)
+	}
+	if semanticQuery != nil {
+		if cacheLookup {
+			if resp, similarity := c.semanticCachedResponse(ctx, semanticQuery, requestID); resp != nil {
+				ctx.Header(cacheStatusHeader, "hit")
+				ctx.Header(cacheSimilarityHeader, strconv.FormatFloat(similarity, 'f', 4, 64))
+				ctx.Set(cacheHitKey, true)
+				c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, cacheHitResult(resp), nil, time.Since(startTime).Milliseconds())
+				ctx.JSON(http.StatusOK, resp)
+				return
+			}
+		}
+		ctx.Header(cacheStatusHeader, "miss")
+	}
+
+	// 获取 Fallback 模型列表，跳过健康检查不通过的 Provider
+	fallbackModels := c.router.SkipUnhealthy(c.getFallbackModels(ctx, modelInfo))
 
 	// 设置超时（默认 30 秒，可从 Provider 配置读取）
 	timeout := time.Duration(modelInfo.Provider.Timeout()) * time.Second
@@ -83,26 +139,52 @@ func (c *ChatController) ChatCompletions(ctx *gin.Context) {
 	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
 	defer cancel()
 
-	// 使用重试服务处理请求
-	result, err := c.retrySvc.RetryWithFallback(timeoutCtx, fallbackModels, func(ctx context.Context, modelName string) (any, error) {
-		return c.callProvider(ctx, &req, modelInfo.ProviderName, modelName, requestID)
-	})
-
-	// 记录日志
-	c.logRequestWithRetry(ctx, requestID, &req, modelInfo, result, err, time.Since(startTime).Milliseconds())
+	// 使用重试服务处理请求（Fallback 列表中的项均为 provider/model_name）
+	call := func(ctx context.Context, ref string) (any, error) {
+		providerName, modelName, _ := strings.Cut(ref, "/")
+		return c.trackTarget(ctx, ref, func() (any, error) {
+			return c.callProvider(ctx, &req, providerName, modelName, requestID)
+		})
+	}
+	var result *service.RetryResult
+	if hedgeDelay := modelInfo.HedgeDelay(); hedgeDelay > 0 && len(fallbackModels) > 1 {
+		// 配置了对冲延迟时，主请求超时未返回则并发请求下一个 Fallback 模型
+		result, err = c.retrySvc.HedgedRetryWithFallback(timeoutCtx, fallbackModels, hedgeDelay, call)
+	} else {
+		result, err = c.retrySvc.RetryWithFallback(timeoutCtx, fallbackModels, call)
+	}
 
 	if err != nil {
+		c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, result, err, time.Since(startTime).Milliseconds())
 		c.handleProviderError(ctx, err, result)
 		return
 	}
 
 	// 处理响应
 	if req.Stream {
-		c.handleStreamResponse(ctx, result.Response, requestID, startTime)
-	} else {
-		resp := result.Response.(*model.ChatResponse)
-		ctx.JSON(http.StatusOK, resp)
+		// 开启 stream_resume 时，流中途失败后在剩余的 Fallback 模型上续写
+		if streamResumeEnabled(modelInfo.Provider) && (req.N == nil || *req.N == 1) {
+			remaining := fallbackModels[result.FallbackCount+1:]
+			chunks := result.Response.(<-chan *adapter.ChatStreamChunk)
+			result.Response = c.resumeStream(timeoutCtx, &req, remaining, requestID, chunks)
+		}
+
+		// 使用量在流结束后才能确定，以累计的使用量替换 channel 后再记录日志
+		usage, streamErr := c.handleStreamResponse(ctx, result.Response, requestID, &req)
+		result.Response = &usage
+		c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, result, streamErr, time.Since(startTime).Milliseconds())
+		return
+	}
+
+	c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, result, nil, time.Since(startTime).Milliseconds())
+	resp := result.Response.(*model.ChatResponse)
+	if cacheable && cacheStore {
+		c.storeResponse(ctx, cacheKey, resp)
+	}
+	if semanticQuery != nil && cacheStore {
+		c.storeSemanticResponse(ctx, semanticQuery, resp)
 	}
+	ctx.JSON(http.StatusOK, resp)
 }
 
 // callProvider 调用 Provider
@@ -129,7 +211,8 @@ func (c *ChatController) callProvider(ctx context.Context, req *model.ChatReques
 		if err != nil {
 			return nil, err
 		}
-		return chunks, nil
+		// 等待第一个数据块，上游在开始输出前失败时由重试服务 Fallback
+		return adapter.PrimeStream(ctx, chunks)
 	} else {
 		resp, err := provider.Chat(ctx, adapterReq)
 		if err != nil {
@@ -140,35 +223,94 @@ func (c *ChatController) callProvider(ctx context.Context, req *model.ChatReques
 	}
 }
 
-// getFallbackModels 获取 Fallback 模型列表
+// trackTarget 调用路由目标并记录其处理中请求数和延迟，供路由组的负载均衡策略使用
+// 流式请求在流结束时才算处理完成，延迟为收到第一个数据块的时间
+func (c *ChatController) trackTarget(ctx context.Context, ref string, call func() (any, error)) (any, error) {
+	end := c.router.BeginRequest(ref)
+	start := time.Now()
+
+	resp, err := call()
+	if err != nil {
+		end()
+		return nil, err
+	}
+	c.router.RecordLatency(ref, time.Since(start))
+
+	chunks, ok := resp.(<-chan *adapter.ChatStreamChunk)
+	if !ok {
+		end()
+		return resp, nil
+	}
+
+	out := make(chan *adapter.ChatStreamChunk, cap(chunks))
+	go func() {
+		defer close(out)
+		defer end()
+		for chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return (<-chan *adapter.ChatStreamChunk)(out), nil
+}
+
+// getFallbackModels 获取 Fallback 模型列表，每一项均为 `provider/model_name`
+// fallback_models 中的 `model_name` 指同一 Provider 下的模型，`provider/model_name` 指其他 Provider 的模型
 func (c *ChatController) getFallbackModels(ctx *gin.Context, modelInfo *service.ModelInfo) []string {
+	userModel := modelInfo.ProviderName + "/" + modelInfo.ModelName
+
+	// 通过别名请求时按别名的目标列表 Fallback，跳过未启用的 Provider
+	if modelInfo.Alias != "" {
+		result := []string{userModel}
+		for _, target := range modelInfo.Targets {
+			providerName, _, _ := strings.Cut(target, "/")
+			if _, ok := adapter.GetProvider(providerName); ok && !slices.Contains(result, target) {
+				result = append(result, target)
+			}
+		}
+		return result
+	}
+
 	// 从 Provider 获取 Fallback 配置
 	config := modelInfo.Provider.Config()
 	if config == nil {
 		// 没有 Fallback 配置，只使用当前模型
-		return []string{modelInfo.ModelName}
+		return []string{userModel}
 	}
 
 	// 获取 fallback_models 配置
 	fallbackModelsRaw, ok := config["fallback_models"]
 	if !ok {
-		return []string{modelInfo.ModelName}
+		return []string{userModel}
 	}
 
 	// 解析 Fallback 模型列表
 	fallbackModels, ok := fallbackModelsRaw.([]string)
 	if !ok || len(fallbackModels) == 0 {
-		return []string{modelInfo.ModelName}
+		return []string{userModel}
 	}
 
 	// 构建最终的模型列表，确保用户指定的模型始终在第一位
 	result := make([]string, 0, len(fallbackModels)+1)
-	result = append(result, modelInfo.ModelName)
+	result = append(result, userModel)
 
 	// 将 fallback 列表中除用户指定的模型外的其他模型添加到列表中
 	for _, m := range fallbackModels {
-		if m != modelInfo.ModelName {
-			result = append(result, m)
+		info, err := service.ParseFallbackModel(m, modelInfo.ProviderName)
+		if err != nil {
+			logger.L.Warn("Skipping invalid fallback model",
+				zap.String("trace_id", middleware.GetTraceID(ctx)),
+				zap.String("provider", modelInfo.ProviderName),
+				zap.String("fallback_model", m))
+			continue
+		}
+
+		ref := info.ProviderName + "/" + info.ModelName
+		if !slices.Contains(result, ref) {
+			result = append(result, ref)
 		}
 	}
 	return result
@@ -187,6 +329,16 @@ func (c *ChatController) handleModelError(ctx *gin.Context, err error, traceID s
 				"type":    "invalid_request_error",
 			},
 		})
+	case *service.ModelNotFoundError:
+		logger.L.Warn("Model not found",
+			zap.String("trace_id", traceID),
+			zap.Error(e))
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"message": e.Error(),
+				"type":    "invalid_request_error",
+			},
+		})
 	case *service.ProviderNotFoundError:
 		logger.L.Warn("Provider not found",
 			zap.String("trace_id", traceID),
@@ -214,15 +366,57 @@ func (c *ChatController) handleModelError(ctx *gin.Context, err error, traceID s
 func (c *ChatController) handleProviderError(ctx *gin.Context, err error, result *service.RetryResult) {
 	traceID := middleware.GetTraceID(ctx)
 
+	// Provider 不支持请求中的内容或参数，属于客户端错误
+	var contentErr *adapter.UnsupportedContentError
+	if errors.As(err, &contentErr) {
+		logger.L.Warn("Unsupported content",
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		c.invalidRequest(ctx, contentErr.Error())
+		return
+	}
+
+	var paramErr *adapter.UnsupportedParameterError
+	if errors.As(err, &paramErr) {
+		logger.L.Warn("Unsupported parameter",
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		c.invalidRequest(ctx, paramErr.Error())
+		return
+	}
+
 	if result != nil && len(result.AttemptDetails) > 0 {
-		// Fallback 耗尽
+		// Fallback 耗尽，按最后一次尝试的错误确定状态码
+		lastErr := result.AttemptDetails[len(result.AttemptDetails)-1].Error
+		status, errType := upstreamErrorStatus(lastErr)
+		message := fmt.Sprintf("All models failed after %d attempts. Last error: %v", len(result.AttemptDetails), lastErr)
+		if errType == "invalid_request_error" {
+			message = lastErr.Error()
+		}
+
+		var upErr *adapter.UpstreamError
+		if errors.As(lastErr, &upErr) && upErr.RateLimited() && upErr.RetryAfter > 0 {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(upErr.RetryAfter.Seconds()))))
+		}
+
 		details := make([]gin.H, 0, len(result.AttemptDetails))
 		for _, detail := range result.AttemptDetails {
-			details = append(details, gin.H{
-				"model":      detail.ModelName,
-				"error_type": detail.ErrorType,
+			item := gin.H{
+				"model":       detail.ModelName,
+				"attempt":     detail.Attempt,
+				"error_type":  detail.ErrorType,
 				"duration_ms": detail.Duration.Milliseconds(),
-			})
+			}
+			if detail.Backoff > 0 {
+				item["backoff_ms"] = detail.Backoff.Milliseconds()
+			}
+			if detail.Hedge {
+				item["hedge"] = true
+			}
+			if detail.ProviderName != "" {
+				item["provider"] = detail.ProviderName
+			}
+			details = append(details, item)
 		}
 
 		logger.L.Error("All models failed",
@@ -230,10 +424,10 @@ func (c *ChatController) handleProviderError(ctx *gin.Context, err error, result
 			zap.Int("attempt_count", len(result.AttemptDetails)),
 			zap.Any("attempt_details", result.AttemptDetails))
 
-		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+		ctx.JSON(status, gin.H{
 			"error": gin.H{
-				"message": fmt.Sprintf("All models failed after %d attempts. Last error: %v", len(result.AttemptDetails), err),
-				"type":    "service_unavailable",
+				"message": message,
+				"type":    errType,
 				"details": details,
 			},
 		})
@@ -253,9 +447,35 @@ func (c *ChatController) handleProviderError(ctx *gin.Context, err error, result
 	})
 }
 
+// upstreamErrorStatus 将上游错误映射为返回给客户端的状态码和错误类型
+// 上游拒绝请求（4xx）时透传状态码；上游鉴权失败属于网关配置问题，返回 502；无法识别的错误返回 503
+func upstreamErrorStatus(err error) (int, string) {
+	var upErr *adapter.UpstreamError
+	switch {
+	case errors.As(err, &upErr):
+		switch {
+		case upErr.RateLimited():
+			return http.StatusTooManyRequests, "rate_limit_error"
+		case upErr.StatusCode == http.StatusRequestTimeout || upErr.StatusCode == http.StatusGatewayTimeout:
+			return http.StatusGatewayTimeout, "timeout_error"
+		case upErr.StatusCode == http.StatusServiceUnavailable:
+			return http.StatusServiceUnavailable, "service_unavailable"
+		case upErr.StatusCode == http.StatusUnauthorized || upErr.StatusCode == http.StatusForbidden || upErr.StatusCode >= 500:
+			return http.StatusBadGateway, "api_error"
+		default:
+			return upErr.StatusCode, "invalid_request_error"
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout_error"
+	default:
+		return http.StatusServiceUnavailable, "service_unavailable"
+	}
+}
+
 // handleNonStreamResponse 处理非流式响应（已合并到 callProvider）
-// handleStreamResponse 处理流式响应
-func (c *ChatController) handleStreamResponse(ctx *gin.Context, resp any, requestID string, startTime time.Time) {
+// handleStreamResponse 处理流式响应，返回本次请求的使用量和流中途的错误
+// 上游未返回使用量时按请求消息和已输出内容估算
+func (c *ChatController) handleStreamResponse(ctx *gin.Context, resp any, requestID string, req *model.ChatRequest) (model.ChatUsage, error) {
 	chunks := resp.(<-chan *adapter.ChatStreamChunk)
 
 	// 设置 SSE Header
@@ -272,31 +492,208 @@ func (c *ChatController) handleStreamResponse(ctx *gin.Context, resp any, reques
 				"type":    "api_error",
 			},
 		})
-		return
+		return model.ChatUsage{}, errors.New("streaming not supported")
 	}
 
+	var (
+		upstreamUsage *adapter.Usage
+		completion    strings.Builder
+		lastModel     string
+	)
+
 	// 流式发送数据
 	for chunk := range chunks {
-		// 检查客户端是否断开
+		// 检查客户端是否断开（已生成的内容仍需计费）
 		select {
 		case <-ctx.Request.Context().Done():
-			return
+			return streamUsage(upstreamUsage, req.Messages, completion.String()), ctx.Request.Context().Err()
 		default:
 		}
 
-		// 转换为 SSE 格式
-		sseChunk := c.toStreamChunk(chunk, requestID)
+		// 上游错误：以 OpenAI 格式的 error 事件结束流，不再发送 [DONE]
+		if chunk.Err != nil {
+			logger.L.Error("Stream interrupted by upstream error",
+				zap.String("trace_id", middleware.GetTraceID(ctx)),
+				zap.String("request_id", requestID),
+				zap.Error(chunk.Err))
+			writeSSE(ctx, flusher, gin.H{
+				"error": gin.H{
+					"message": chunk.Err.Error(),
+					"type":    "api_error",
+				},
+			})
+			return streamUsage(upstreamUsage, req.Messages, completion.String()), chunk.Err
+		}
+
+		if chunk.Usage != nil {
+			upstreamUsage = chunk.Usage
+		}
+		lastModel = chunk.Model
+		for _, choice := range chunk.Choices {
+			completion.WriteString(choice.Delta.Content)
+			for _, call := range choice.Delta.ToolCalls {
+				completion.WriteString(call.Function.Name)
+				completion.WriteString(call.Function.Arguments)
+			}
+		}
+
+		// 只包含使用量的块不转发，由最后的 usage 块统一返回
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		// 转换为 SSE 格式并发送
+		writeSSE(ctx, flusher, c.toStreamChunk(chunk, requestID))
+	}
 
-		// 发送数据
-		data, _ := json.Marshal(sseChunk)
-		fmt.Fprintf(ctx.Writer, "data: %s\n", data)
-		fmt.Fprint(ctx.Writer, "\n")
-		flusher.Flush()
+	usage := streamUsage(upstreamUsage, req.Messages, completion.String())
+
+	// 客户端要求时在结束前发送使用量（OpenAI stream_options.include_usage 格式）
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		writeSSE(ctx, flusher, model.ChatStreamResponse{
+			ID:      requestID,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   lastModel,
+			Choices: []model.ChatStreamChoice{},
+			Usage:   &usage,
+		})
 	}
 
 	// 发送结束标记
 	fmt.Fprint(ctx.Writer, "data: [DONE]\n\n")
 	flusher.Flush()
+
+	return usage, nil
+}
+
+// streamResumeEnabled Provider 是否开启流式续写（extra_config.stream_resume）
+func streamResumeEnabled(provider adapter.Provider) bool {
+	enabled, _ := provider.Config()["stream_resume"].(bool)
+	return enabled
+}
+
+// resumeStream 流中途失败时依次在剩余的 Fallback 模型上续写
+// 已输出的内容作为 assistant 消息追加到请求末尾，由下一个模型接着生成（尽力而为，续写效果取决于模型）；
+// 已开始输出工具调用或没有剩余模型时，将错误原样传递给客户端
+func (c *ChatController) resumeStream(ctx context.Context, req *model.ChatRequest, models []string, requestID string, chunks <-chan *adapter.ChatStreamChunk) <-chan *adapter.ChatStreamChunk {
+	out := make(chan *adapter.ChatStreamChunk, cap(chunks))
+	breakers := c.router.Breakers()
+
+	go func() {
+		defer close(out)
+
+		var (
+			partial   strings.Builder
+			toolCalls bool
+			resumed   bool
+		)
+		for {
+			var streamErr error
+			for chunk := range chunks {
+				if chunk.Err != nil {
+					streamErr = chunk.Err
+					break
+				}
+				for i := range chunk.Choices {
+					// 续写的流不再重复发送 role
+					if resumed {
+						chunk.Choices[i].Delta.Role = ""
+					}
+					partial.WriteString(chunk.Choices[i].Delta.Content)
+					toolCalls = toolCalls || len(chunk.Choices[i].Delta.ToolCalls) > 0
+				}
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if streamErr == nil {
+				return
+			}
+
+			// 依次尝试剩余模型，直到有模型成功开始输出，跳过已熔断的模型
+			var next <-chan *adapter.ChatStreamChunk
+			for !toolCalls && next == nil && len(models) > 0 {
+				ref := models[0]
+				providerName, modelName, _ := strings.Cut(ref, "/")
+				if !breakers.Allow(ref) {
+					models = models[1:]
+					continue
+				}
+
+				logger.L.Warn("Stream interrupted, resuming on fallback model",
+					zap.String("request_id", requestID),
+					zap.String("provider", providerName),
+					zap.String("model", modelName),
+					zap.Error(streamErr))
+
+				resumeReq := *req
+				resumeReq.Messages = slices.Clone(req.Messages)
+				// Anthropic 等要求 assistant 预填充内容不能以空白结尾
+				if text := strings.TrimRight(partial.String(), " \t\n"); text != "" {
+					resumeReq.Messages = append(resumeReq.Messages, model.ChatMessage{Role: "assistant", Content: model.TextContent(text)})
+				}
+
+				resp, err := c.trackTarget(ctx, ref, func() (any, error) {
+					return c.callProvider(ctx, &resumeReq, providerName, modelName, requestID)
+				})
+				models = models[1:]
+				if err != nil {
+					if ctx.Err() != nil {
+						breakers.Release(ref)
+					} else {
+						breakers.Record(ref, !c.retrySvc.IsRetryableError(err))
+					}
+					streamErr = err
+					continue
+				}
+				breakers.Record(ref, true)
+				next = resp.(<-chan *adapter.ChatStreamChunk)
+			}
+
+			if next == nil {
+				adapter.SendStreamError(ctx, out, streamErr)
+				return
+			}
+			chunks = next
+			resumed = true
+		}
+	}()
+
+	return out
+}
+
+// writeSSE 发送一个 SSE 数据块
+func writeSSE(ctx *gin.Context, flusher http.Flusher, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(ctx.Writer, "data: %s\n", data)
+	fmt.Fprint(ctx.Writer, "\n")
+	flusher.Flush()
+}
+
+// streamUsage 计算流式请求的使用量
+// 优先使用上游返回的使用量，否则按请求消息和输出内容估算
+func streamUsage(upstream *adapter.Usage, messages []model.ChatMessage, completion string) model.ChatUsage {
+	if upstream != nil && (upstream.PromptTokens > 0 || upstream.CompletionTokens > 0) {
+		usage := model.ChatUsage{
+			PromptTokens:     upstream.PromptTokens,
+			CompletionTokens: upstream.CompletionTokens,
+			TotalTokens:      upstream.TotalTokens,
+		}
+		if usage.TotalTokens == 0 {
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+		return usage
+	}
+
+	usage := model.ChatUsage{
+		PromptTokens:     service.EstimatePromptTokens(messages),
+		CompletionTokens: service.EstimateTokens(completion),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
 }
 
 // toAdapterRequest 转换为 Adapter 请求格式
@@ -304,17 +701,129 @@ func (c *ChatController) toAdapterRequest(req *model.ChatRequest, modelName stri
 	messages := make([]adapter.Message, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = adapter.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
+			Role:       msg.Role,
+			Content:    msg.Content.Text,
+			Parts:      toAdapterContentParts(msg.Content.Parts),
+			Name:       msg.Name,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			messages[i].ToolCalls = append(messages[i].ToolCalls, adapter.ToolCall{
+				ID:   call.ID,
+				Type: call.Type,
+				Function: adapter.FunctionCall{
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				},
+			})
 		}
 	}
 
-	return &adapter.ChatRequest{
+	adapterReq := &adapter.ChatRequest{
 		Messages:    messages,
 		Model:       modelName,
 		Temperature: req.Temperature,
 		MaxTokens:   req.MaxTokens,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+		User:        req.User,
+
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Seed:             req.Seed,
+		LogitBias:        req.LogitBias,
+		Logprobs:         req.Logprobs,
+		TopLogprobs:      req.TopLogprobs,
+	}
+
+	if req.N != nil {
+		adapterReq.N = *req.N
+	}
+
+	if req.ResponseFormat != nil {
+		adapterReq.ResponseFormat = &adapter.ResponseFormat{Type: req.ResponseFormat.Type}
+		if schema := req.ResponseFormat.JSONSchema; schema != nil {
+			adapterReq.ResponseFormat.JSONSchema = &adapter.JSONSchema{
+				Name:        schema.Name,
+				Description: schema.Description,
+				Schema:      schema.Schema,
+				Strict:      schema.Strict,
+			}
+		}
+	}
+
+	for _, tool := range req.Tools {
+		adapterReq.Tools = append(adapterReq.Tools, adapter.Tool{
+			Type: tool.Type,
+			Function: adapter.ToolFunction{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+				Strict:      tool.Function.Strict,
+			},
+		})
+	}
+
+	if req.ToolChoice != nil {
+		adapterReq.ToolChoice = &adapter.ToolChoice{
+			Type:         req.ToolChoice.Type,
+			FunctionName: req.ToolChoice.FunctionName,
+		}
+	}
+
+	return adapterReq
+}
+
+// toAdapterContentParts 转换多模态内容片段
+func toAdapterContentParts(parts []model.ContentPart) []adapter.ContentPart {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	result := make([]adapter.ContentPart, len(parts))
+	for i, part := range parts {
+		result[i] = adapter.ContentPart{Type: part.Type, Text: part.Text}
+		if part.ImageURL != nil {
+			result[i].ImageURL = &adapter.ImageURL{URL: part.ImageURL.URL, Detail: part.ImageURL.Detail}
+		}
+		if part.InputAudio != nil {
+			result[i].InputAudio = &adapter.InputAudio{Data: part.InputAudio.Data, Format: part.InputAudio.Format}
+		}
 	}
+	return result
+}
+
+// validateMessages 校验消息角色与内容
+// - tool 消息必须携带 tool_call_id
+// - content 不能为空，携带 tool_calls 的 assistant 消息除外
+// - 图片、音频片段只能出现在 user 消息中
+func validateMessages(messages []model.ChatMessage) error {
+	for i, msg := range messages {
+		switch msg.Role {
+		case "system", "user", "assistant":
+		case "tool":
+			if msg.ToolCallID == "" {
+				return fmt.Errorf("messages[%d]: tool message requires tool_call_id", i)
+			}
+		default:
+			return fmt.Errorf("messages[%d]: invalid role %q", i, msg.Role)
+		}
+
+		if len(msg.ToolCalls) > 0 && msg.Role != "assistant" {
+			return fmt.Errorf("messages[%d]: only assistant messages can contain tool_calls", i)
+		}
+
+		if msg.Content.IsEmpty() && len(msg.ToolCalls) == 0 {
+			return fmt.Errorf("messages[%d]: content is required", i)
+		}
+
+		for _, part := range msg.Content.Parts {
+			if part.Type != model.ContentPartText && msg.Role != "user" {
+				return fmt.Errorf("messages[%d]: %s content is only allowed in user messages", i, part.Type)
+			}
+		}
+	}
+	return nil
 }
 
 // toChatResponse 转换为 Chat 响应格式
@@ -324,10 +833,12 @@ func (c *ChatController) toChatResponse(adapterResp *adapter.ChatResponse, reque
 		choices[i] = model.ChatChoice{
 			Index: choice.Index,
 			Message: model.ChatMessage{
-				Role:    choice.Message.Role,
-				Content: choice.Message.Content,
+				Role:      choice.Message.Role,
+				Content:   model.TextContent(choice.Message.Content),
+				ToolCalls: toModelToolCalls(choice.Message.ToolCalls),
 			},
 			FinishReason: choice.FinishReason,
+			Logprobs:     choice.Logprobs,
 		}
 	}
 
@@ -352,10 +863,12 @@ func (c *ChatController) toStreamChunk(chunk *adapter.ChatStreamChunk, requestID
 		choices[i] = model.ChatStreamChoice{
 			Index: choice.Index,
 			Delta: model.ChatMessageDelta{
-				Role:    choice.Delta.Role,
-				Content: choice.Delta.Content,
+				Role:      choice.Delta.Role,
+				Content:   choice.Delta.Content,
+				ToolCalls: toModelToolCallDeltas(choice.Delta.ToolCalls),
 			},
 			FinishReason: choice.FinishReason,
+			Logprobs:     choice.Logprobs,
 		}
 	}
 
@@ -368,8 +881,50 @@ func (c *ChatController) toStreamChunk(chunk *adapter.ChatStreamChunk, requestID
 	}
 }
 
+// toModelToolCalls 转换工具调用
+func toModelToolCalls(calls []adapter.ToolCall) []model.ToolCall {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	result := make([]model.ToolCall, len(calls))
+	for i, call := range calls {
+		result[i] = model.ToolCall{
+			ID:   call.ID,
+			Type: call.Type,
+			Function: model.FunctionCall{
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+			},
+		}
+	}
+	return result
+}
+
+// toModelToolCallDeltas 转换流式工具调用增量
+func toModelToolCallDeltas(deltas []adapter.ToolCallDelta) []model.ToolCallDelta {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	result := make([]model.ToolCallDelta, len(deltas))
+	for i, delta := range deltas {
+		result[i] = model.ToolCallDelta{
+			Index: delta.Index,
+			ID:    delta.ID,
+			Type:  delta.Type,
+			Function: model.FunctionCall{
+				Name:      delta.Function.Name,
+				Arguments: delta.Function.Arguments,
+			},
+		}
+	}
+	return result
+}
+
 // logRequestWithRetry 记录带重试信息的请求日志
-func (c *ChatController) logRequestWithRetry(ctx *gin.Context, requestID string, req *model.ChatRequest, modelInfo *service.ModelInfo, result *service.RetryResult, err error, latencyMs int64) {
+// requestModel 为客户端请求的 model 参数（provider/model_name）
+func (c *ChatController) logRequestWithRetry(ctx *gin.Context, requestID string, requestModel string, modelInfo *service.ModelInfo, result *service.RetryResult, err error, latencyMs int64) {
 	traceID := middleware.GetTraceID(ctx)
 	apiKeyMasked, _ := ctx.Get("api_key_masked")
 	authType, _ := middleware.GetAuthType(ctx)
@@ -390,58 +945,104 @@ func (c *ChatController) logRequestWithRetry(ctx *gin.Context, requestID string,
 	attemptDetails := make([]model.AttemptDetail, 0)
 	if result != nil {
 		for _, detail := range result.AttemptDetails {
+			providerName := detail.ProviderName
+			if providerName == "" {
+				providerName = modelInfo.ProviderName
+			}
 			attemptDetails = append(attemptDetails, model.AttemptDetail{
-				ModelName: detail.ModelName,
-				ErrorType: detail.ErrorType,
-				DurationMs: detail.Duration.Milliseconds(),
+				ProviderName: providerName,
+				ModelName:    detail.ModelName,
+				Attempt:      detail.Attempt,
+				ErrorType:    detail.ErrorType,
+				DurationMs:   detail.Duration.Milliseconds(),
+				BackoffMs:    detail.Backoff.Milliseconds(),
+				Hedge:        detail.Hedge,
 			})
 		}
 	}
 
 	log := model.ChatLog{
-		RequestID:        requestID,
-		TraceID:          traceID,
-		APIKey:           fmt.Sprintf("%v", apiKeyMasked),
-		Model:            req.Model,
-		ProviderName:     modelInfo.ProviderName,
-		ProviderType:     modelInfo.Provider.Type(),
-		ModelName:        modelInfo.ModelName,
-		FallbackCount:    0,
-		FinalModelName:   modelInfo.ModelName,
-		AttemptDetails:   attemptDetails,
-		PromptTokens:     0,
-		CompletionTokens: 0,
-		TotalTokens:      0,
-		LatencyMs:        latencyMs,
-		Status:           status,
-		Error:            errorMsg,
-		Timestamp:        time.Now(),
+		RequestID:         requestID,
+		TraceID:           traceID,
+		APIKey:            fmt.Sprintf("%v", apiKeyMasked),
+		Model:             requestModel,
+		Alias:             modelInfo.Alias,
+		ProviderName:      modelInfo.ProviderName,
+		ProviderType:      modelInfo.Provider.Type(),
+		ModelName:         modelInfo.ModelName,
+		FallbackCount:     0,
+		FinalModelName:    modelInfo.ModelName,
+		FinalProviderName: modelInfo.ProviderName,
+		AttemptDetails:    attemptDetails,
+		PromptTokens:      0,
+		CompletionTokens:  0,
+		TotalTokens:       0,
+		LatencyMs:         latencyMs,
+		Status:            status,
+		Error:             errorMsg,
+		Timestamp:         time.Now(),
+	}
+
+	if result != nil {
+		log.Hedged = result.Hedged
 	}
 
 	if result != nil && result.Success {
 		log.FallbackCount = result.FallbackCount
 		log.FinalModelName = result.FinalModelName
+		if result.FinalProviderName != "" {
+			log.FinalProviderName = result.FinalProviderName
+		}
 
 		// 如果是响应中有 Usage 信息
-		if resp, ok := result.Response.(*model.ChatResponse); ok {
+		switch resp := result.Response.(type) {
+		case *model.ChatResponse:
 			log.PromptTokens = resp.Usage.PromptTokens
 			log.CompletionTokens = resp.Usage.CompletionTokens
 			log.TotalTokens = resp.Usage.TotalTokens
+		case *model.ChatUsage:
+			// 流式请求结束后累计的使用量
+			log.PromptTokens = resp.PromptTokens
+			log.CompletionTokens = resp.CompletionTokens
+			log.TotalTokens = resp.TotalTokens
+		case *model.EmbeddingResponse:
+			log.PromptTokens = resp.Usage.PromptTokens
+			log.TotalTokens = resp.Usage.TotalTokens
 		}
 	}
 
+	// 按实际使用量对账限流额度，缓存命中不消耗上游 Token
+	if ctx.GetBool(cacheHitKey) {
+		c.reconcileRateLimit(ctx, 0)
+	} else {
+		c.reconcileRateLimit(ctx, log.TotalTokens)
+	}
+
 	// 使用结构化日志
 	if status == "success" {
 		logger.L.Info("Chat request completed",
 			zap.String("trace_id", log.TraceID),
 			zap.String("request_id", log.RequestID),
 			zap.String("model", log.Model),
+			zap.String("alias", log.Alias),
 			zap.String("provider", log.ProviderName),
 			zap.Int("fallback_count", log.FallbackCount),
+			zap.String("final_provider", log.FinalProviderName),
 			zap.String("final_model", log.FinalModelName),
+			zap.Bool("hedged", log.Hedged),
+			zap.Bool("cache_hit", ctx.GetBool(cacheHitKey)),
 			zap.Int64("latency_ms", log.LatencyMs),
 			zap.String("auth_type", authType),
 			zap.String("status", log.Status))
+		if log.Hedged {
+			// 对冲请求记录全部尝试，胜出的为 final_provider/final_model
+			logger.L.Info("Hedged request attempts",
+				zap.String("trace_id", log.TraceID),
+				zap.String("request_id", log.RequestID),
+				zap.String("final_provider", log.FinalProviderName),
+				zap.String("final_model", log.FinalModelName),
+				zap.Any("attempt_details", log.AttemptDetails))
+		}
 	} else {
 		logger.L.Error("Chat request failed",
 			zap.String("trace_id", log.TraceID),
@@ -468,14 +1069,15 @@ func (c *ChatController) logRequestWithRetry(ctx *gin.Context, requestID string,
 			APIKeyID:         apiKeyIDValue,
 			RequestID:        requestID,
 			TraceID:          traceID,
-			Model:            req.Model,
-			ProviderName:     modelInfo.ProviderName,
+			Model:            requestModel,
+			ProviderName:     log.FinalProviderName, // 实际处理请求的 Provider（Fallback、路由组选择后）
 			PromptTokens:     log.PromptTokens,
 			CompletionTokens: log.CompletionTokens,
 			TotalTokens:      log.TotalTokens,
 			LatencyMs:        latencyMs,
 			Status:           status,
 			ErrorType:        errorMsg,
+			CacheHit:         ctx.GetBool(cacheHitKey),
 		}
 
 		// 异步记录使用量（使用独立 context）
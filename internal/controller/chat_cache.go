@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/middleware"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+const (
+	// cacheStatusHeader 响应缓存状态响应头：hit 或 miss
+	cacheStatusHeader = "x-courier-cache"
+	// cacheSimilarityHeader 语义缓存命中时的余弦相似度响应头
+	cacheSimilarityHeader = "x-courier-cache-similarity"
+	// cacheHitKey gin.Context 中标记请求由响应缓存返回的 key，记录使用量时读取
+	cacheHitKey = "cache_hit"
+)
+
+// SetResponseCache 设置非流式响应缓存，为 nil 时不缓存
+func (c *ChatController) SetResponseCache(cache service.ResponseCache) {
+	c.cache = cache
+}
+
+// cacheDirectives 解析请求的 Cache-Control
+// no-cache 跳过缓存查询（仍写入新的响应），no-store 既不查询也不写入
+func cacheDirectives(ctx *gin.Context) (lookup, store bool) {
+	lookup, store = true, true
+	for _, directive := range strings.Split(ctx.GetHeader("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache":
+			lookup = false
+		case "no-store":
+			lookup, store = false, false
+		}
+	}
+	return lookup, store
+}
+
+// cachedResponse 查询响应缓存，命中时返回以本次请求 ID 改写的响应
+func (c *ChatController) cachedResponse(ctx *gin.Context, key, requestID string) *model.ChatResponse {
+	resp, err := c.cache.Get(ctx.Request.Context(), key)
+	if err != nil {
+		// 缓存不可用时直接请求上游
+		logger.L.Warn("Failed to read response cache",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+		return nil
+	}
+	if resp == nil {
+		return nil
+	}
+
+	resp.ID = requestID
+	resp.Created = time.Now().Unix()
+	return resp
+}
+
+// storeResponse 写入响应缓存，失败只记录日志
+func (c *ChatController) storeResponse(ctx *gin.Context, key string, resp *model.ChatResponse) {
+	if err := c.cache.Set(ctx.Request.Context(), key, resp); err != nil {
+		logger.L.Warn("Failed to write response cache",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+	}
+}
+
+// cacheHitResult 将缓存命中转换为重试结果，用于记录日志和使用量
+// 缓存的响应中 model 为最初处理请求的 provider/model_name
+func cacheHitResult(resp *model.ChatResponse) *service.RetryResult {
+	providerName, modelName, _ := strings.Cut(resp.Model, "/")
+	return &service.RetryResult{
+		Success:           true,
+		FinalModelName:    modelName,
+		FinalProviderName: providerName,
+		Response:          resp,
+	}
+}
+
+// SetSemanticCache 设置语义缓存，为 nil 时不使用
+func (c *ChatController) SetSemanticCache(cache *service.SemanticCache) {
+	c.semanticCache = cache
+}
+
+// semanticScope 语义缓存的隔离标识：按 API Key 隔离时使用 API Key ID（JWT 认证的请求没有 API Key，按用户隔离），
+// 否则使用用户 ID；无法识别调用方时返回 false
+func (c *ChatController) semanticScope(ctx *gin.Context) (string, bool) {
+	if c.semanticCache.Scope() == service.SemanticCacheScopeAPIKey {
+		if apiKeyID, ok := ctx.Get("api_key_id"); ok {
+			return fmt.Sprintf("api_key:%v", apiKeyID), true
+		}
+	}
+	if userID, ok := ctx.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID), true
+	}
+	return "", false
+}
+
+// prepareSemanticQuery 请求开启了语义缓存时计算查询向量，不适用或计算失败时返回 nil
+func (c *ChatController) prepareSemanticQuery(ctx *gin.Context, req *model.ChatRequest, modelInfo *service.ModelInfo) *service.SemanticQuery {
+	if !c.semanticCache.EnabledFor(modelInfo) {
+		return nil
+	}
+	scope, ok := c.semanticScope(ctx)
+	if !ok {
+		return nil
+	}
+
+	q, call, err := c.semanticCache.Prepare(ctx.Request.Context(), scope, req)
+	if call != nil {
+		c.recordSemanticEmbeddingUsage(ctx, call)
+	}
+	if err != nil {
+		// Embeddings 不可用时直接请求上游
+		logger.L.Warn("Failed to prepare semantic cache query",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+		return nil
+	}
+	return q
+}
+
+// recordSemanticEmbeddingUsage 将计算查询向量的 Embeddings 调用记录到使用量
+// 与 /v1/embeddings 的请求一样单独记录一条，trace_id 与本次对话请求相同
+func (c *ChatController) recordSemanticEmbeddingUsage(ctx *gin.Context, call *service.SemanticEmbeddingCall) {
+	userID, hasUserID := ctx.Get("user_id")
+	if !hasUserID || c.usageService == nil {
+		return
+	}
+
+	var apiKeyIDValue *int64
+	if apiKeyID, ok := ctx.Get("api_key_id"); ok {
+		id := apiKeyID.(int64)
+		apiKeyIDValue = &id
+	}
+
+	status := "success"
+	errorMsg := ""
+	if call.Err != nil {
+		status = "error"
+		errorMsg = call.Err.Error()
+	}
+
+	record := &model.UsageRecord{
+		UserID:       userID.(int64),
+		APIKeyID:     apiKeyIDValue,
+		RequestID:    "emb-" + uuid.New().String(),
+		TraceID:      middleware.GetTraceID(ctx),
+		Model:        call.Model,
+		ProviderName: call.ProviderName,
+		PromptTokens: call.PromptTokens,
+		TotalTokens:  call.TotalTokens,
+		LatencyMs:    call.Latency.Milliseconds(),
+		Status:       status,
+		ErrorType:    errorMsg,
+	}
+
+	// 异步记录使用量（使用独立 context）
+	if err := c.usageService.RecordUsage(context.Background(), record); err != nil {
+		logger.L.Error("Failed to record usage",
+			zap.String("request_id", record.RequestID),
+			zap.Any("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// semanticCachedResponse 查询语义缓存，命中时返回以本次请求 ID 改写的响应和相似度
+func (c *ChatController) semanticCachedResponse(ctx *gin.Context, q *service.SemanticQuery, requestID string) (*model.ChatResponse, float64) {
+	resp, similarity, err := c.semanticCache.Lookup(ctx.Request.Context(), q)
+	if err != nil {
+		logger.L.Warn("Failed to read semantic cache",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+		return nil, 0
+	}
+	if resp == nil {
+		return nil, 0
+	}
+
+	resp.ID = requestID
+	resp.Created = time.Now().Unix()
+	return resp, similarity
+}
+
+// storeSemanticResponse 写入语义缓存，失败只记录日志
+func (c *ChatController) storeSemanticResponse(ctx *gin.Context, q *service.SemanticQuery, resp *model.ChatResponse) {
+	if err := c.semanticCache.Store(ctx.Request.Context(), q, resp); err != nil {
+		logger.L.Warn("Failed to write semantic cache",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+	}
+}
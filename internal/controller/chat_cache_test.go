@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/middleware"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// TestChatCompletions_ResponseCache 测试结果确定的非流式请求命中响应缓存
+func TestChatCompletions_ResponseCache(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	setupChatRouter(t, provider)
+
+	router := gin.New()
+	chatCtrl := NewChatController(service.NewRouterService(), nil)
+	chatCtrl.SetResponseCache(service.NewMemoryResponseCache(time.Minute, 10))
+	chatCtrl.RegisterRoutes(router.Group("/v1"))
+
+	post := func(body, cacheControl string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if cacheControl != "" {
+			req.Header.Set("Cache-Control", cacheControl)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"model":"openai/gpt-4o","temperature":0,"messages":[{"role":"user","content":"Hi"}]}`
+
+	w := post(body, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "miss", w.Header().Get(cacheStatusHeader))
+	var first model.ChatResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+
+	// 相同请求命中缓存，不请求上游，响应 ID 为本次请求的 ID
+	w = post(body, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hit", w.Header().Get(cacheStatusHeader))
+	var cached model.ChatResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cached))
+	assert.NotEqual(t, first.ID, cached.ID)
+	assert.Equal(t, first.Choices, cached.Choices)
+	assert.Equal(t, "openai/gpt-4o", cached.Model)
+	assert.Len(t, provider.requests, 1)
+
+	// no-cache 跳过缓存
+	w = post(body, "no-cache")
+	assert.Equal(t, "miss", w.Header().Get(cacheStatusHeader))
+	assert.Len(t, provider.requests, 2)
+
+	// temperature 不为 0 的请求不缓存
+	w = post(`{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`, "")
+	assert.Empty(t, w.Header().Get(cacheStatusHeader))
+	assert.Len(t, provider.requests, 3)
+}
+
+// TestChatCompletions_SemanticCache 测试开启语义缓存的模型按调用方命中相似问题的响应
+func TestChatCompletions_SemanticCache(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{
+		MockProvider: MockProvider{name: "openai", typ: "openai"},
+		config:       map[string]any{"semantic_cache": true},
+	}}
+	// 模拟的 Embeddings 对任何输入返回相同的向量
+	embedder := &MockEmbeddingProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "embedder", typ: "openai"}}}
+	setupChatRouter(t, provider, embedder)
+
+	cfg := service.DefaultSemanticCacheConfig()
+	cfg.EmbeddingModel = "embedder/text-embedding-3-small"
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", ctx.GetHeader("X-Test-User"))
+	})
+	chatCtrl := NewChatController(service.NewRouterService(), nil)
+	chatCtrl.SetSemanticCache(service.NewSemanticCacheWithStore(cfg, service.NewMemoryVectorIndex(time.Minute, 10)))
+	chatCtrl.RegisterRoutes(router.Group("/v1"))
+
+	post := func(user, text string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"` + text + `"}]}`
+		req, _ := http.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("1", "What is the capital of France?")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "miss", w.Header().Get(cacheStatusHeader))
+
+	w = post("1", "what's the capital city of France")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hit", w.Header().Get(cacheStatusHeader))
+	assert.Equal(t, "1.0000", w.Header().Get(cacheSimilarityHeader))
+	assert.Len(t, provider.requests, 1)
+
+	// 其他用户不共享缓存
+	w = post("2", "What is the capital of France?")
+	assert.Equal(t, "miss", w.Header().Get(cacheStatusHeader))
+	assert.Len(t, provider.requests, 2)
+}
+
+// fakeUsageRepository 只保存写入的使用记录
+type fakeUsageRepository struct {
+	repository.UsageRepository
+	mu      sync.Mutex
+	records []*model.UsageRecord
+}
+
+func (r *fakeUsageRepository) CreateUsageRecord(ctx context.Context, record *model.UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *fakeUsageRepository) Records() []*model.UsageRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.UsageRecord(nil), r.records...)
+}
+
+// TestChatCompletions_SemanticCacheUsage 测试语义缓存计算向量的 Embeddings 调用以相同的 trace_id 记录使用量
+func TestChatCompletions_SemanticCacheUsage(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{
+		MockProvider: MockProvider{name: "openai", typ: "openai"},
+		config:       map[string]any{"semantic_cache": true},
+	}}
+	embedder := &MockEmbeddingProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "embedder", typ: "openai"}}}
+	setupChatRouter(t, provider, embedder)
+
+	usageRepo := &fakeUsageRepository{}
+	usageSvc := service.NewUsageService(usageRepo, nil)
+	defer usageSvc.Close()
+
+	cfg := service.DefaultSemanticCacheConfig()
+	cfg.EmbeddingModel = "embedder/text-embedding-3-small"
+	router := gin.New()
+	router.Use(middleware.TraceID(), func(ctx *gin.Context) {
+		ctx.Set("user_id", int64(1))
+	})
+	chatCtrl := NewChatController(service.NewRouterService(), usageSvc)
+	chatCtrl.SetSemanticCache(service.NewSemanticCacheWithStore(cfg, service.NewMemoryVectorIndex(time.Minute, 10)))
+	chatCtrl.RegisterRoutes(router.Group("/v1"))
+
+	w := postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"What is the capital of France?"}]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	// 使用量异步写入
+	require.Eventually(t, func() bool { return len(usageRepo.Records()) == 2 }, 3*time.Second, 10*time.Millisecond)
+	byModel := make(map[string]*model.UsageRecord)
+	for _, record := range usageRepo.Records() {
+		byModel[record.Model] = record
+	}
+	embedding := byModel["embedder/text-embedding-3-small"]
+	require.NotNil(t, embedding)
+	assert.Equal(t, "embedder", embedding.ProviderName)
+	assert.Equal(t, 4, embedding.TotalTokens)
+	assert.Equal(t, "success", embedding.Status)
+	assert.True(t, strings.HasPrefix(embedding.RequestID, "emb-"))
+
+	chat := byModel["openai/gpt-4o"]
+	require.NotNil(t, chat)
+	assert.Equal(t, w.Header().Get(middleware.TraceIDHeader), embedding.TraceID)
+	assert.Equal(t, chat.TraceID, embedding.TraceID)
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/middleware"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// rateLimitResultKey gin.Context 中保存限流预扣结果的 key，记录使用量时按实际 Token 数对账
+const rateLimitResultKey = "rate_limit_result"
+
+// SetRateLimiter 设置按用户和 API Key 的 RPM/TPM 限制，为 nil 时不限制
+func (c *ChatController) SetRateLimiter(limiter *service.RateLimitService) {
+	c.rateLimiter = limiter
+}
+
+// checkRateLimit 检查调用方的限制并预扣额度，设置 x-ratelimit-* 响应头
+// 超出限制时返回 429 和 Retry-After 并返回 false；令牌桶竞争激烈无法扣减时同样返回 429，限制无法读取时放行
+func (c *ChatController) checkRateLimit(ctx *gin.Context, req *model.ChatRequest) bool {
+	if c.rateLimiter == nil {
+		return true
+	}
+	userID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return true
+	}
+
+	subject := service.RateLimitSubject{UserID: userID}
+	if apiKeyID, ok := ctx.Get("api_key_id"); ok {
+		id := apiKeyID.(int64)
+		subject.APIKeyID = &id
+	}
+
+	result, err := c.rateLimiter.Reserve(ctx.Request.Context(), subject, service.RateLimitTokens(req))
+	if errors.Is(err, service.ErrRateLimitContention) {
+		logger.L.Warn("Rate limit buckets are contended",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Int64("user_id", userID),
+			zap.Error(err))
+		ctx.Header("Retry-After", "1")
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error": gin.H{
+				"message": "Too many concurrent requests, please try again in 1s",
+				"type":    "rate_limit_error",
+			},
+		})
+		return false
+	}
+	if err != nil {
+		logger.L.Warn("Failed to check rate limits",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Error(err))
+		return true
+	}
+	setRateLimitHeaders(ctx, result)
+
+	if !result.Allowed {
+		retryAfter := max(int(math.Ceil(result.RetryAfter.Seconds())), 1)
+		logger.L.Warn("Rate limit exceeded",
+			zap.String("trace_id", middleware.GetTraceID(ctx)),
+			zap.Int64("user_id", userID),
+			zap.String("exceeded", result.Exceeded),
+			zap.Int("retry_after", retryAfter))
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"error": gin.H{
+				"message": fmt.Sprintf("Rate limit reached for %s per minute, please try again in %ds", result.Exceeded, retryAfter),
+				"type":    "rate_limit_error",
+			},
+		})
+		return false
+	}
+
+	ctx.Set(rateLimitResultKey, result)
+	return true
+}
+
+// reconcileRateLimit 请求结束后按实际使用的 Token 数调整预扣的额度
+func (c *ChatController) reconcileRateLimit(ctx *gin.Context, usedTokens int) {
+	if value, ok := ctx.Get(rateLimitResultKey); ok {
+		// 请求可能已经取消，对账不使用请求的 context
+		if err := c.rateLimiter.Reconcile(context.Background(), value.(*service.RateLimitResult), usedTokens); err != nil {
+			logger.L.Warn("Failed to reconcile rate limits",
+				zap.String("trace_id", middleware.GetTraceID(ctx)),
+				zap.Error(err))
+		}
+	}
+}
+
+// setRateLimitHeaders 设置 OpenAI 风格的 x-ratelimit-* 响应头，不限制的维度不设置
+func setRateLimitHeaders(ctx *gin.Context, result *service.RateLimitResult) {
+	if result.LimitRequests > 0 {
+		ctx.Header("x-ratelimit-limit-requests", strconv.Itoa(result.LimitRequests))
+		ctx.Header("x-ratelimit-remaining-requests", strconv.Itoa(result.RemainingRequests))
+		ctx.Header("x-ratelimit-reset-requests", formatReset(result.ResetRequests))
+	}
+	if result.LimitTokens > 0 {
+		ctx.Header("x-ratelimit-limit-tokens", strconv.Itoa(result.LimitTokens))
+		ctx.Header("x-ratelimit-remaining-tokens", strconv.Itoa(result.RemainingTokens))
+		ctx.Header("x-ratelimit-reset-tokens", formatReset(result.ResetTokens))
+	}
+}
+
+// formatReset 额度恢复时间，格式同 OpenAI（如 20ms、1.5s、6m0s）
+func formatReset(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// TestChatCompletions_RateLimit 测试超出 RPM 限制时返回 429 和 Retry-After，未超出时返回 x-ratelimit-* 响应头
+func TestChatCompletions_RateLimit(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	setupChatRouter(t, provider)
+
+	repo := NewMockUserRepositoryForController()
+	require.NoError(t, repo.CreateUser(context.Background(), &model.User{Email: "user@example.com", Role: "user"}))
+	limiter := service.NewRateLimitService(service.RateLimitConfig{User: service.RateLimits{RPM: 1, TPM: 1000}}, repo, service.NewMemoryRateLimitBackend())
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", int64(1))
+	})
+	chatCtrl := NewChatController(service.NewRouterService(), nil)
+	chatCtrl.SetRateLimiter(limiter)
+	chatCtrl.RegisterRoutes(router.Group("/v1"))
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/chat/completions",
+			strings.NewReader(`{"model":"openai/gpt-4o","max_tokens":100,"messages":[{"role":"user","content":"Hi"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("x-ratelimit-limit-requests"))
+	assert.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+	assert.Equal(t, "1m0s", w.Header().Get("x-ratelimit-reset-requests"))
+	assert.Equal(t, "1000", w.Header().Get("x-ratelimit-limit-tokens"))
+	assert.NotEmpty(t, w.Header().Get("x-ratelimit-remaining-tokens"))
+
+	w = post()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "rate_limit_error", body.Error.Type)
+	assert.Contains(t, body.Error.Message, "requests")
+	assert.Len(t, provider.requests, 1)
+}
+
+// contendedRateLimitBackend 令牌桶总是被同时修改的限流后端
+type contendedRateLimitBackend struct{}
+
+func (contendedRateLimitBackend) Take(ctx context.Context, charges []service.RateLimitCharge) ([]service.RateLimitBucketState, bool, error) {
+	return nil, false, fmt.Errorf("%w, gave up", service.ErrRateLimitContention)
+}
+
+func (contendedRateLimitBackend) Adjust(ctx context.Context, charges []service.RateLimitCharge) error {
+	return fmt.Errorf("%w, gave up", service.ErrRateLimitContention)
+}
+
+// TestChatCompletions_RateLimitContention 测试令牌桶竞争激烈无法扣减时返回 429，不放行请求
+func TestChatCompletions_RateLimitContention(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	setupChatRouter(t, provider)
+
+	repo := NewMockUserRepositoryForController()
+	require.NoError(t, repo.CreateUser(context.Background(), &model.User{Email: "user@example.com", Role: "user"}))
+	limiter := service.NewRateLimitService(service.RateLimitConfig{User: service.RateLimits{RPM: 1, TPM: 1000}}, repo, contendedRateLimitBackend{})
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("user_id", int64(1))
+	})
+	chatCtrl := NewChatController(service.NewRouterService(), nil)
+	chatCtrl.SetRateLimiter(limiter)
+	chatCtrl.RegisterRoutes(router.Group("/v1"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/chat/completions",
+		strings.NewReader(`{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Empty(t, provider.requests)
+}
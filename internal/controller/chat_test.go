@@ -0,0 +1,512 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/middleware"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// MockChatProvider 记录请求并返回固定结果的模拟 Provider
+type MockChatProvider struct {
+	MockConfiguredProvider
+	err      error
+	chunks   []*adapter.ChatStreamChunk            // ChatStream 依次返回的数据块
+	streams  map[string][]*adapter.ChatStreamChunk // 按模型覆盖 chunks
+	requests []*adapter.ChatRequest
+}
+
+func (m *MockChatProvider) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	m.requests = append(m.requests, req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &adapter.ChatResponse{
+		ID:      "chatcmpl-mock",
+		Model:   req.Model,
+		Choices: []adapter.Choice{{Message: adapter.Message{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+	}, nil
+}
+
+func (m *MockChatProvider) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	m.requests = append(m.requests, req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	chunks, ok := m.streams[req.Model]
+	if !ok {
+		chunks = m.chunks
+	}
+	ch := make(chan *adapter.ChatStreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, nil
+}
+
+func postChat(router http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestValidateMessages 测试消息角色与工具调用字段校验
+func TestValidateMessages(t *testing.T) {
+	toolCalls := []model.ToolCall{{ID: "call_1", Type: "function", Function: model.FunctionCall{Name: "get_weather", Arguments: "{}"}}}
+
+	tests := []struct {
+		name     string
+		messages []model.ChatMessage
+		valid    bool
+	}{
+		{"plain", []model.ChatMessage{{Role: "user", Content: model.TextContent("hi")}}, true},
+		{"tool round trip", []model.ChatMessage{
+			{Role: "user", Content: model.TextContent("weather?")},
+			{Role: "assistant", ToolCalls: toolCalls},
+			{Role: "tool", ToolCallID: "call_1", Content: model.TextContent("18C")},
+		}, true},
+		{"invalid role", []model.ChatMessage{{Role: "function", Content: model.TextContent("hi")}}, false},
+		{"tool without id", []model.ChatMessage{{Role: "tool", Content: model.TextContent("18C")}}, false},
+		{"user with tool_calls", []model.ChatMessage{{Role: "user", Content: model.TextContent("hi"), ToolCalls: toolCalls}}, false},
+		{"empty content", []model.ChatMessage{{Role: "user"}}, false},
+	}
+
+	for _, tt := range tests {
+		err := validateMessages(tt.messages)
+		assert.Equal(t, tt.valid, err == nil, tt.name)
+	}
+}
+
+// TestToAdapterRequest_Tools 测试 tools 与 tool_choice 的解析和转换
+func TestToAdapterRequest_Tools(t *testing.T) {
+	body := `{
+		"model": "openai/gpt-4o",
+		"messages": [{"role": "user", "content": "weather?"}],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}}
+	}`
+
+	var req model.ChatRequest
+	assert.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	adapterReq := (&ChatController{}).toAdapterRequest(&req, "gpt-4o")
+	assert.Len(t, adapterReq.Tools, 1)
+	assert.Equal(t, "get_weather", adapterReq.Tools[0].Function.Name)
+	assert.JSONEq(t, `{"type":"object"}`, string(adapterReq.Tools[0].Function.Parameters))
+	assert.Equal(t, &adapter.ToolChoice{Type: adapter.ToolChoiceFunction, FunctionName: "get_weather"}, adapterReq.ToolChoice)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"model":"openai/gpt-4o","messages":[],"tool_choice":"required"}`), &req))
+	assert.Equal(t, adapter.ToolChoiceRequired, (&ChatController{}).toAdapterRequest(&req, "gpt-4o").ToolChoice.Type)
+}
+
+// TestChatCompletions_InvalidMessages 测试非法消息返回 400
+func TestChatCompletions_InvalidMessages(t *testing.T) {
+	router := setupChatRouter(t)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"tool","content":"18C"}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "tool_call_id")
+}
+
+// TestChatCompletions_ContentParts 测试多模态内容片段的解析与转换
+func TestChatCompletions_ContentParts(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":[
+		{"type":"text","text":"What is this?"},
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo=","detail":"high"}},
+		{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}
+	]}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	msg := provider.requests[0].Messages[0]
+	assert.Equal(t, "What is this?", msg.Content)
+	assert.Len(t, msg.Parts, 3)
+	assert.Equal(t, "high", msg.Parts[1].ImageURL.Detail)
+	assert.Equal(t, "wav", msg.Parts[2].InputAudio.Format)
+
+	// 响应中的 content 仍为字符串
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp["choices"].([]any)[0].(map[string]any)["message"].(map[string]any)["content"])
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown part type", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":[{"type":"video","video":{}}]}]}`},
+		{"missing image url", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":[{"type":"image_url","image_url":{}}]}]}`},
+		{"image in system message", `{"model":"openai/gpt-4o","messages":[{"role":"system","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`},
+	}
+	for _, tt := range tests {
+		w := postChat(router, tt.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.name)
+	}
+}
+
+// TestChatCompletions_UnsupportedContent 测试 Provider 不支持的内容返回 400
+func TestChatCompletions_UnsupportedContent(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "claude", typ: "anthropic"}},
+		err:                    &adapter.UnsupportedContentError{ProviderType: "anthropic", PartType: adapter.ContentPartInputAudio},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"claude/claude-3-5-sonnet-latest","messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "does not support input_audio")
+}
+
+// TestChatCompletions_SamplingParams 测试采样参数解析与透传
+func TestChatCompletions_SamplingParams(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],
+		"top_p":0.5,"n":2,"stop":"END","presence_penalty":0.1,"frequency_penalty":-0.1,"seed":7,
+		"logit_bias":{"50256":-100},"logprobs":true,"top_logprobs":3,"user":"u-1",
+		"response_format":{"type":"json_schema","json_schema":{"name":"answer","schema":{"type":"object"}}}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req := provider.requests[0]
+	assert.Equal(t, 0.5, *req.TopP)
+	assert.Equal(t, 2, req.N)
+	assert.Equal(t, []string{"END"}, req.Stop)
+	assert.Equal(t, 0.1, *req.PresencePenalty)
+	assert.Equal(t, -0.1, *req.FrequencyPenalty)
+	assert.Equal(t, int64(7), *req.Seed)
+	assert.Equal(t, -100.0, req.LogitBias["50256"])
+	assert.True(t, req.Logprobs)
+	assert.Equal(t, 3, *req.TopLogprobs)
+	assert.Equal(t, "u-1", req.User)
+	assert.Equal(t, adapter.ResponseFormatJSONSchema, req.ResponseFormat.Type)
+	assert.Equal(t, "answer", req.ResponseFormat.JSONSchema.Name)
+	assert.JSONEq(t, `{"type":"object"}`, string(req.ResponseFormat.JSONSchema.Schema))
+
+	// stop 也可以是数组
+	w = postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"stop":["a","b"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"a", "b"}, provider.requests[1].Stop)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"n is zero", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"n":0}`},
+		{"too many stop sequences", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"stop":["a","b","c","d","e"]}`},
+		{"penalty out of range", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"presence_penalty":3}`},
+		{"unknown response format", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"response_format":{"type":"xml"}}`},
+		{"json_schema without schema", `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}],"response_format":{"type":"json_schema"}}`},
+	}
+	for _, tt := range tests {
+		w := postChat(router, tt.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.name)
+	}
+}
+
+// TestChatCompletions_UnsupportedParameter 测试 Provider 不支持的参数返回 400
+func TestChatCompletions_UnsupportedParameter(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "claude", typ: "anthropic"}},
+		err:                    &adapter.UnsupportedParameterError{ProviderType: "anthropic", Parameter: adapter.ParamSeed},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"claude/claude-3-5-sonnet-latest","messages":[{"role":"user","content":"Hi"}],"seed":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "does not support parameter seed")
+}
+
+// TestChatCompletions_StreamUsage 测试流式响应末尾返回使用量
+func TestChatCompletions_StreamUsage(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}},
+		chunks: []*adapter.ChatStreamChunk{
+			{Model: "gpt-4o", Choices: []adapter.StreamChoice{{Delta: adapter.MessageDelta{Content: "Hello"}}}},
+			{Model: "gpt-4o", Usage: &adapter.Usage{PromptTokens: 9, CompletionTokens: 1, TotalTokens: 10}},
+		},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	assert.Len(t, events, 3)
+	assert.NotContains(t, events[0], `"usage"`)
+	assert.Contains(t, events[1], `"choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}`)
+	assert.Equal(t, "data: [DONE]", events[2])
+
+	// 未要求 include_usage 时不返回使用量块
+	w = postChat(router, `{"model":"openai/gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.NotContains(t, w.Body.String(), `"usage"`)
+}
+
+// TestChatCompletions_StreamError 测试上游流式错误以 error 事件返回
+func TestChatCompletions_StreamError(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}},
+		chunks: []*adapter.ChatStreamChunk{
+			{Model: "gpt-4o", Choices: []adapter.StreamChoice{{Delta: adapter.MessageDelta{Content: "Hel"}}}},
+			{Err: errors.New("upstream connection reset")},
+		},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+	assert.Len(t, events, 2)
+	assert.Contains(t, events[0], `"content":"Hel"`)
+	assert.Equal(t, `data: {"error":{"message":"upstream connection reset","type":"api_error"}}`, events[1])
+	assert.NotContains(t, w.Body.String(), "[DONE]")
+}
+
+// TestChatCompletions_StreamFallback 测试流式请求在输出前失败时 Fallback
+func TestChatCompletions_StreamFallback(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+			config:       map[string]any{"fallback_models": []string{"gpt-4o-mini"}},
+		},
+		streams: map[string][]*adapter.ChatStreamChunk{
+			"gpt-4o":      {{Err: &adapter.UpstreamError{StatusCode: 503, Message: "overloaded"}}},
+			"gpt-4o-mini": {{Model: "gpt-4o-mini", Choices: []adapter.StreamChoice{{Delta: adapter.MessageDelta{Content: "Hi"}}}}},
+		},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, provider.requests, 2)
+	assert.Contains(t, w.Body.String(), `"model":"gpt-4o-mini"`)
+	assert.Contains(t, w.Body.String(), "data: [DONE]")
+
+	// 不可重试的错误在输出前直接返回 HTTP 错误
+	provider.streams["gpt-4o"] = []*adapter.ChatStreamChunk{{Err: errors.New("invalid api key")}}
+	w = postChat(router, `{"model":"openai/gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "data:")
+}
+
+// TestChatCompletions_StreamResume 测试开启 stream_resume 时流中途失败在 Fallback 模型上续写
+func TestChatCompletions_StreamResume(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+			config:       map[string]any{"fallback_models": []string{"gpt-4o-mini"}, "stream_resume": true},
+		},
+		streams: map[string][]*adapter.ChatStreamChunk{
+			"gpt-4o": {
+				{Model: "gpt-4o", Choices: []adapter.StreamChoice{{Delta: adapter.MessageDelta{Role: "assistant", Content: "Hello, "}}}},
+				{Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+			},
+			"gpt-4o-mini": {
+				{Model: "gpt-4o-mini", Choices: []adapter.StreamChoice{{Delta: adapter.MessageDelta{Role: "assistant", Content: "world"}}}},
+			},
+		},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"openai/gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `"content":"Hello, "`)
+	assert.Contains(t, body, `"delta":{"content":"world"}`)
+	assert.NotContains(t, body, `"error"`)
+	assert.Contains(t, body, "data: [DONE]")
+
+	// 已输出的内容作为 assistant 消息交给下一个模型续写
+	assert.Len(t, provider.requests, 2)
+	resumed := provider.requests[1].Messages
+	assert.Equal(t, "assistant", resumed[len(resumed)-1].Role)
+	assert.Equal(t, "Hello,", resumed[len(resumed)-1].Content)
+}
+
+// TestChatCompletions_SlashModelFallback 测试同一 Provider 下包含 "/" 的 Fallback 模型名称
+func TestChatCompletions_SlashModelFallback(t *testing.T) {
+	provider := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "vllm-local", typ: "vllm"},
+			config:       map[string]any{"fallback_models": []string{"meta-llama/Llama-3-8B"}},
+		},
+		err: &adapter.UpstreamError{StatusCode: 503, Message: "overloaded"},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postChat(router, `{"model":"vllm-local/Qwen/Qwen2.5-7B-Instruct","messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	if assert.Len(t, provider.requests, 2) {
+		assert.Equal(t, "Qwen/Qwen2.5-7B-Instruct", provider.requests[0].Model)
+		assert.Equal(t, "meta-llama/Llama-3-8B", provider.requests[1].Model)
+	}
+}
+
+// TestChatCompletions_CrossProviderFallback 测试 fallback_models 中引用其他 Provider 的模型
+func TestChatCompletions_CrossProviderFallback(t *testing.T) {
+	primary := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+			config:       map[string]any{"fallback_models": []string{"azure/gpt-4o"}},
+		},
+		err: &adapter.UpstreamError{StatusCode: 503, Message: "overloaded"},
+	}
+	secondary := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "azure", typ: "azure"},
+		},
+	}
+	router := setupChatRouter(t, primary, secondary)
+
+	// 请求转到 azure
+	w := postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, primary.requests, 1)
+	assert.Len(t, secondary.requests, 1)
+	assert.Equal(t, "gpt-4o", secondary.requests[0].Model)
+
+	// 全部失败时尝试详情包含 Provider
+	secondary.err = &adapter.UpstreamError{StatusCode: 502, Message: "bad gateway"}
+	w = postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	var resp struct {
+		Error struct {
+			Details []map[string]any `json:"details"`
+		} `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Error.Details, 2) {
+		assert.Equal(t, "openai", resp.Error.Details[0]["provider"])
+		assert.Equal(t, "azure", resp.Error.Details[1]["provider"])
+		assert.Equal(t, "gpt-4o", resp.Error.Details[1]["model"])
+	}
+}
+
+// TestChatCompletions_UpstreamStatus 测试按上游错误返回对应的状态码
+func TestChatCompletions_UpstreamStatus(t *testing.T) {
+	provider := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}}
+	router := setupChatRouter(t, provider)
+	body := `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+	}{
+		{"上游拒绝请求", &adapter.UpstreamError{StatusCode: 400, Message: "max_tokens is too large"}, http.StatusBadRequest, "invalid_request_error"},
+		{"上游鉴权失败", &adapter.UpstreamError{StatusCode: 401, Message: "invalid api key"}, http.StatusBadGateway, "api_error"},
+		{"上游服务错误", &adapter.UpstreamError{StatusCode: 500, Message: "internal error"}, http.StatusBadGateway, "api_error"},
+		{"上游超时", &adapter.UpstreamError{StatusCode: 504, Message: "gateway timeout"}, http.StatusGatewayTimeout, "timeout_error"},
+		{"上游限流", &adapter.UpstreamError{StatusCode: 429, Message: "rate limited", RetryAfter: 90 * time.Second}, http.StatusTooManyRequests, "rate_limit_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider.err = tt.err
+			w := postChat(router, body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var resp struct {
+				Error struct {
+					Type string `json:"type"`
+				} `json:"error"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantType, resp.Error.Type)
+		})
+	}
+
+	// 限流时透传 Retry-After
+	w := postChat(router, body)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+}
+
+// TestChatCompletions_Alias 测试通过模型别名请求并按别名目标 Fallback
+func TestChatCompletions_Alias(t *testing.T) {
+	primary := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+		},
+		err: &adapter.UpstreamError{StatusCode: 503, Message: "overloaded"},
+	}
+	secondary := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "vllm", typ: "vllm"},
+		},
+	}
+	setupChatRouter(t, primary, secondary)
+
+	routerSvc := service.NewRouterService()
+	routerSvc.SetModelAliases([]*model.ModelAlias{
+		{Name: "chat-default", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}, {Model: "vllm/meta-llama/Llama-3-8B"}}},
+	})
+	router := gin.New()
+	NewChatController(routerSvc, nil).RegisterRoutes(router.Group("/v1"))
+
+	w := postChat(router, `{"model":"chat-default","messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, primary.requests, 1)
+	if assert.Len(t, secondary.requests, 1) {
+		assert.Equal(t, "meta-llama/Llama-3-8B", secondary.requests[0].Model)
+	}
+
+	// 未配置的别名仍按模型格式错误处理
+	w = postChat(router, `{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestStreamUsage 测试流式使用量的上游优先与本地估算
+func TestStreamUsage(t *testing.T) {
+	messages := []model.ChatMessage{{Role: "user", Content: model.TextContent("Hi")}}
+
+	usage := streamUsage(&adapter.Usage{PromptTokens: 9, CompletionTokens: 2}, messages, "Hello")
+	assert.Equal(t, model.ChatUsage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}, usage)
+
+	usage = streamUsage(nil, messages, "Hello world!")
+	assert.Equal(t, service.EstimatePromptTokens(messages), usage.PromptTokens)
+	assert.Equal(t, 3, usage.CompletionTokens)
+	assert.Equal(t, usage.PromptTokens+3, usage.TotalTokens)
+}
+
+// TestChatCompletions_BodyTooLarge 测试请求体超过上限返回 413
+func TestChatCompletions_BodyTooLarge(t *testing.T) {
+	setupChatRouter(t)
+
+	router := gin.New()
+	group := router.Group("/v1")
+	group.Use(middleware.BodySizeLimit(64))
+	NewChatController(service.NewRouterService(), nil).RegisterRoutes(group)
+
+	// 未声明 Content-Length，由 Controller 在解析请求体时识别超限
+	body := `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"` + strings.Repeat("x", 128) + `"}]}`
+	req, _ := http.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "request_too_large")
+}
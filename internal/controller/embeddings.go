@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/middleware"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// Embedding 向量编码格式
+const (
+	EncodingFormatFloat  = "float"
+	EncodingFormatBase64 = "base64"
+)
+
+// Embeddings Embeddings 端点
+// POST /v1/embeddings
+func (c *ChatController) Embeddings(ctx *gin.Context) {
+	startTime := time.Now()
+	traceID := middleware.GetTraceID(ctx)
+
+	// 解析请求
+	var req model.EmbeddingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.L.Warn("Invalid request format",
+			zap.String("trace_id", traceID),
+			zap.Error(err))
+		c.handleBindError(ctx, err)
+		return
+	}
+
+	if req.Input.Len() == 0 {
+		c.invalidRequest(ctx, "input must not be empty")
+		return
+	}
+
+	switch req.EncodingFormat {
+	case "", EncodingFormatFloat, EncodingFormatBase64:
+	default:
+		c.invalidRequest(ctx, fmt.Sprintf("unsupported encoding_format: %s (must be float or base64)", req.EncodingFormat))
+		return
+	}
+
+	// 解析模型参数
+	modelInfo, err := c.router.ResolveModel(req.Model)
+	if err != nil {
+		c.handleModelError(ctx, err, traceID)
+		return
+	}
+
+	if _, ok := modelInfo.Provider.(adapter.EmbeddingProvider); !ok {
+		c.invalidRequest(ctx, fmt.Sprintf("provider %s (type %s) does not support embeddings", modelInfo.ProviderName, modelInfo.Provider.Type()))
+		return
+	}
+
+	// 生成请求 ID
+	requestID := "emb-" + uuid.New().String()
+
+	// 获取 Fallback 模型列表
+	fallbackModels := c.getEmbeddingFallbackModels(modelInfo)
+
+	// 设置超时（默认 30 秒，可从 Provider 配置读取）
+	timeout := time.Duration(modelInfo.Provider.Timeout()) * time.Second
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	// 创建带超时的上下文
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+
+	// 使用重试服务处理请求（Fallback 列表中的项均为 provider/model_name）
+	result, err := c.retrySvc.RetryWithFallback(timeoutCtx, fallbackModels, func(ctx context.Context, ref string) (any, error) {
+		providerName, modelName, _ := strings.Cut(ref, "/")
+		return c.callEmbedding(ctx, &req, providerName, modelName)
+	})
+
+	// 记录日志
+	c.logRequestWithRetry(ctx, requestID, req.Model, modelInfo, result, err, time.Since(startTime).Milliseconds())
+
+	if err != nil {
+		c.handleProviderError(ctx, err, result)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result.Response)
+}
+
+// callEmbedding 调用 Provider 生成向量
+func (c *ChatController) callEmbedding(ctx context.Context, req *model.EmbeddingRequest, providerName, modelName string) (any, error) {
+	// 获取 Provider
+	provider, err := c.router.ResolveProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, ok := provider.(adapter.EmbeddingProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support embeddings", providerName)
+	}
+
+	resp, err := embedder.Embed(ctx, &adapter.EmbeddingRequest{
+		Model:      modelName,
+		Input:      req.Input.Texts,
+		Tokens:     req.Input.Tokens,
+		Dimensions: req.Dimensions,
+		User:       req.User,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toEmbeddingResponse(resp, req.Model, req.EncodingFormat), nil
+}
+
+// getEmbeddingFallbackModels 获取 Embedding Fallback 模型列表，每一项均为同一 Provider 下的 `provider/model_name`
+// 不同 Embedding 模型的向量空间不兼容，因此不复用 fallback_models，
+// 仅使用 extra_config.embedding_fallback_models 中显式声明的模型
+func (c *ChatController) getEmbeddingFallbackModels(modelInfo *service.ModelInfo) []string {
+	result := []string{modelInfo.ProviderName + "/" + modelInfo.ModelName}
+
+	raw, ok := modelInfo.Provider.Config()["embedding_fallback_models"].([]any)
+	if !ok {
+		return result
+	}
+
+	for _, v := range raw {
+		if m, ok := v.(string); ok && m != "" && m != modelInfo.ModelName {
+			result = append(result, modelInfo.ProviderName+"/"+m)
+		}
+	}
+	return result
+}
+
+// handleBindError 处理请求体解析错误，请求体超过大小限制时返回 413
+func (c *ChatController) handleBindError(ctx *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": gin.H{
+				"message": fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytesErr.Limit),
+				"type":    "request_too_large",
+			},
+		})
+		return
+	}
+	c.invalidRequest(ctx, err.Error())
+}
+
+// invalidRequest 返回 400 invalid_request_error
+func (c *ChatController) invalidRequest(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    "invalid_request_error",
+		},
+	})
+}
+
+// toEmbeddingResponse 转换为 Embeddings 响应格式
+func toEmbeddingResponse(resp *adapter.EmbeddingResponse, modelName, encodingFormat string) *model.EmbeddingResponse {
+	data := make([]model.EmbeddingData, len(resp.Data))
+	for i, d := range resp.Data {
+		var embedding any = d.Embedding
+		if encodingFormat == EncodingFormatBase64 {
+			embedding = encodeEmbeddingBase64(d.Embedding)
+		}
+
+		data[i] = model.EmbeddingData{
+			Object:    "embedding",
+			Index:     d.Index,
+			Embedding: embedding,
+		}
+	}
+
+	return &model.EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  modelName,
+		Usage: model.EmbeddingUsage{
+			PromptTokens: resp.Usage.PromptTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		},
+	}
+}
+
+// encodeEmbeddingBase64 按 OpenAI 格式编码向量：float32 小端序字节的 base64
+func encodeEmbeddingBase64(embedding []float64) string {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// MockEmbeddingProvider 支持 Embeddings 的模拟 Provider
+type MockEmbeddingProvider struct {
+	MockConfiguredProvider
+	failModels map[string]bool
+	requests   []*adapter.EmbeddingRequest
+}
+
+func (m *MockEmbeddingProvider) Embed(ctx context.Context, req *adapter.EmbeddingRequest) (*adapter.EmbeddingResponse, error) {
+	m.requests = append(m.requests, req)
+	if m.failModels[req.Model] {
+		return nil, &adapter.UpstreamError{StatusCode: 503, Message: "Service Unavailable"}
+	}
+
+	count := len(req.Input)
+	if len(req.Tokens) > 0 {
+		count = len(req.Tokens)
+	}
+
+	data := make([]adapter.Embedding, count)
+	for i := range data {
+		data[i] = adapter.Embedding{Index: i, Embedding: []float64{0.5, -1.25}}
+	}
+
+	return &adapter.EmbeddingResponse{
+		Model: req.Model,
+		Data:  data,
+		Usage: adapter.Usage{PromptTokens: 4 * count, TotalTokens: 4 * count},
+	}, nil
+}
+
+func setupChatRouter(t *testing.T, providers ...adapter.Provider) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger.L = zap.NewNop()
+
+	for _, p := range providers {
+		adapter.RegisterProvider(p)
+	}
+	t.Cleanup(func() {
+		for _, p := range providers {
+			adapter.UnregisterProvider(p.Name())
+		}
+	})
+
+	router := gin.New()
+	NewChatController(service.NewRouterService(), nil).RegisterRoutes(router.Group("/v1"))
+	return router
+}
+
+func postEmbeddings(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/embeddings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestEmbeddings_Float 测试浮点格式与 Fallback
+func TestEmbeddings_Float(t *testing.T) {
+	provider := &MockEmbeddingProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+			config: map[string]any{
+				"fallback_models":           []string{"gpt-4o"},
+				"embedding_fallback_models": []any{"text-embedding-3-small-eu"},
+			},
+		},
+		failModels: map[string]bool{"text-embedding-3-small": true},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postEmbeddings(router, `{"model":"openai/text-embedding-3-small","input":["hello","world"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp model.EmbeddingResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "list", resp.Object)
+	assert.Equal(t, "openai/text-embedding-3-small", resp.Model)
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, []any{0.5, -1.25}, resp.Data[1].Embedding)
+	assert.Equal(t, 8, resp.Usage.TotalTokens)
+
+	// 只 fallback 到 embedding_fallback_models，不使用对话模型
+	assert.Len(t, provider.requests, 2)
+	assert.Equal(t, "text-embedding-3-small-eu", provider.requests[1].Model)
+}
+
+// TestEmbeddings_Base64 测试 base64 编码与 token 输入
+func TestEmbeddings_Base64(t *testing.T) {
+	provider := &MockEmbeddingProvider{
+		MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "vllm", typ: "vllm"}},
+	}
+	router := setupChatRouter(t, provider)
+
+	w := postEmbeddings(router, `{"model":"vllm/bge-m3","input":[1820,374,264,1296],"encoding_format":"base64"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, [][]int{{1820, 374, 264, 1296}}, provider.requests[0].Tokens)
+
+	var resp model.EmbeddingResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	raw, err := base64.StdEncoding.DecodeString(resp.Data[0].Embedding.(string))
+	assert.NoError(t, err)
+	assert.Len(t, raw, 8)
+	assert.Equal(t, float32(0.5), math.Float32frombits(binary.LittleEndian.Uint32(raw[0:4])))
+	assert.Equal(t, float32(-1.25), math.Float32frombits(binary.LittleEndian.Uint32(raw[4:8])))
+}
+
+// TestEmbeddings_InvalidRequest 测试请求校验
+func TestEmbeddings_InvalidRequest(t *testing.T) {
+	router := setupChatRouter(t,
+		&MockEmbeddingProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "openai", typ: "openai"}}},
+		&MockProvider{name: "claude", typ: "anthropic"},
+	)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"empty input", `{"model":"openai/text-embedding-3-small","input":[]}`, http.StatusBadRequest},
+		{"invalid input", `{"model":"openai/text-embedding-3-small","input":{"text":"hi"}}`, http.StatusBadRequest},
+		{"invalid encoding", `{"model":"openai/text-embedding-3-small","input":"hi","encoding_format":"int8"}`, http.StatusBadRequest},
+		{"unsupported provider", `{"model":"claude/claude-3-haiku","input":"hi"}`, http.StatusBadRequest},
+		{"unknown provider", `{"model":"missing/text-embedding-3-small","input":"hi"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := postEmbeddings(router, tt.body)
+		assert.Equal(t, tt.code, w.Code, tt.name)
+		assert.Contains(t, w.Body.String(), "invalid_request_error", tt.name)
+	}
+}
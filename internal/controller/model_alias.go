@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// ModelAliasService 模型别名服务接口（用于依赖注入和测试）
+type ModelAliasService interface {
+	CreateAlias(ctx context.Context, alias *model.ModelAlias) error
+	GetAlias(ctx context.Context, name string) (*model.ModelAlias, error)
+	ListAliases(ctx context.Context) ([]*model.ModelAlias, error)
+	UpdateAlias(ctx context.Context, name string, update *service.ModelAliasUpdate) (*model.ModelAlias, error)
+	DeleteAlias(ctx context.Context, name string) error
+}
+
+// ModelAliasController 模型别名管理 API 控制器
+type ModelAliasController struct {
+	svc ModelAliasService
+}
+
+// NewModelAliasController 创建 ModelAlias Controller
+func NewModelAliasController(svc ModelAliasService) *ModelAliasController {
+	return &ModelAliasController{svc: svc}
+}
+
+// CreateModelAliasRequest 创建别名请求
+type CreateModelAliasRequest struct {
+	Name          string             `json:"name" binding:"required"`
+	Targets       model.AliasTargets `json:"targets" binding:"required,min=1"`
+	Strategy      string             `json:"strategy,omitempty"`       // 默认 priority
+	HedgeDelayMs  int                `json:"hedge_delay_ms,omitempty"` // 默认 0，使用首选 Provider 的配置
+	SemanticCache bool               `json:"semantic_cache,omitempty"` // 默认 false
+	Description   string             `json:"description,omitempty"`
+}
+
+// UpdateModelAliasRequest 更新别名请求
+type UpdateModelAliasRequest struct {
+	Targets       model.AliasTargets `json:"targets,omitempty" binding:"omitempty,min=1"`
+	Strategy      *string            `json:"strategy,omitempty"`
+	HedgeDelayMs  *int               `json:"hedge_delay_ms,omitempty"`
+	SemanticCache *bool              `json:"semantic_cache,omitempty"`
+	Description   *string            `json:"description,omitempty"`
+}
+
+// RegisterRoutes 注册路由
+func (c *ModelAliasController) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/admin/model-aliases", c.CreateAlias)
+	r.GET("/admin/model-aliases", c.ListAliases)
+	r.GET("/admin/model-aliases/:name", c.GetAlias)
+	r.PUT("/admin/model-aliases/:name", c.UpdateAlias)
+	r.DELETE("/admin/model-aliases/:name", c.DeleteAlias)
+}
+
+// CreateAlias 创建别名
+// POST /api/v1/admin/model-aliases
+func (c *ModelAliasController) CreateAlias(ctx *gin.Context) {
+	var req CreateModelAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias := &model.ModelAlias{
+		Name:          req.Name,
+		Targets:       req.Targets,
+		Strategy:      req.Strategy,
+		HedgeDelayMs:  req.HedgeDelayMs,
+		SemanticCache: req.SemanticCache,
+		Description:   req.Description,
+	}
+	if err := c.svc.CreateAlias(ctx.Request.Context(), alias); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, alias)
+}
+
+// ListAliases 列出所有别名
+// GET /api/v1/admin/model-aliases
+func (c *ModelAliasController) ListAliases(ctx *gin.Context) {
+	aliases, err := c.svc.ListAliases(ctx.Request.Context())
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	if aliases == nil {
+		aliases = []*model.ModelAlias{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"aliases": aliases})
+}
+
+// GetAlias 获取别名
+// GET /api/v1/admin/model-aliases/:name
+func (c *ModelAliasController) GetAlias(ctx *gin.Context) {
+	alias, err := c.svc.GetAlias(ctx.Request.Context(), ctx.Param("name"))
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, alias)
+}
+
+// UpdateAlias 更新别名
+// PUT /api/v1/admin/model-aliases/:name
+func (c *ModelAliasController) UpdateAlias(ctx *gin.Context) {
+	var req UpdateModelAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := c.svc.UpdateAlias(ctx.Request.Context(), ctx.Param("name"), &service.ModelAliasUpdate{
+		Targets:       req.Targets,
+		Strategy:      req.Strategy,
+		HedgeDelayMs:  req.HedgeDelayMs,
+		SemanticCache: req.SemanticCache,
+		Description:   req.Description,
+	})
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, alias)
+}
+
+// DeleteAlias 删除别名
+// DELETE /api/v1/admin/model-aliases/:name
+func (c *ModelAliasController) DeleteAlias(ctx *gin.Context) {
+	if err := c.svc.DeleteAlias(ctx.Request.Context(), ctx.Param("name")); err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// handleError 将 Service 错误转换为 HTTP 响应
+func (c *ModelAliasController) handleError(ctx *gin.Context, err error) {
+	var (
+		invalidErr  *service.InvalidModelAliasError
+		existsErr   *service.ModelAliasExistsError
+		notFoundErr *service.ModelAliasNotFoundError
+	)
+	switch {
+	case errors.As(err, &invalidErr):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &existsErr):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &notFoundErr):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// MockModelAliasService 模拟 ModelAliasService
+type MockModelAliasService struct {
+	createErr error
+	aliases   map[string]*model.ModelAlias
+}
+
+func (m *MockModelAliasService) CreateAlias(ctx context.Context, alias *model.ModelAlias) error {
+	return m.createErr
+}
+
+func (m *MockModelAliasService) GetAlias(ctx context.Context, name string) (*model.ModelAlias, error) {
+	alias, ok := m.aliases[name]
+	if !ok {
+		return nil, &service.ModelAliasNotFoundError{Name: name}
+	}
+	return alias, nil
+}
+
+func (m *MockModelAliasService) ListAliases(ctx context.Context) ([]*model.ModelAlias, error) {
+	return nil, nil
+}
+
+func (m *MockModelAliasService) UpdateAlias(ctx context.Context, name string, update *service.ModelAliasUpdate) (*model.ModelAlias, error) {
+	return m.GetAlias(ctx, name)
+}
+
+func (m *MockModelAliasService) DeleteAlias(ctx context.Context, name string) error {
+	_, err := m.GetAlias(ctx, name)
+	return err
+}
+
+func setupModelAliasRouter(svc ModelAliasService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewModelAliasController(svc).RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+// TestCreateAlias_Errors 测试创建别名的错误状态码
+func TestCreateAlias_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{"成功", `{"name":"chat-default","targets":["openai/gpt-4o"]}`, nil, http.StatusCreated},
+		{"带权重的目标", `{"name":"chat-default","strategy":"weighted","targets":[{"model":"vllm-a/llama","weight":3},"vllm-b/llama"]}`, nil, http.StatusCreated},
+		{"目标格式错误", `{"name":"chat-default","targets":[1]}`, nil, http.StatusBadRequest},
+		{"缺少目标", `{"name":"chat-default","targets":[]}`, nil, http.StatusBadRequest},
+		{"校验失败", `{"name":"chat-default","targets":["missing/gpt-4o"]}`, &service.InvalidModelAliasError{Name: "chat-default", Reason: "provider missing does not exist"}, http.StatusBadRequest},
+		{"已存在", `{"name":"chat-default","targets":["openai/gpt-4o"]}`, &service.ModelAliasExistsError{Name: "chat-default"}, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupModelAliasRouter(&MockModelAliasService{createErr: tt.err})
+
+			req, _ := http.NewRequest("POST", "/api/v1/admin/model-aliases", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+// TestDeleteAlias_NotFound 测试删除不存在的别名
+func TestDeleteAlias_NotFound(t *testing.T) {
+	router := setupModelAliasRouter(&MockModelAliasService{})
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/admin/model-aliases/missing", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
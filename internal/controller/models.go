@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// ModelsController OpenAI 兼容的模型列表控制器
+type ModelsController struct {
+	router *service.RouterService
+}
+
+// NewModelsController 创建 Models Controller
+func NewModelsController(router *service.RouterService) *ModelsController {
+	return &ModelsController{router: router}
+}
+
+// RegisterRoutes 注册路由
+// 模型 ID 形如 provider/model_name，包含 "/"，因此单个模型使用通配路由
+func (c *ModelsController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/models", c.ListModels)
+	r.GET("/models/*id", c.GetModel)
+}
+
+// ListModels 列出所有可用模型
+// GET /v1/models
+func (c *ModelsController) ListModels(ctx *gin.Context) {
+	models := c.router.GetAvailableModels(ctx.Request.Context())
+
+	data := make([]model.ModelObject, len(models))
+	for i, m := range models {
+		data[i] = toModelObject(&m)
+	}
+
+	ctx.JSON(http.StatusOK, model.ModelList{
+		Object: "list",
+		Data:   data,
+	})
+}
+
+// GetModel 获取单个模型
+// GET /v1/models/{provider}/{model_name}
+func (c *ModelsController) GetModel(ctx *gin.Context) {
+	id := strings.TrimPrefix(ctx.Param("id"), "/")
+
+	m, err := c.router.GetAvailableModel(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"message": "The model '" + id + "' does not exist",
+				"type":    "invalid_request_error",
+				"code":    "model_not_found",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, toModelObject(m))
+}
+
+// toModelObject 转换为 OpenAI 模型对象
+// Provider 配置中没有模型的创建时间，created 固定为 0
+func toModelObject(m *service.AvailableModel) model.ModelObject {
+	return model.ModelObject{
+		ID:      m.ID,
+		Object:  "model",
+		OwnedBy: m.ProviderName,
+	}
+}
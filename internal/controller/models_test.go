@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// MockConfiguredProvider 带配置模型的模拟 Provider
+type MockConfiguredProvider struct {
+	MockProvider
+	config map[string]any
+}
+
+func (m *MockConfiguredProvider) Config() map[string]any {
+	return m.config
+}
+
+func setupModelsRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	adapter.RegisterProvider(&MockConfiguredProvider{
+		MockProvider: MockProvider{name: "qwen", typ: "openai"},
+		config:       map[string]any{"fallback_models": []string{"qwen-max", "qwen-plus"}},
+	})
+	t.Cleanup(func() { adapter.UnregisterProvider("qwen") })
+
+	router := gin.New()
+	NewModelsController(service.NewRouterService()).RegisterRoutes(router.Group("/v1"))
+	return router
+}
+
+// TestListModels 测试 OpenAI 兼容的模型列表
+func TestListModels(t *testing.T) {
+	router := setupModelsRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/models", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp model.ModelList
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "list", resp.Object)
+	assert.Equal(t, []model.ModelObject{
+		{ID: "qwen/qwen-max", Object: "model", OwnedBy: "qwen"},
+		{ID: "qwen/qwen-plus", Object: "model", OwnedBy: "qwen"},
+	}, resp.Data)
+}
+
+// TestGetModel 测试查询单个模型（ID 包含 "/"）
+func TestGetModel(t *testing.T) {
+	router := setupModelsRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/models/qwen/qwen-max", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp model.ModelObject
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "qwen/qwen-max", resp.ID)
+
+	for _, path := range []string{"/v1/models/qwen/qwen-turbo", "/v1/models/missing/qwen-max", "/v1/models/qwen-max"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.Contains(t, w.Body.String(), "model_not_found")
+	}
+}
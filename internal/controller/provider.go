@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
 	"github.com/lucheng0127/courier/internal/adapter"
 	"github.com/lucheng0127/courier/internal/middleware"
 	"github.com/lucheng0127/courier/internal/model"
@@ -28,7 +30,8 @@ type ProviderService interface {
 
 // ProviderController Provider 管理 API 控制器
 type ProviderController struct {
-	svc ProviderService
+	svc    ProviderService
+	router *service.RouterService
 }
 
 // NewProviderController 创建 Provider Controller
@@ -36,6 +39,11 @@ func NewProviderController(svc ProviderService) *ProviderController {
 	return &ProviderController{svc: svc}
 }
 
+// SetRouterService 设置查询上游模型列表使用的 RouterService（与模型路由共用缓存），为 nil 时只返回已配置的模型
+func (c *ProviderController) SetRouterService(router *service.RouterService) {
+	c.router = router
+}
+
 // CreateProviderRequest 创建 Provider 请求
 type CreateProviderRequest struct {
 	Name           string         `json:"name" binding:"required"`
@@ -109,6 +117,11 @@ func (c *ProviderController) CreateProvider(ctx *gin.Context) {
 	}
 
 	if err := c.svc.CreateProvider(context.Background(), provider); err != nil {
+		var fallbackErr *service.InvalidFallbackModelError
+		if errors.As(err, &fallbackErr) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -207,6 +220,9 @@ func (c *ProviderController) ListProviderModels(ctx *gin.Context) {
 		}
 	}
 
+	// 开启模型发现的 Provider（如 Ollama）合并上游查询到的模型
+	models = c.appendUpstreamModels(ctx, name, models)
+
 	ctx.JSON(http.StatusOK, ProviderModelsResponse{
 		Name:   provider.Name,
 		Type:   provider.Type,
@@ -214,6 +230,30 @@ func (c *ProviderController) ListProviderModels(ctx *gin.Context) {
 	})
 }
 
+// appendUpstreamModels 合并上游查询到的模型（去重），上游查询失败时仅返回已配置的模型
+func (c *ProviderController) appendUpstreamModels(ctx *gin.Context, name string, models []string) []string {
+	if c.router == nil {
+		return models
+	}
+	instance, err := c.svc.GetProvider(name)
+	if err != nil || instance == nil {
+		return models
+	}
+
+	upstream := c.router.UpstreamModels(ctx.Request.Context(), instance)
+	seen := make(map[string]bool, len(models))
+	for _, m := range models {
+		seen[m] = true
+	}
+	for _, m := range upstream {
+		if !seen[m] {
+			seen[m] = true
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
 // UpdateProvider 更新 Provider
 // PUT /api/v1/providers/:name
 func (c *ProviderController) UpdateProvider(ctx *gin.Context) {
@@ -252,6 +292,11 @@ func (c *ProviderController) UpdateProvider(ctx *gin.Context) {
 	// 调用 Service 层更新
 	provider, err := c.svc.UpdateProvider(context.Background(), name, updates)
 	if err != nil {
+		var fallbackErr *service.InvalidFallbackModelError
+		if errors.As(err, &fallbackErr) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// 检查是否是"not found"错误
 		if containsString(err.Error(), "not found") {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
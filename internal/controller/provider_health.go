@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// ProviderHealthController Provider 健康检查 API 控制器
+type ProviderHealthController struct {
+	health *service.HealthChecker
+}
+
+// NewProviderHealthController 创建 Provider Health Controller
+func NewProviderHealthController(health *service.HealthChecker) *ProviderHealthController {
+	return &ProviderHealthController{health: health}
+}
+
+// RegisterRoutes 注册路由
+func (c *ProviderHealthController) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/admin/providers/health", c.ListHealth)
+	r.POST("/admin/providers/:name/health-check", c.CheckProvider)
+}
+
+// ListHealth 列出所有已注册 Provider 的健康检查结果
+// GET /api/v1/admin/providers/health
+func (c *ProviderHealthController) ListHealth(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"providers": c.health.Results()})
+}
+
+// CheckProvider 立即探测指定 Provider
+// POST /api/v1/admin/providers/:name/health-check
+func (c *ProviderHealthController) CheckProvider(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	provider, ok := adapter.GetProvider(name)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "provider not running: " + name})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.health.Check(ctx.Request.Context(), provider))
+}
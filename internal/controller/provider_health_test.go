@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// TestProviderHealth_SkipUnhealthy 测试手动探测、健康检查列表以及 Chat 跳过不健康的 Provider
+func TestProviderHealth_SkipUnhealthy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.L = zap.NewNop()
+
+	primary := &MockChatProvider{
+		MockConfiguredProvider: MockConfiguredProvider{
+			MockProvider: MockProvider{name: "openai", typ: "openai"},
+			config: map[string]any{
+				"fallback_models": []string{"azure/gpt-4o"},
+				"health_check":    map[string]any{"enabled": true, "model": "gpt-4o-mini", "unhealthy_threshold": float64(1)},
+			},
+		},
+		err: errors.New("HTTP 503: service unavailable"),
+	}
+	secondary := &MockChatProvider{MockConfiguredProvider: MockConfiguredProvider{MockProvider: MockProvider{name: "azure", typ: "azure"}}}
+	for _, p := range []adapter.Provider{primary, secondary} {
+		adapter.RegisterProvider(p)
+	}
+	t.Cleanup(func() {
+		adapter.UnregisterProvider("openai")
+		adapter.UnregisterProvider("azure")
+	})
+
+	health := service.NewHealthChecker()
+	routerSvc := service.NewRouterService()
+	routerSvc.SetHealthChecker(health)
+
+	router := gin.New()
+	NewProviderHealthController(health).RegisterRoutes(router.Group("/api/v1"))
+	NewChatController(routerSvc, nil).RegisterRoutes(router.Group("/v1"))
+
+	// 手动探测
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/providers/openai/health-check", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result service.ProviderHealth
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, service.HealthStatusUnhealthy, result.Status)
+	assert.Equal(t, "gpt-4o-mini", primary.requests[0].Model)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/providers/missing/health-check", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 健康检查列表
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/providers/health", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Providers []service.ProviderHealth `json:"providers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Providers, 2)
+	assert.Equal(t, "azure", list.Providers[0].Provider)
+	assert.Equal(t, service.HealthStatusUnknown, list.Providers[0].Status)
+	assert.Equal(t, service.HealthStatusUnhealthy, list.Providers[1].Status)
+
+	// 不健康的 Provider 不再接收请求
+	w = postChat(router, `{"model":"openai/gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, primary.requests, 1)
+	assert.Len(t, secondary.requests, 1)
+}
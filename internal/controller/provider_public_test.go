@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -16,6 +17,7 @@ import (
 // MockProviderServiceForPublic 模拟 ProviderService 用于公开查询接口测试
 type MockProviderServiceForPublic struct {
 	providers []*model.Provider
+	instance  adapter.Provider // 非空时由 GetProvider 返回
 }
 
 func (m *MockProviderServiceForPublic) CreateProvider(ctx context.Context, provider *model.Provider) error {
@@ -23,6 +25,9 @@ func (m *MockProviderServiceForPublic) CreateProvider(ctx context.Context, provi
 }
 
 func (m *MockProviderServiceForPublic) GetProvider(name string) (adapter.Provider, error) {
+	if m.instance != nil {
+		return m.instance, nil
+	}
 	return &MockProvider{name: name, typ: "openai"}, nil
 }
 
@@ -298,3 +303,54 @@ func TestListProviderModels_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+// MockListerProvider 支持上游模型发现的模拟 Provider
+type MockListerProvider struct {
+	MockProvider
+	models []string
+}
+
+func (m *MockListerProvider) ListModels(ctx context.Context) ([]string, error) {
+	return m.models, nil
+}
+
+// TestListProviderModels_Upstream 测试合并上游发现的模型
+func TestListProviderModels_Upstream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	fallbackModels := make(model.JSON)
+	fallbackModels["llama3.1:8b"] = true
+
+	providers := []*model.Provider{
+		{
+			Name:           "ollama-dev",
+			Type:           "ollama",
+			BaseURL:        "http://localhost:11434",
+			Enabled:        true,
+			FallbackModels: fallbackModels,
+		},
+	}
+
+	mockSvc := &MockProviderServiceForPublic{
+		providers: providers,
+		instance: &MockListerProvider{
+			MockProvider: MockProvider{name: "ollama-dev", typ: "ollama"},
+			models:       []string{"llama3.1:8b", "qwen2.5:7b"},
+		},
+	}
+	providerCtrl := NewProviderController(mockSvc)
+	providerCtrl.SetRouterService(service.NewRouterService())
+
+	router.GET("/providers/:name/models", providerCtrl.ListProviderModels)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/providers/ollama-dev/models", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp ProviderModelsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"llama3.1:8b", "qwen2.5:7b"}, resp.Models)
+}
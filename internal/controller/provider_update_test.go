@@ -184,6 +184,29 @@ func TestUpdateProvider_InvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+// TestUpdateProvider_InvalidFallbackModel 测试 fallback_models 引用不存在的 Provider
+func TestUpdateProvider_InvalidFallbackModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &MockProviderServiceForUpdate{
+		updateFunc: func(ctx context.Context, name string, updates map[string]any) (*model.Provider, error) {
+			return nil, &service.InvalidFallbackModelError{Model: "missing/gpt-4o", Reason: "provider missing does not exist"}
+		},
+	}
+	controller := NewProviderController(mockSvc)
+
+	router := gin.New()
+	router.PUT("/providers/:name", controller.UpdateProvider)
+
+	body := `{"fallback_models": ["missing/gpt-4o"]}`
+	req, _ := http.NewRequest("PUT", "/providers/test-provider", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // TestDeleteProvider_Success 测试成功删除 Provider
 func TestDeleteProvider_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// RateLimitController 用户和 API Key 速率限制管理 API 控制器
+type RateLimitController struct {
+	limiter *service.RateLimitService
+}
+
+// NewRateLimitController 创建 RateLimit Controller
+func NewRateLimitController(limiter *service.RateLimitService) *RateLimitController {
+	return &RateLimitController{limiter: limiter}
+}
+
+// RegisterRoutes 注册路由
+func (c *RateLimitController) RegisterRoutes(r *gin.RouterGroup) {
+	r.PUT("/admin/users/:id/rate-limits", c.UpdateUserLimits)
+	r.PUT("/admin/api-keys/:id/rate-limits", c.UpdateAPIKeyLimits)
+}
+
+// UpdateUserLimits 设置用户的 RPM/TPM 限制
+// PUT /api/v1/admin/users/:id/rate-limits
+func (c *RateLimitController) UpdateUserLimits(ctx *gin.Context) {
+	id, req, ok := c.bindLimits(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.limiter.SetUserLimits(ctx.Request.Context(), id, req.RPMLimit, req.TPMLimit)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, user)
+}
+
+// UpdateAPIKeyLimits 设置 API Key 的 RPM/TPM 限制
+// PUT /api/v1/admin/api-keys/:id/rate-limits
+func (c *RateLimitController) UpdateAPIKeyLimits(ctx *gin.Context) {
+	id, req, ok := c.bindLimits(ctx)
+	if !ok {
+		return
+	}
+
+	key, err := c.limiter.SetAPIKeyLimits(ctx.Request.Context(), id, req.RPMLimit, req.TPMLimit)
+	if err != nil {
+		c.handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
+
+// bindLimits 解析路径中的 ID 和请求体
+func (c *RateLimitController) bindLimits(ctx *gin.Context) (int64, *model.UpdateRateLimitsRequest, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + ctx.Param("id")})
+		return 0, nil, false
+	}
+
+	var req model.UpdateRateLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, nil, false
+	}
+	return id, &req, true
+}
+
+// handleError 将服务层错误映射为 HTTP 状态码
+func (c *RateLimitController) handleError(ctx *gin.Context, err error) {
+	var notFound *service.RateLimitTargetNotFoundError
+	if errors.As(err, &notFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
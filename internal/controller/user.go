@@ -191,6 +191,8 @@ func (c *UserController) ListAPIKeys(ctx *gin.Context) {
 			KeyPrefix:  key.KeyPrefix,
 			Name:       key.Name,
 			Status:     key.Status,
+			RPMLimit:   key.RPMLimit,
+			TPMLimit:   key.TPMLimit,
 			LastUsedAt: key.LastUsedAt,
 			ExpiresAt:  key.ExpiresAt,
 			CreatedAt:  key.CreatedAt,
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes 默认请求体大小上限（20 MiB），多模态请求中的 base64 图片、音频会显著增大请求体
+const DefaultMaxBodyBytes int64 = 20 << 20
+
+// MaxBodyBytesFromEnv 从 MAX_REQUEST_BODY_BYTES 读取请求体大小上限，未设置或非法时使用默认值
+func MaxBodyBytesFromEnv() int64 {
+	if v := os.Getenv("MAX_REQUEST_BODY_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultMaxBodyBytes
+}
+
+// BodySizeLimit 请求体大小限制中间件
+// Content-Length 超过上限时直接返回 413；未声明长度时由 http.MaxBytesReader 在读取时截断，
+// 读取方可通过 *http.MaxBytesError 识别
+func BodySizeLimit(maxBytes int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > maxBytes {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": gin.H{
+					"message": fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytes),
+					"type":    "request_too_large",
+				},
+			})
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestBodySizeLimit 测试请求体大小限制
+func TestBodySizeLimit(t *testing.T) {
+	router := gin.New()
+	router.Use(BodySizeLimit(16))
+	router.POST("/test", func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		expected      int
+	}{
+		{"within limit", "small", 5, http.StatusOK},
+		{"content-length exceeds limit", strings.Repeat("x", 32), 32, http.StatusRequestEntityTooLarge},
+		{"unknown length exceeds limit", strings.Repeat("x", 32), -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(tt.body))
+		req.ContentLength = tt.contentLength
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.expected, w.Code)
+		}
+	}
+}
+
+// TestMaxBodyBytesFromEnv 测试从环境变量读取上限
+func TestMaxBodyBytesFromEnv(t *testing.T) {
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "")
+	if got := MaxBodyBytesFromEnv(); got != DefaultMaxBodyBytes {
+		t.Errorf("expected default %d, got %d", DefaultMaxBodyBytes, got)
+	}
+
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "1048576")
+	if got := MaxBodyBytesFromEnv(); got != 1048576 {
+		t.Errorf("expected 1048576, got %d", got)
+	}
+
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "invalid")
+	if got := MaxBodyBytesFromEnv(); got != DefaultMaxBodyBytes {
+		t.Errorf("expected default for invalid value, got %d", got)
+	}
+}
@@ -1,119 +1,59 @@
 package middleware
 
 import (
+	"errors"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-)
-
-// RateLimiter 速率限制器
-type RateLimiter struct {
-	mu       sync.RWMutex
-	requests map[string][]time.Time // IP -> 请求时间列表
-	limit    int                    // 时间窗口内最大请求数
-	window   time.Duration          // 时间窗口大小
-}
-
-// NewRateLimiter 创建速率限制器
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-	}
+	"go.uber.org/zap"
 
-	// 启动清理 goroutine，定期过期旧记录
-	go rl.cleanup()
-
-	return rl
-}
-
-// cleanup 定期清理过期的请求记录
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(time.Minute * 5)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for ip, times := range rl.requests {
-			// 过滤掉时间窗口外的记录
-			validTimes := make([]time.Time, 0, len(times))
-			for _, t := range times {
-				if now.Sub(t) < rl.window {
-					validTimes = append(validTimes, t)
-				}
-			}
-			if len(validTimes) == 0 {
-				delete(rl.requests, ip)
-			} else {
-				rl.requests[ip] = validTimes
-			}
-		}
-		rl.mu.Unlock()
-	}
-}
-
-// Allow 检查是否允许请求
-func (rl *RateLimiter) Allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-
-	// 获取该 IP 的请求记录
-	times, exists := rl.requests[ip]
-	if !exists {
-		rl.requests[ip] = []time.Time{now}
-		return true
-	}
-
-	// 过滤掉时间窗口外的记录
-	validTimes := make([]time.Time, 0, len(times))
-	for _, t := range times {
-		if now.Sub(t) < rl.window {
-			validTimes = append(validTimes, t)
-		}
-	}
-
-	// 检查是否超过限制
-	if len(validTimes) >= rl.limit {
-		rl.requests[ip] = validTimes
-		return false
-	}
-
-	// 添加当前请求
-	rl.requests[ip] = append(validTimes, now)
-	return true
-}
-
-// 注册速率限制器（全局实例）
-var (
-	registerLimiter     *RateLimiter
-	registerLimiterOnce sync.Once
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/service"
 )
 
-// getRegisterLimiter 获取注册速率限制器实例
-// 同一 IP 每小时最多 5 次注册请求
-func getRegisterLimiter() *RateLimiter {
-	registerLimiterOnce.Do(func() {
-		registerLimiter = NewRateLimiter(5, time.Hour)
-	})
-	return registerLimiter
-}
+const (
+	// registerRateLimit 同一 IP 每小时最多 5 次注册请求
+	registerRateLimit  = 5
+	registerRateWindow = time.Hour
+)
 
 // RegisterRateLimit 注册速率限制中间件
-func RegisterRateLimit() gin.HandlerFunc {
-	limiter := getRegisterLimiter()
-
+// 按客户端 IP 使用令牌桶限制，令牌桶保存在 backend 中，使用共享后端时在多个网关副本间共享；
+// 令牌桶竞争激烈无法扣减时拒绝，backend 不可用时放行
+func RegisterRateLimit(backend service.RateLimitBackend) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// 获取客户端 IP
-		ip := ctx.ClientIP()
+		// 按客户端 IP 计数
+		charge := service.RateLimitCharge{
+			Key:    "register:" + ctx.ClientIP(),
+			Limit:  registerRateLimit,
+			Window: registerRateWindow,
+			Cost:   1,
+		}
 
 		// 检查是否允许请求
-		if !limiter.Allow(ip) {
+		states, allowed, err := backend.Take(ctx.Request.Context(), []service.RateLimitCharge{charge})
+		if errors.Is(err, service.ErrRateLimitContention) {
+			ctx.Header("Retry-After", "1")
+			ctx.JSON(http.StatusTooManyRequests, gin.H{
+				"message": "Too many registration attempts, please try again later",
+				"type":    "rate_limit_error",
+			})
+			ctx.Abort()
+			return
+		}
+		if err != nil {
+			logger.L.Warn("Failed to check register rate limit",
+				zap.String("trace_id", GetTraceID(ctx)),
+				zap.Error(err))
+			ctx.Next()
+			return
+		}
+		if !allowed {
+			retryAfter := max(int(math.Ceil(states[0].RetryAfter.Seconds())), 1)
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
 			ctx.JSON(http.StatusTooManyRequests, gin.H{
 				"message": "Too many registration attempts, please try again later",
 				"type":    "rate_limit_error",
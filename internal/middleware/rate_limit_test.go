@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/courier/internal/service"
+)
+
+// TestRegisterRateLimit 测试同一 IP 每小时最多 5 次注册请求，不同 IP 分别计数
+func TestRegisterRateLimit(t *testing.T) {
+	router := gin.New()
+	router.Use(RegisterRateLimit(service.NewMemoryRateLimitBackend()))
+	router.POST("/register", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	register := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/register", nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := register("10.0.0.1"); w.Code != http.StatusCreated {
+			t.Fatalf("request %d: expected status %d, got %d", i+1, http.StatusCreated, w.Code)
+		}
+	}
+
+	w := register("10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	// 每 12 分钟恢复 1 次
+	if got := w.Header().Get("Retry-After"); got != "720" {
+		t.Errorf("expected Retry-After 720, got %q", got)
+	}
+
+	if w := register("10.0.0.2"); w.Code != http.StatusCreated {
+		t.Errorf("expected other IP to be allowed, got %d", w.Code)
+	}
+}
@@ -124,6 +124,9 @@ func (m *Migrator) autoMigrate() error {
 		&model.User{},
 		&model.APIKey{},
 		&model.UsageRecord{},
+		&model.ModelAlias{},
+		&model.ResponseCacheEntry{},
+		&model.RateLimitBucket{},
 	}
 
 	// 添加注册的额外 models
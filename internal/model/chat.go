@@ -1,21 +1,270 @@
 package model
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // ChatRequest Chat 请求（OpenAI 兼容格式）
 type ChatRequest struct {
-	Model       string             `json:"model" binding:"required"`        // provider/model_name 格式
-	Messages    []ChatMessage      `json:"messages" binding:"required,min=1"`
-	Stream      bool               `json:"stream"`                          // 是否流式响应
-	Temperature *float64           `json:"temperature,omitempty"`
-	MaxTokens   *int               `json:"max_tokens,omitempty"`
-	TopP        *float64           `json:"top_p,omitempty"`
-	N           *int               `json:"n,omitempty"`
-	Stop        *string            `json:"stop,omitempty"`
+	Model         string         `json:"model" binding:"required"` // provider/model_name 格式
+	Messages      []ChatMessage  `json:"messages" binding:"required,min=1"`
+	Stream        bool           `json:"stream"` // 是否流式响应
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	N             *int           `json:"n,omitempty" binding:"omitempty,min=1,max=128"`
+	Stop          StopSequences  `json:"stop,omitempty"`
+	Tools         []ChatTool     `json:"tools,omitempty" binding:"omitempty,dive"`
+	ToolChoice    *ToolChoice    `json:"tool_choice,omitempty"`
+
+	PresencePenalty  *float64           `json:"presence_penalty,omitempty" binding:"omitempty,min=-2,max=2"`
+	FrequencyPenalty *float64           `json:"frequency_penalty,omitempty" binding:"omitempty,min=-2,max=2"`
+	Seed             *int64             `json:"seed,omitempty"`
+	LogitBias        map[string]float64 `json:"logit_bias,omitempty"`
+	Logprobs         bool               `json:"logprobs,omitempty"`
+	TopLogprobs      *int               `json:"top_logprobs,omitempty" binding:"omitempty,min=0,max=20"`
+	User             string             `json:"user,omitempty"`
+	ResponseFormat   *ResponseFormat    `json:"response_format,omitempty"`
+}
+
+// StreamOptions 流式响应选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 在 [DONE] 之前额外发送一个只包含 usage 的块
+}
+
+// StopSequences 停止序列
+// JSON 格式为字符串或字符串数组（最多 4 个）
+type StopSequences []string
+
+// UnmarshalJSON 解析 stop 字段
+func (s *StopSequences) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = StopSequences{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("stop must be a string or an array of strings")
+	}
+	if len(list) > 4 {
+		return errors.New("stop supports at most 4 sequences")
+	}
+	*s = list
+	return nil
+}
+
+// 响应格式类型
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 响应格式（结构化输出）
+type ResponseFormat struct {
+	Type       string      `json:"type" binding:"required,oneof=text json_object json_schema"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty" binding:"required_if=Type json_schema"`
+}
+
+// JSONSchema json_schema 响应格式的 Schema 定义
+type JSONSchema struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
 }
 
 // ChatMessage 聊天消息
+// content 在 assistant 消息携带 tool_calls 时可以为空，由 Controller 校验
 type ChatMessage struct {
-	Role    string `json:"role" binding:"required"`    // system, user, assistant
-	Content string `json:"content" binding:"required"`
+	Role       string         `json:"role" binding:"required"` // system, user, assistant, tool
+	Content    MessageContent `json:"content"`
+	Name       string         `json:"name,omitempty"`
+	ToolCalls  []ToolCall     `json:"tool_calls,omitempty"`   // assistant 消息发起的工具调用
+	ToolCallID string         `json:"tool_call_id,omitempty"` // tool 消息对应的工具调用 ID
+}
+
+// 内容片段类型
+const (
+	ContentPartText       = "text"
+	ContentPartImageURL   = "image_url"
+	ContentPartInputAudio = "input_audio"
+)
+
+// MessageContent 消息内容
+// JSON 格式为字符串或内容片段数组（OpenAI 多模态格式），Text 为所有文本片段的拼接
+type MessageContent struct {
+	Text  string
+	Parts []ContentPart // 仅当 content 为数组时非空
+}
+
+// ContentPart 内容片段
+type ContentPart struct {
+	Type       string      `json:"type"` // text, image_url, input_audio
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+// ImageURL 图片，URL 可以是 http(s) 地址或 data:image/png;base64,... 格式的 Data URL
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // auto, low, high
+}
+
+// InputAudio 音频（base64 编码）
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"` // wav, mp3
+}
+
+// TextContent 创建纯文本内容
+func TextContent(text string) MessageContent {
+	return MessageContent{Text: text}
+}
+
+// IsEmpty 内容是否为空
+func (c MessageContent) IsEmpty() bool {
+	return c.Text == "" && len(c.Parts) == 0
+}
+
+// UnmarshalJSON 解析 content 字段
+func (c *MessageContent) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = MessageContent{}
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = MessageContent{Text: text}
+		return nil
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return errors.New("content must be a string or an array of content parts")
+	}
+
+	var texts []string
+	for i, part := range parts {
+		switch part.Type {
+		case ContentPartText:
+			texts = append(texts, part.Text)
+		case ContentPartImageURL:
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return fmt.Errorf("content[%d]: image_url.url is required", i)
+			}
+		case ContentPartInputAudio:
+			if part.InputAudio == nil || part.InputAudio.Data == "" || part.InputAudio.Format == "" {
+				return fmt.Errorf("content[%d]: input_audio.data and input_audio.format are required", i)
+			}
+		default:
+			return fmt.Errorf("content[%d]: unsupported content part type %q", i, part.Type)
+		}
+	}
+
+	*c = MessageContent{Text: strings.Join(texts, "\n"), Parts: parts}
+	return nil
+}
+
+// MarshalJSON 纯文本输出为字符串，多模态内容输出为片段数组
+func (c MessageContent) MarshalJSON() ([]byte, error) {
+	if len(c.Parts) == 0 {
+		return json.Marshal(c.Text)
+	}
+	return json.Marshal(c.Parts)
+}
+
+// ChatTool 工具定义
+type ChatTool struct {
+	Type     string       `json:"type" binding:"required,eq=function"`
+	Function ChatFunction `json:"function"`
+}
+
+// ChatFunction 函数定义
+type ChatFunction struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"` // JSON Schema
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// ToolChoice 工具选择策略
+// JSON 格式为字符串（auto、none、required）或 {"type":"function","function":{"name":"..."}}
+type ToolChoice struct {
+	Type         string // auto, none, required, function
+	FunctionName string
+}
+
+// UnmarshalJSON 解析 tool_choice 字段
+func (t *ToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		switch mode {
+		case "auto", "none", "required":
+			t.Type = mode
+			return nil
+		}
+		return fmt.Errorf("invalid tool_choice: %s", mode)
+	}
+
+	var named struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &named); err != nil || named.Type != "function" || named.Function.Name == "" {
+		return errors.New(`tool_choice must be "auto", "none", "required" or {"type":"function","function":{"name":"..."}}`)
+	}
+
+	t.Type = "function"
+	t.FunctionName = named.Function.Name
+	return nil
+}
+
+// MarshalJSON 输出 OpenAI 格式的 tool_choice
+func (t ToolChoice) MarshalJSON() ([]byte, error) {
+	if t.Type != "function" {
+		return json.Marshal(t.Type)
+	}
+	return json.Marshal(map[string]any{
+		"type":     "function",
+		"function": map[string]string{"name": t.FunctionName},
+	})
+}
+
+// ToolCall 工具调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // function
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 函数调用
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"` // JSON 字符串
+}
+
+// ToolCallDelta 流式工具调用增量（通过 index 关联同一工具调用的参数片段）
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
 }
 
 // ChatResponse Chat 非流式响应（OpenAI 兼容格式）
@@ -30,9 +279,10 @@ type ChatResponse struct {
 
 // ChatChoice 响应选项
 type ChatChoice struct {
-	Index        int            `json:"index"`
-	Message      ChatMessage    `json:"message"`
-	FinishReason string         `json:"finish_reason"` // stop, length, content_filter
+	Index        int             `json:"index"`
+	Message      ChatMessage     `json:"message"`
+	FinishReason string          `json:"finish_reason"`      // stop, length, content_filter
+	Logprobs     json.RawMessage `json:"logprobs,omitempty"` // 请求 logprobs 时由上游返回
 }
 
 // ChatUsage Token 使用统计
@@ -44,22 +294,25 @@ type ChatUsage struct {
 
 // ChatStreamResponse Chat 流式响应（SSE 格式）
 type ChatStreamResponse struct {
-	ID      string                `json:"id"`
-	Object  string                `json:"object"`        // chat.completion.chunk
-	Created int64                 `json:"created"`
-	Model   string                `json:"model"`
-	Choices []ChatStreamChoice    `json:"choices"`
+	ID      string             `json:"id"`
+	Object  string             `json:"object"` // chat.completion.chunk
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []ChatStreamChoice `json:"choices"`
+	Usage   *ChatUsage         `json:"usage,omitempty"` // 仅 stream_options.include_usage 时的最后一个块
 }
 
 // ChatStreamChoice 流式响应选项
 type ChatStreamChoice struct {
-	Index        int             `json:"index"`
+	Index        int              `json:"index"`
 	Delta        ChatMessageDelta `json:"delta"`
-	FinishReason *string         `json:"finish_reason"`
+	FinishReason *string          `json:"finish_reason"`
+	Logprobs     json.RawMessage  `json:"logprobs,omitempty"`
 }
 
 // ChatMessageDelta 流式消息增量
 type ChatMessageDelta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
 }
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// EmbeddingRequest Embeddings 请求（OpenAI 兼容格式）
+type EmbeddingRequest struct {
+	Model          string         `json:"model" binding:"required"` // provider/model_name 格式
+	Input          EmbeddingInput `json:"input"`
+	EncodingFormat string         `json:"encoding_format,omitempty"` // float（默认）、base64
+	Dimensions     *int           `json:"dimensions,omitempty"`
+	User           string         `json:"user,omitempty"`
+}
+
+// EmbeddingInput Embeddings 输入
+// 兼容 OpenAI 的四种格式：字符串、字符串数组、token 数组、token 数组的数组
+type EmbeddingInput struct {
+	Texts  []string
+	Tokens [][]int
+}
+
+// UnmarshalJSON 解析 input 字段
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		in.Texts = []string{text}
+		return nil
+	}
+
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err == nil {
+		in.Texts = texts
+		return nil
+	}
+
+	var tokens []int
+	if err := json.Unmarshal(data, &tokens); err == nil {
+		in.Tokens = [][]int{tokens}
+		return nil
+	}
+
+	var tokenLists [][]int
+	if err := json.Unmarshal(data, &tokenLists); err == nil {
+		in.Tokens = tokenLists
+		return nil
+	}
+
+	return errors.New("input must be a string, an array of strings, an array of tokens, or an array of token arrays")
+}
+
+// Len 返回输入条数
+func (in *EmbeddingInput) Len() int {
+	if len(in.Tokens) > 0 {
+		return len(in.Tokens)
+	}
+	return len(in.Texts)
+}
+
+// EmbeddingResponse Embeddings 响应（OpenAI 兼容格式）
+type EmbeddingResponse struct {
+	Object string          `json:"object"` // list
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"` // 客户端请求的 model 参数
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData 单条向量
+type EmbeddingData struct {
+	Object    string `json:"object"` // embedding
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"` // float 格式为 []float64，base64 格式为 float32 小端序的 base64 字符串
+}
+
+// EmbeddingUsage Embeddings Token 使用统计
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
@@ -4,28 +4,35 @@ import "time"
 
 // ChatLog Chat 请求日志
 type ChatLog struct {
-	RequestID        string        `json:"request_id"`
-	TraceID          string        `json:"trace_id"`          // TraceID
-	APIKey           string        `json:"api_key"`           // 脱敏后
-	Model            string        `json:"model"`             // 完整的 provider/model_name
-	ProviderName     string        `json:"provider_name"`     // Provider 名称
-	ProviderType     string        `json:"provider_type"`     // Provider 类型
-	ModelName        string        `json:"model_name"`        // 模型名称
-	FallbackCount    int           `json:"fallback_count"`    // Fallback 次数
-	FinalModelName   string        `json:"final_model_name"`  // 最终使用的模型
-	AttemptDetails   []AttemptDetail `json:"attempt_details,omitempty"` // 尝试详情
-	PromptTokens     int           `json:"prompt_tokens"`
-	CompletionTokens int           `json:"completion_tokens"`
-	TotalTokens      int           `json:"total_tokens"`
-	LatencyMs        int64         `json:"latency_ms"`        // 请求耗时（毫秒）
-	Status           string        `json:"status"`            // success, error
-	Error            string        `json:"error,omitempty"`   // 错误信息
-	Timestamp        time.Time     `json:"timestamp"`
+	RequestID         string          `json:"request_id"`
+	TraceID           string          `json:"trace_id"`                  // TraceID
+	APIKey            string          `json:"api_key"`                   // 脱敏后
+	Model             string          `json:"model"`                     // 客户端请求的 model 参数（provider/model_name 或别名）
+	Alias             string          `json:"alias,omitempty"`           // 通过别名（路由组）请求时的别名
+	ProviderName      string          `json:"provider_name"`             // Provider 名称
+	ProviderType      string          `json:"provider_type"`             // Provider 类型
+	ModelName         string          `json:"model_name"`                // 模型名称
+	FallbackCount     int             `json:"fallback_count"`            // Fallback 次数
+	FinalModelName    string          `json:"final_model_name"`          // 最终使用的模型
+	FinalProviderName string          `json:"final_provider_name"`       // 最终使用的 Provider（跨 Provider Fallback 时与 ProviderName 不同）
+	Hedged            bool            `json:"hedged,omitempty"`          // 是否发出了对冲请求，胜出的为 FinalProviderName/FinalModelName
+	AttemptDetails    []AttemptDetail `json:"attempt_details,omitempty"` // 尝试详情
+	PromptTokens      int             `json:"prompt_tokens"`
+	CompletionTokens  int             `json:"completion_tokens"`
+	TotalTokens       int             `json:"total_tokens"`
+	LatencyMs         int64           `json:"latency_ms"`      // 请求耗时（毫秒）
+	Status            string          `json:"status"`          // success, error
+	Error             string          `json:"error,omitempty"` // 错误信息
+	Timestamp         time.Time       `json:"timestamp"`
 }
 
 // AttemptDetail 单次尝试详情（用于日志）
 type AttemptDetail struct {
-	ModelName string `json:"model_name"`
-	ErrorType string `json:"error_type,omitempty"`
-	DurationMs int64 `json:"duration_ms"`
+	ProviderName string `json:"provider_name"`
+	ModelName    string `json:"model_name"`
+	Attempt      int    `json:"attempt"`
+	ErrorType    string `json:"error_type,omitempty"`
+	DurationMs   int64  `json:"duration_ms"`
+	BackoffMs    int64  `json:"backoff_ms,omitempty"`
+	Hedge        bool   `json:"hedge,omitempty"`
 }
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// 路由策略
+const (
+	RouteStrategyPriority      = "priority"        // 按目标顺序，前面的失败后 Fallback 到后面的（默认）
+	RouteStrategyWeighted      = "weighted"        // 按权重随机
+	RouteStrategyRoundRobin    = "round_robin"     // 轮询
+	RouteStrategyLeastInFlight = "least_in_flight" // 处理中请求最少
+	RouteStrategyLowestLatency = "lowest_latency"  // 最近延迟中位数最低
+)
+
+// AliasTarget 别名的目标模型
+// JSON 格式为 "provider/model_name" 或 {"model": "provider/model_name", "weight": 3}
+type AliasTarget struct {
+	Model  string `json:"model"`            // provider/model_name
+	Weight int    `json:"weight,omitempty"` // 权重，仅 weighted 策略使用，未设置时为 1
+}
+
+// EffectiveWeight 返回生效的权重
+func (t AliasTarget) EffectiveWeight() int {
+	if t.Weight <= 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// UnmarshalJSON 解析目标
+func (t *AliasTarget) UnmarshalJSON(data []byte) error {
+	var ref string
+	if err := json.Unmarshal(data, &ref); err == nil {
+		*t = AliasTarget{Model: ref}
+		return nil
+	}
+
+	type target AliasTarget
+	var obj target
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return errors.New(`target must be "provider/model_name" or {"model":"provider/model_name","weight":1}`)
+	}
+	*t = AliasTarget(obj)
+	return nil
+}
+
+// MarshalJSON 未设置权重时输出为字符串
+func (t AliasTarget) MarshalJSON() ([]byte, error) {
+	if t.Weight == 0 {
+		return json.Marshal(t.Model)
+	}
+	type target AliasTarget
+	return json.Marshal(target(t))
+}
+
+// AliasTargets 别名的目标列表，以 JSON 数组存储
+type AliasTargets []AliasTarget
+
+// Value 实现 driver.Valuer 接口
+func (l AliasTargets) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (l *AliasTargets) Scan(value any) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// Models 返回全部目标的 provider/model_name
+func (l AliasTargets) Models() []string {
+	models := make([]string, len(l))
+	for i, t := range l {
+		models[i] = t.Model
+	}
+	return models
+}
+
+// ModelAlias 模型别名（路由组）
+// 客户端使用不带 provider 前缀的名称（如 chat-default）请求时，按 Strategy 在 Targets 中选择模型，
+// 其余目标作为 Fallback
+type ModelAlias struct {
+	ID            int64        `json:"id" db:"id" gorm:"primaryKey"`
+	Name          string       `json:"name" db:"name" gorm:"uniqueIndex;not null"`                             // 别名，不能包含 "/"
+	Targets       AliasTargets `json:"targets" db:"targets" gorm:"type:jsonb;not null"`                        // 目标列表
+	Strategy      string       `json:"strategy" db:"strategy" gorm:"size:32;not null;default:priority"`        // 路由策略
+	HedgeDelayMs  int          `json:"hedge_delay_ms,omitempty" db:"hedge_delay_ms" gorm:"not null;default:0"` // 对冲延迟（毫秒），0 表示使用首选 Provider 的配置
+	SemanticCache bool         `json:"semantic_cache" db:"semantic_cache" gorm:"not null;default:false"`       // 是否使用语义缓存
+	Description   string       `json:"description,omitempty" db:"description" gorm:"size:255"`                 // 可选：说明
+	CreatedAt     time.Time    `json:"created_at" db:"created_at" gorm:"autoCreateTime;default:NOW()"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at" gorm:"autoUpdateTime;default:NOW()"`
+}
+
+// TableName 指定表名
+func (ModelAlias) TableName() string {
+	return "model_aliases"
+}
@@ -0,0 +1,15 @@
+package model
+
+// ModelObject OpenAI 兼容的模型对象
+type ModelObject struct {
+	ID      string `json:"id"`     // provider/model_name
+	Object  string `json:"object"` // model
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"` // Provider 名称
+}
+
+// ModelList OpenAI 兼容的模型列表
+type ModelList struct {
+	Object string        `json:"object"` // list
+	Data   []ModelObject `json:"data"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// RateLimitBucket 限流令牌桶（RATE_LIMIT_BACKEND=postgres 时使用）
+type RateLimitBucket struct {
+	Key       string    `db:"key" gorm:"primaryKey;size:255"`                 // 如 user:1:requests、register:<ip>
+	Tokens    *float64  `db:"tokens"`                                         // 剩余令牌数，为空表示新建的满桶
+	UpdatedAt time.Time `db:"updated_at" gorm:"not null;default:NOW()"`       // 上次计算令牌数的时间
+	ExpiresAt time.Time `db:"expires_at" gorm:"index;not null;default:NOW()"` // 补满的时间，之后可以删除
+}
+
+// TableName 指定表名
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ResponseCacheEntry 响应缓存条目（RESPONSE_CACHE_BACKEND=postgres 时使用）
+type ResponseCacheEntry struct {
+	Key       string    `db:"key" gorm:"primaryKey;size:64"`       // 请求的规范化哈希
+	Response  []byte    `db:"response" gorm:"type:jsonb;not null"` // 缓存的 ChatResponse
+	ExpiresAt time.Time `db:"expires_at" gorm:"index;not null"`    // 过期时间
+	CreatedAt time.Time `db:"created_at" gorm:"index;autoCreateTime;default:NOW()"`
+}
+
+// TableName 指定表名
+func (ResponseCacheEntry) TableName() string {
+	return "response_cache"
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// SemanticCacheEntry 语义缓存条目（SEMANTIC_CACHE_BACKEND=pgvector 时使用）
+// semantic_cache 表依赖 pgvector 扩展，由 SemanticCacheRepository.EnsureSchema 创建，不参与自动迁移
+type SemanticCacheEntry struct {
+	ID         int64     `db:"id"`
+	Partition  string    `db:"partition"`  // 隔离范围、模型、上文和参数的哈希
+	Embedding  []float32 `db:"-"`          // 最后一条用户消息的向量
+	Response   []byte    `db:"response"`   // 缓存的 ChatResponse
+	Similarity float64   `db:"similarity"` // 查询时与请求向量的余弦相似度
+	ExpiresAt  time.Time `db:"expires_at"`
+	CreatedAt  time.Time `db:"created_at"`
+}
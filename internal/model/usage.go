@@ -17,6 +17,7 @@ type UsageRecord struct {
 	LatencyMs        int64     `json:"latency_ms" db:"latency_ms"`
 	Status           string    `json:"status" db:"status" gorm:"index"` // success, error
 	ErrorType        string    `json:"error_type,omitempty" db:"error_type"`
+	CacheHit         bool      `json:"cache_hit" db:"cache_hit" gorm:"not null;default:false"` // 是否由响应缓存返回（未请求上游）
 	Timestamp        time.Time `json:"timestamp" db:"timestamp" gorm:"autoCreateTime;default:NOW()"`
 }
 
@@ -55,6 +56,7 @@ type UsageSummary struct {
 	TotalPromptTokens     int64   `json:"total_prompt_tokens"`
 	TotalCompletionTokens int64   `json:"total_completion_tokens"`
 	AverageLatencyMs      float64 `json:"average_latency_ms"`
+	CacheHits             int     `json:"cache_hits"` // 由响应缓存返回的请求数
 }
 
 // DailyUsageStats 按天统计
@@ -104,4 +106,5 @@ type SummaryRow struct {
 	TotalPromptTokens     int64   `db:"total_prompt_tokens"`
 	TotalCompletionTokens int64   `db:"total_completion_tokens"`
 	AverageLatencyMs      float64 `db:"average_latency_ms"`
+	CacheHits             int     `db:"cache_hits"`
 }
@@ -7,9 +7,11 @@ type User struct {
 	ID           int64     `json:"id" db:"id" gorm:"primaryKey"`
 	Name         string    `json:"name" db:"name" gorm:"not null"`
 	Email        string    `json:"email" db:"email" gorm:"uniqueIndex;not null"`
-	PasswordHash string    `json:"-" db:"password_hash" gorm:"not null"` // 密码哈希，不输出到 JSON
+	PasswordHash string    `json:"-" db:"password_hash" gorm:"not null"`             // 密码哈希，不输出到 JSON
 	Role         string    `json:"role" db:"role" gorm:"index;default:'user'"`       // user, admin
-	Status       string    `json:"status" db:"status" gorm:"index;default:'active'"`   // active, disabled
+	Status       string    `json:"status" db:"status" gorm:"index;default:'active'"` // active, disabled
+	RPMLimit     *int      `json:"rpm_limit,omitempty" db:"rpm_limit"`               // 每分钟请求数上限，为空时使用角色默认值，0 表示不限制
+	TPMLimit     *int      `json:"tpm_limit,omitempty" db:"tpm_limit"`               // 每分钟 Token 数上限，为空时使用角色默认值，0 表示不限制
 	CreatedAt    time.Time `json:"created_at" db:"created_at" gorm:"autoCreateTime;default:NOW()"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at" gorm:"autoUpdateTime;default:NOW()"`
 }
@@ -23,10 +25,12 @@ func (User) TableName() string {
 type APIKey struct {
 	ID         int64      `json:"id" db:"id" gorm:"primaryKey"`
 	UserID     int64      `json:"user_id" db:"user_id" gorm:"index;not null"`
-	KeyHash    string     `json:"-" db:"key_hash" gorm:"not null"`    // SHA256 哈希存储，不输出到 JSON
-	KeyPrefix  string     `json:"key_prefix" db:"key_prefix" gorm:"not null"` // 前8位用于识别
-	Name       string     `json:"name" db:"name" gorm:"not null"`      // 用户定义的名称
-	Status     string     `json:"status" db:"status" gorm:"index;default:'active'"`  // active, disabled, revoked
+	KeyHash    string     `json:"-" db:"key_hash" gorm:"not null"`                  // SHA256 哈希存储，不输出到 JSON
+	KeyPrefix  string     `json:"key_prefix" db:"key_prefix" gorm:"not null"`       // 前8位用于识别
+	Name       string     `json:"name" db:"name" gorm:"not null"`                   // 用户定义的名称
+	Status     string     `json:"status" db:"status" gorm:"index;default:'active'"` // active, disabled, revoked
+	RPMLimit   *int       `json:"rpm_limit,omitempty" db:"rpm_limit"`               // 每分钟请求数上限，为空时只受用户限制，0 表示不限制
+	TPMLimit   *int       `json:"tpm_limit,omitempty" db:"tpm_limit"`               // 每分钟 Token 数上限，为空时只受用户限制，0 表示不限制
 	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at" gorm:"autoCreateTime;default:NOW()"`
@@ -46,19 +50,23 @@ type CreateUserRequest struct {
 
 // CreateAPIKeyRequest 创建 API Key 请求
 type CreateAPIKeyRequest struct {
-	Name      string    `json:"name" binding:"required"`
+	Name      string     `json:"name" binding:"required"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RPMLimit  *int       `json:"rpm_limit,omitempty" binding:"omitempty,min=0"`
+	TPMLimit  *int       `json:"tpm_limit,omitempty" binding:"omitempty,min=0"`
 }
 
 // CreateAPIKeyResponse 创建 API Key 响应（包含完整 Key，仅在创建时返回）
 type CreateAPIKeyResponse struct {
-	ID        int64     `json:"id"`
-	Key       string    `json:"key"`       // 完整的 API Key，仅此一次
-	KeyPrefix string    `json:"key_prefix"`
-	Name      string    `json:"name"`
-	Status    string    `json:"status"`
+	ID        int64      `json:"id"`
+	Key       string     `json:"key"` // 完整的 API Key，仅此一次
+	KeyPrefix string     `json:"key_prefix"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	RPMLimit  *int       `json:"rpm_limit,omitempty"`
+	TPMLimit  *int       `json:"tpm_limit,omitempty"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 // APIKeyListItem API Key 列表项（不包含完整 Key）
@@ -67,11 +75,20 @@ type APIKeyListItem struct {
 	KeyPrefix  string     `json:"key_prefix"`
 	Name       string     `json:"name"`
 	Status     string     `json:"status"`
+	RPMLimit   *int       `json:"rpm_limit,omitempty"`
+	TPMLimit   *int       `json:"tpm_limit,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// UpdateRateLimitsRequest 设置用户或 API Key 的速率限制请求
+// 字段为空时清除设置（用户恢复角色默认值，API Key 只受用户限制），0 表示不限制
+type UpdateRateLimitsRequest struct {
+	RPMLimit *int `json:"rpm_limit" binding:"omitempty,min=0"`
+	TPMLimit *int `json:"tpm_limit" binding:"omitempty,min=0"`
+}
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -0,0 +1,295 @@
+// Package resp 实现 Redis 协议（RESP2）的最小客户端，只支持网关需要的命令
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNil 服务端返回空值（如 EXEC 因 WATCH 的 key 被修改而放弃执行）
+var ErrNil = errors.New("resp: nil reply")
+
+// Error 服务端返回的错误回复
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Options 连接参数
+type Options struct {
+	Addr        string // host:port
+	Password    string
+	DB          int
+	DialTimeout time.Duration
+	// CommandTimeout context 没有截止时间时单条命令（写入和读取回复）的超时时间
+	CommandTimeout time.Duration
+	MaxIdle        int // 连接池保留的空闲连接数
+}
+
+// ParseURL 解析 redis://[:password@]host:port[/db] 格式的地址
+func ParseURL(rawURL string) (Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Options{}, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return Options{}, fmt.Errorf("invalid redis url scheme %q", u.Scheme)
+	}
+
+	opts := Options{Addr: u.Host, DialTimeout: 5 * time.Second, CommandTimeout: 5 * time.Second, MaxIdle: 8}
+	if u.Port() == "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		opts.Password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if opts.DB, err = strconv.Atoi(db); err != nil {
+			return Options{}, fmt.Errorf("invalid redis db %q", db)
+		}
+	}
+	return opts, nil
+}
+
+// Client 带连接池的客户端，可并发使用
+type Client struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   []*Conn
+	closed bool
+}
+
+// NewClient 创建客户端，连接在第一次使用时建立
+func NewClient(opts Options) *Client {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.CommandTimeout <= 0 {
+		opts.CommandTimeout = 5 * time.Second
+	}
+	return &Client{opts: opts}
+}
+
+// Do 使用连接池中的连接执行一条命令
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.Do(ctx, args...)
+	c.Release(conn, err)
+	return reply, err
+}
+
+// Conn 从连接池取出一条连接，WATCH/MULTI/EXEC 等需要在同一连接上执行的命令使用，用完后调用 Release
+func (c *Client) Conn(ctx context.Context) (*Conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("resp: client closed")
+	}
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	return c.dial(ctx)
+}
+
+// Release 归还连接；err 为网络或协议错误时关闭连接
+func (c *Client) Release(conn *Conn, err error) {
+	var replyErr Error
+	if err != nil && !errors.Is(err, ErrNil) && !errors.As(err, &replyErr) {
+		conn.Close()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= max(c.opts.MaxIdle, 1) {
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+// Close 关闭全部空闲连接，之后不能再使用
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, conn := range c.idle {
+		conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// dial 建立连接并完成认证和选择数据库
+func (c *Client) dial(ctx context.Context) (*Conn, error) {
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis %s: %w", c.opts.Addr, err)
+	}
+	conn := &Conn{conn: netConn, r: bufio.NewReader(netConn), w: bufio.NewWriter(netConn), timeout: c.opts.CommandTimeout}
+
+	if c.opts.Password != "" {
+		if _, err := conn.Do(ctx, "AUTH", c.opts.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := conn.Do(ctx, "SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select db failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Conn 单条连接，不能并发使用
+type Conn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration // context 没有截止时间时的命令超时时间
+}
+
+// Do 执行一条命令并读取回复
+// 回复类型：简单字符串和批量字符串为 string，整数为 int64，数组为 []any；空值返回 ErrNil，错误回复返回 Error
+// ctx 被取消时关闭连接并返回 ctx.Err()，之后不能再使用该连接
+func (c *Conn) Do(ctx context.Context, args ...string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok && c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.Close()
+	})
+	defer stop()
+
+	reply, err := c.roundTrip(args)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case nil:
+		return nil, ErrNil
+	case Error:
+		return nil, v
+	}
+	return reply, nil
+}
+
+// roundTrip 写入命令并读取回复
+func (c *Conn) roundTrip(args []string) (any, error) {
+	if err := WriteCommand(c.w, args...); err != nil {
+		return nil, err
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return ReadReply(c.r)
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// WriteCommand 以批量字符串数组写入命令，返回第一个写入错误
+func WriteCommand(w *bufio.Writer, args ...string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadReply 读取一个回复，空值返回 nil，错误回复返回 Error（不作为 error 返回）
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("resp: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := parseLength(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := parseLength(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = ReadReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("resp: unexpected reply %q", line)
+	}
+}
+
+// parseLength 解析批量字符串或数组的长度，-1 表示空值，其他负数或无法解析时返回协议错误
+func parseLength(line string) (int, error) {
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < -1 {
+		return 0, fmt.Errorf("resp: invalid length %q", line)
+	}
+	return n, nil
+}
+
+// readLine 读取一行，去掉结尾的 \r\n
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
@@ -0,0 +1,185 @@
+package resp_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/pkg/resp"
+	"github.com/lucheng0127/courier/internal/pkg/resp/resptest"
+)
+
+func TestParseURL(t *testing.T) {
+	opts, err := resp.ParseURL("redis://:secret@cache.internal:6380/2")
+	require.NoError(t, err)
+	assert.Equal(t, "cache.internal:6380", opts.Addr)
+	assert.Equal(t, "secret", opts.Password)
+	assert.Equal(t, 2, opts.DB)
+
+	opts, err = resp.ParseURL("redis://localhost")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:6379", opts.Addr)
+	assert.Equal(t, 5*time.Second, opts.CommandTimeout)
+
+	_, err = resp.ParseURL("http://localhost:6379")
+	assert.Error(t, err)
+	_, err = resp.ParseURL("redis://localhost:6379/x")
+	assert.Error(t, err)
+}
+
+func TestReadReply(t *testing.T) {
+	read := func(data string) (any, error) {
+		return resp.ReadReply(bufio.NewReader(strings.NewReader(data)))
+	}
+
+	reply, err := read("$5\r\nhello\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", reply)
+
+	reply, err = read("*2\r\n:1\r\n$-1\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(1), nil}, reply)
+
+	// 只有 -1 表示空值
+	reply, err = read("*-1\r\n")
+	require.NoError(t, err)
+	assert.Nil(t, reply)
+
+	for _, data := range []string{"$-2\r\n", "*-5\r\n", "$abc\r\n", "*\r\n"} {
+		_, err = read(data)
+		assert.Error(t, err, data)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestWriteCommand_Error(t *testing.T) {
+	// 缓冲区小于命令长度，写入时返回底层连接的错误
+	w := bufio.NewWriterSize(failingWriter{}, 16)
+	err := resp.WriteCommand(w, "SET", "key", strings.Repeat("v", 64))
+	assert.EqualError(t, err, "broken pipe")
+}
+
+func TestClient_Do(t *testing.T) {
+	server, err := resptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	server.RequirePassword("secret")
+
+	opts, err := resp.ParseURL("redis://:secret@" + server.Addr())
+	require.NoError(t, err)
+	client := resp.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	reply, err := client.Do(ctx, "SET", "greeting", "hello world")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+
+	reply, err = client.Do(ctx, "MGET", "greeting", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"hello world", nil}, reply)
+
+	_, err = client.Do(ctx, "GET", "missing")
+	assert.ErrorIs(t, err, resp.ErrNil)
+
+	_, err = client.Do(ctx, "INCR", "counter")
+	var replyErr resp.Error
+	assert.ErrorAs(t, err, &replyErr)
+
+	// 错误回复不影响连接复用
+	reply, err = client.Do(ctx, "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "PONG", reply)
+}
+
+func TestConn_WatchConflict(t *testing.T) {
+	server, err := resptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+
+	client := resp.NewClient(resp.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	conn, err := client.Conn(ctx)
+	require.NoError(t, err)
+	defer client.Release(conn, nil)
+
+	_, err = conn.Do(ctx, "WATCH", "bucket")
+	require.NoError(t, err)
+	// 其他客户端在 WATCH 之后修改了 key，事务放弃执行
+	server.Set("bucket", "other")
+	_, err = conn.Do(ctx, "MULTI")
+	require.NoError(t, err)
+	reply, err := conn.Do(ctx, "SET", "bucket", "mine")
+	require.NoError(t, err)
+	assert.Equal(t, "QUEUED", reply)
+	_, err = conn.Do(ctx, "EXEC")
+	assert.ErrorIs(t, err, resp.ErrNil)
+
+	value, _ := server.Get("bucket")
+	assert.Equal(t, "other", value)
+
+	// 没有冲突时事务执行
+	_, err = conn.Do(ctx, "WATCH", "bucket")
+	require.NoError(t, err)
+	_, err = conn.Do(ctx, "MULTI")
+	require.NoError(t, err)
+	_, err = conn.Do(ctx, "SET", "bucket", "mine", "PX", "1000")
+	require.NoError(t, err)
+	reply, err = conn.Do(ctx, "EXEC")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"OK"}, reply)
+
+	value, _ = server.Get("bucket")
+	assert.Equal(t, "mine", value)
+}
+
+// TestConn_Timeout 测试服务端不回复时，命令在超时时间后或 context 取消时返回
+func TestConn_Timeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// 接受连接但从不回复
+			defer conn.Close()
+		}
+	}()
+
+	client := resp.NewClient(resp.Options{Addr: listener.Addr().String(), CommandTimeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.Do(context.Background(), "PING")
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	assert.Less(t, time.Since(start), time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	client = resp.NewClient(resp.Options{Addr: listener.Addr().String(), CommandTimeout: time.Minute})
+	defer client.Close()
+
+	start = time.Now()
+	_, err = client.Do(ctx, "PING")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
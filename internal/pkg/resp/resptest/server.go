@@ -0,0 +1,324 @@
+// Package resptest 提供用于测试的进程内 Redis 协议服务端，支持 resp 客户端使用的命令子集
+package resptest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/pkg/resp"
+)
+
+// Server 进程内 Redis 协议服务端
+// 支持 PING、AUTH、SELECT、TIME、GET、MGET、SET（PX）、DEL、WATCH、UNWATCH、MULTI、EXEC、DISCARD
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	now      func() time.Time
+	data     map[string]entry
+	versions map[string]int64 // key -> 修改次数，用于 WATCH
+	password string
+}
+
+// entry 键值和过期时间
+type entry struct {
+	value     string
+	expiresAt time.Time // 零值表示不过期
+}
+
+// NewServer 在 127.0.0.1 的随机端口上启动服务端
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		now:      time.Now,
+		data:     make(map[string]entry),
+		versions: make(map[string]int64),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL redis:// 格式的地址
+func (s *Server) URL() string {
+	return "redis://" + s.Addr()
+}
+
+// SetNow 设置服务端时钟，用于 TIME 和过期时间
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// RequirePassword 要求客户端先执行 AUTH
+func (s *Server) RequirePassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// Get 读取键值，用于测试断言
+func (s *Server) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(key)
+	return e.value, ok
+}
+
+// Set 写入键值，会使 WATCH 该 key 的事务失败，用于模拟其他客户端的并发修改
+func (s *Server) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(key, entry{value: value})
+}
+
+// Close 停止服务端
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// session 单条连接的状态
+type session struct {
+	authed  bool
+	watched map[string]int64 // WATCH 的 key -> WATCH 时的版本
+	queued  [][]string       // MULTI 之后排队的命令，为 nil 表示不在事务中
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	sess := &session{}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		writeReply(w, s.exec(sess, args))
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// exec 执行一条命令，返回回复
+func (s *Server) exec(sess *session, args []string) any {
+	if len(args) == 0 {
+		return resp.Error("ERR empty command")
+	}
+	cmd := strings.ToUpper(args[0])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cmd == "AUTH" {
+		if len(args) != 2 || args[1] != s.password {
+			return resp.Error("WRONGPASS invalid password")
+		}
+		sess.authed = true
+		return "OK"
+	}
+	if s.password != "" && !sess.authed {
+		return resp.Error("NOAUTH Authentication required.")
+	}
+
+	if sess.queued != nil {
+		switch cmd {
+		case "EXEC":
+			queued := sess.queued
+			sess.queued = nil
+			conflict := false
+			for key, version := range sess.watched {
+				if s.versions[key] != version {
+					conflict = true
+				}
+			}
+			sess.watched = nil
+			if conflict {
+				return nil
+			}
+			replies := make([]any, len(queued))
+			for i, q := range queued {
+				replies[i] = s.run(q)
+			}
+			return replies
+		case "DISCARD":
+			sess.queued = nil
+			sess.watched = nil
+			return "OK"
+		case "MULTI", "WATCH":
+			return resp.Error("ERR " + cmd + " inside MULTI is not allowed")
+		default:
+			sess.queued = append(sess.queued, args)
+			return "QUEUED"
+		}
+	}
+
+	switch cmd {
+	case "MULTI":
+		sess.queued = [][]string{}
+		return "OK"
+	case "EXEC", "DISCARD":
+		return resp.Error("ERR " + cmd + " without MULTI")
+	case "WATCH":
+		if sess.watched == nil {
+			sess.watched = make(map[string]int64)
+		}
+		for _, key := range args[1:] {
+			s.lookup(key) // 已过期的 key 在 WATCH 前清理，避免过期被视为修改
+			sess.watched[key] = s.versions[key]
+		}
+		return "OK"
+	case "UNWATCH":
+		sess.watched = nil
+		return "OK"
+	default:
+		return s.run(args)
+	}
+}
+
+// run 执行读写命令，调用方需持有 s.mu
+func (s *Server) run(args []string) any {
+	switch cmd := strings.ToUpper(args[0]); cmd {
+	case "PING":
+		return "PONG"
+	case "SELECT":
+		return "OK"
+	case "TIME":
+		now := s.now()
+		return []any{strconv.FormatInt(now.Unix(), 10), strconv.Itoa(now.Nanosecond() / 1000)}
+	case "GET":
+		if len(args) != 2 {
+			return wrongArgs(cmd)
+		}
+		if e, ok := s.lookup(args[1]); ok {
+			return e.value
+		}
+		return nil
+	case "MGET":
+		values := make([]any, len(args)-1)
+		for i, key := range args[1:] {
+			if e, ok := s.lookup(key); ok {
+				values[i] = e.value
+			}
+		}
+		return values
+	case "SET":
+		if len(args) != 3 && len(args) != 5 {
+			return wrongArgs(cmd)
+		}
+		e := entry{value: args[2]}
+		if len(args) == 5 {
+			ms, err := strconv.ParseInt(args[4], 10, 64)
+			if strings.ToUpper(args[3]) != "PX" || err != nil || ms <= 0 {
+				return resp.Error("ERR syntax error")
+			}
+			e.expiresAt = s.now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.write(args[1], e)
+		return "OK"
+	case "DEL":
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := s.lookup(key); ok {
+				delete(s.data, key)
+				s.versions[key]++
+				n++
+			}
+		}
+		return n
+	default:
+		return resp.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// lookup 读取未过期的键值，调用方需持有 s.mu
+func (s *Server) lookup(key string) (entry, bool) {
+	e, ok := s.data[key]
+	if ok && !e.expiresAt.IsZero() && !s.now().Before(e.expiresAt) {
+		delete(s.data, key)
+		s.versions[key]++
+		return entry{}, false
+	}
+	return e, ok
+}
+
+// write 写入键值，调用方需持有 s.mu
+func (s *Server) write(key string, e entry) {
+	s.data[key] = e
+	s.versions[key]++
+}
+
+func wrongArgs(cmd string) resp.Error {
+	return resp.Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+}
+
+// readCommand 读取客户端发送的批量字符串数组
+func readCommand(r *bufio.Reader) ([]string, error) {
+	reply, err := resp.ReadReply(r)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, errors.New("resptest: expected array command")
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		if args[i], ok = item.(string); !ok {
+			return nil, errors.New("resptest: expected bulk string argument")
+		}
+	}
+	return args, nil
+}
+
+// writeReply 按 RESP2 格式写入回复
+func writeReply(w io.Writer, reply any) {
+	switch v := reply.(type) {
+	case nil:
+		io.WriteString(w, "$-1\r\n")
+	case resp.Error:
+		fmt.Fprintf(w, "-%s\r\n", string(v))
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		if v == "OK" || v == "QUEUED" || v == "PONG" {
+			fmt.Fprintf(w, "+%s\r\n", v)
+		} else {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+		}
+	case []any:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	}
+}
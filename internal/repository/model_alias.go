@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// ModelAliasRepository 模型别名数据访问接口
+type ModelAliasRepository interface {
+	// Create 创建别名
+	Create(ctx context.Context, alias *model.ModelAlias) error
+
+	// GetByName 按 name 查询
+	GetByName(ctx context.Context, name string) (*model.ModelAlias, error)
+
+	// List 列出所有别名
+	List(ctx context.Context) ([]*model.ModelAlias, error)
+
+	// Update 更新别名
+	Update(ctx context.Context, alias *model.ModelAlias) error
+
+	// Delete 删除别名
+	Delete(ctx context.Context, id int64) error
+
+	// ExistsByName 检查 name 是否存在
+	ExistsByName(ctx context.Context, name string) (bool, error)
+}
+
+// modelAliasRepository 模型别名数据访问实现
+type modelAliasRepository struct {
+	db *sqlx.DB
+}
+
+// NewModelAliasRepository 创建 ModelAlias Repository
+func NewModelAliasRepository(db *sqlx.DB) ModelAliasRepository {
+	return &modelAliasRepository{db: db}
+}
+
+// Create 创建别名
+func (r *modelAliasRepository) Create(ctx context.Context, alias *model.ModelAlias) error {
+	query := `
+		INSERT INTO model_aliases (name, targets, strategy, hedge_delay_ms, semantic_cache, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		alias.Name,
+		alias.Targets,
+		alias.Strategy,
+		alias.HedgeDelayMs,
+		alias.SemanticCache,
+		alias.Description,
+	).Scan(&alias.ID, &alias.CreatedAt, &alias.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create model alias: %w", err)
+	}
+	return nil
+}
+
+// GetByName 按 name 查询
+func (r *modelAliasRepository) GetByName(ctx context.Context, name string) (*model.ModelAlias, error) {
+	var alias model.ModelAlias
+	query := `SELECT id, name, targets, strategy, hedge_delay_ms, semantic_cache, description, created_at, updated_at FROM model_aliases WHERE name = $1`
+	err := r.db.GetContext(ctx, &alias, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model alias by name: %w", err)
+	}
+	return &alias, nil
+}
+
+// List 列出所有别名
+func (r *modelAliasRepository) List(ctx context.Context) ([]*model.ModelAlias, error) {
+	var aliases []*model.ModelAlias
+	query := `SELECT id, name, targets, strategy, hedge_delay_ms, semantic_cache, description, created_at, updated_at FROM model_aliases ORDER BY name`
+	err := r.db.SelectContext(ctx, &aliases, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// Update 更新别名
+func (r *modelAliasRepository) Update(ctx context.Context, alias *model.ModelAlias) error {
+	query := `
+		UPDATE model_aliases
+		SET targets = $1, strategy = $2, hedge_delay_ms = $3, semantic_cache = $4, description = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		alias.Targets,
+		alias.Strategy,
+		alias.HedgeDelayMs,
+		alias.SemanticCache,
+		alias.Description,
+		alias.ID,
+	).Scan(&alias.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update model alias: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除别名
+func (r *modelAliasRepository) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM model_aliases WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete model alias: %w", err)
+	}
+	return nil
+}
+
+// ExistsByName 检查 name 是否存在
+func (r *modelAliasRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM model_aliases WHERE name = $1)`
+	err := r.db.GetContext(ctx, &exists, query, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to check model alias name existence: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// RateLimitRepository 限流令牌桶数据访问接口
+type RateLimitRepository interface {
+	// Update 在一个事务中锁定 keys 对应的令牌桶（不存在时创建），以数据库时间调用 fn 修改后写回
+	// fn 返回错误时回滚
+	Update(ctx context.Context, keys []string, fn func(now time.Time, buckets map[string]*model.RateLimitBucket) error) error
+
+	// DeleteExpired 删除已经补满的令牌桶
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// rateLimitRepository 限流令牌桶数据访问实现
+type rateLimitRepository struct {
+	db *sqlx.DB
+}
+
+// NewRateLimitRepository 创建 RateLimit Repository
+func NewRateLimitRepository(db *sqlx.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Update 锁定、修改并写回令牌桶
+// 按 key 排序加锁，多个副本同时扣减重叠的令牌桶时不会死锁；使用数据库时间，不受各副本时钟偏差影响
+func (r *rateLimitRepository) Update(ctx context.Context, keys []string, fn func(now time.Time, buckets map[string]*model.RateLimitBucket) error) error {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin rate limit transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := `
+		INSERT INTO rate_limit_buckets (key, updated_at, expires_at)
+		SELECT k, NOW(), NOW() FROM unnest($1::text[]) AS k ORDER BY k
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insert, pq.Array(sorted)); err != nil {
+		return fmt.Errorf("failed to create rate limit buckets: %w", err)
+	}
+
+	var rows []model.RateLimitBucket
+	query := `SELECT key, tokens, updated_at, expires_at FROM rate_limit_buckets WHERE key = ANY($1) ORDER BY key FOR UPDATE`
+	if err := tx.SelectContext(ctx, &rows, query, pq.Array(sorted)); err != nil {
+		return fmt.Errorf("failed to lock rate limit buckets: %w", err)
+	}
+
+	// 等待锁之后再取时间，NOW() 为事务开始的时间，可能早于其他事务写入的 updated_at
+	var now time.Time
+	if err := tx.GetContext(ctx, &now, `SELECT clock_timestamp()`); err != nil {
+		return fmt.Errorf("failed to get database time: %w", err)
+	}
+
+	buckets := make(map[string]*model.RateLimitBucket, len(rows))
+	for i := range rows {
+		buckets[rows[i].Key] = &rows[i]
+	}
+	if err := fn(now, buckets); err != nil {
+		return err
+	}
+
+	update := `UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3, expires_at = $4 WHERE key = $1`
+	for _, b := range rows {
+		if _, err := tx.ExecContext(ctx, update, b.Key, b.Tokens, b.UpdatedAt, b.ExpiresAt); err != nil {
+			return fmt.Errorf("failed to update rate limit bucket: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rate limit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired 删除已经补满的令牌桶，之后再使用时按满桶重新创建
+func (r *rateLimitRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired rate limit buckets: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// ResponseCacheRepository 响应缓存数据访问接口
+type ResponseCacheRepository interface {
+	// Get 按 key 查询未过期的条目，不存在时返回 nil
+	Get(ctx context.Context, key string) (*model.ResponseCacheEntry, error)
+
+	// Upsert 写入条目，key 已存在时覆盖
+	Upsert(ctx context.Context, entry *model.ResponseCacheEntry) error
+
+	// Trim 删除过期条目，以及超出 maxEntries 的最早写入的条目
+	Trim(ctx context.Context, maxEntries int) (int64, error)
+}
+
+// responseCacheRepository 响应缓存数据访问实现
+type responseCacheRepository struct {
+	db *sqlx.DB
+}
+
+// NewResponseCacheRepository 创建 ResponseCache Repository
+func NewResponseCacheRepository(db *sqlx.DB) ResponseCacheRepository {
+	return &responseCacheRepository{db: db}
+}
+
+// Get 按 key 查询未过期的条目
+func (r *responseCacheRepository) Get(ctx context.Context, key string) (*model.ResponseCacheEntry, error) {
+	var entry model.ResponseCacheEntry
+	query := `SELECT key, response, expires_at, created_at FROM response_cache WHERE key = $1 AND expires_at > NOW()`
+	err := r.db.GetContext(ctx, &entry, query, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Upsert 写入条目
+func (r *responseCacheRepository) Upsert(ctx context.Context, entry *model.ResponseCacheEntry) error {
+	query := `
+		INSERT INTO response_cache (key, response, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET response = EXCLUDED.response, expires_at = EXCLUDED.expires_at, created_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query, entry.Key, entry.Response, entry.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert response cache entry: %w", err)
+	}
+	return nil
+}
+
+// Trim 删除过期和超出容量的条目
+func (r *responseCacheRepository) Trim(ctx context.Context, maxEntries int) (int64, error) {
+	query := `
+		DELETE FROM response_cache
+		WHERE expires_at <= NOW()
+			OR key IN (SELECT key FROM response_cache ORDER BY created_at DESC OFFSET $1)
+	`
+	result, err := r.db.ExecContext(ctx, query, maxEntries)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim response cache: %w", err)
+	}
+	return result.RowsAffected()
+}
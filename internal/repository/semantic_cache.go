@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// SemanticCacheRepository 语义缓存数据访问接口（pgvector）
+type SemanticCacheRepository interface {
+	// EnsureSchema 创建 pgvector 扩展和 semantic_cache 表
+	EnsureSchema(ctx context.Context) error
+
+	// Nearest 在分区中查找余弦距离最近的未过期条目，不存在时返回 nil
+	Nearest(ctx context.Context, partition string, embedding []float32) (*model.SemanticCacheEntry, error)
+
+	// Create 写入条目
+	Create(ctx context.Context, entry *model.SemanticCacheEntry) error
+
+	// Trim 删除过期条目，以及超出 maxEntries 的最早写入的条目
+	Trim(ctx context.Context, maxEntries int) (int64, error)
+}
+
+// semanticCacheRepository 语义缓存数据访问实现
+type semanticCacheRepository struct {
+	db *sqlx.DB
+}
+
+// NewSemanticCacheRepository 创建 SemanticCache Repository
+func NewSemanticCacheRepository(db *sqlx.DB) SemanticCacheRepository {
+	return &semanticCacheRepository{db: db}
+}
+
+// EnsureSchema 创建 pgvector 扩展和 semantic_cache 表
+// 向量列不限定维度，以便更换 Embeddings 模型；查询时只比较维度相同的条目
+func (r *semanticCacheRepository) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		`CREATE TABLE IF NOT EXISTS semantic_cache (
+			id BIGSERIAL PRIMARY KEY,
+			partition VARCHAR(128) NOT NULL,
+			embedding vector NOT NULL,
+			response JSONB NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_semantic_cache_partition ON semantic_cache (partition)`,
+		`CREATE INDEX IF NOT EXISTS idx_semantic_cache_expires_at ON semantic_cache (expires_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to ensure semantic cache schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Nearest 在分区中查找余弦距离最近的未过期条目
+func (r *semanticCacheRepository) Nearest(ctx context.Context, partition string, embedding []float32) (*model.SemanticCacheEntry, error) {
+	var entry model.SemanticCacheEntry
+	query := `
+		SELECT id, partition, response, 1 - (embedding <=> $2::vector) AS similarity, expires_at, created_at
+		FROM semantic_cache
+		WHERE partition = $1 AND expires_at > NOW() AND vector_dims(embedding) = $3
+		ORDER BY embedding <=> $2::vector
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &entry, query, partition, vectorLiteral(embedding), len(embedding))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search semantic cache: %w", err)
+	}
+	return &entry, nil
+}
+
+// Create 写入条目
+func (r *semanticCacheRepository) Create(ctx context.Context, entry *model.SemanticCacheEntry) error {
+	query := `
+		INSERT INTO semantic_cache (partition, embedding, response, expires_at)
+		VALUES ($1, $2::vector, $3, $4)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		entry.Partition,
+		vectorLiteral(entry.Embedding),
+		entry.Response,
+		entry.ExpiresAt,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create semantic cache entry: %w", err)
+	}
+	return nil
+}
+
+// Trim 删除过期和超出容量的条目
+func (r *semanticCacheRepository) Trim(ctx context.Context, maxEntries int) (int64, error) {
+	query := `
+		DELETE FROM semantic_cache
+		WHERE expires_at <= NOW()
+			OR id IN (SELECT id FROM semantic_cache ORDER BY created_at DESC OFFSET $1)
+	`
+	result, err := r.db.ExecContext(ctx, query, maxEntries)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim semantic cache: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// vectorLiteral 将向量格式化为 pgvector 的文本格式，如 [0.1,0.2]
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -42,9 +42,9 @@ func (r *usageRepository) CreateUsageRecord(ctx context.Context, record *model.U
 	query := `
 		INSERT INTO usage_records (
 			user_id, api_key_id, request_id, trace_id, model, provider_name,
-			prompt_tokens, completion_tokens, total_tokens, latency_ms, status, error_type
+			prompt_tokens, completion_tokens, total_tokens, latency_ms, status, error_type, cache_hit
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, timestamp
 	`
 	err := r.db.QueryRowContext(ctx, query,
@@ -60,6 +60,7 @@ func (r *usageRepository) CreateUsageRecord(ctx context.Context, record *model.U
 		record.LatencyMs,
 		record.Status,
 		record.ErrorType,
+		record.CacheHit,
 	).Scan(&record.ID, &record.Timestamp)
 	if err != nil {
 		return fmt.Errorf("failed to create usage record: %w", err)
@@ -72,7 +73,7 @@ func (r *usageRepository) QueryUsageByUserAndTimeRange(ctx context.Context, user
 	var records []*model.UsageRecord
 	query := `
 		SELECT id, user_id, api_key_id, request_id, trace_id, model, provider_name,
-			prompt_tokens, completion_tokens, total_tokens, latency_ms, status, error_type, timestamp
+			prompt_tokens, completion_tokens, total_tokens, latency_ms, status, error_type, cache_hit, timestamp
 		FROM usage_records
 		WHERE user_id = $1 AND timestamp >= $2 AND timestamp <= $3
 		ORDER BY timestamp DESC
@@ -139,7 +140,8 @@ func (r *usageRepository) GetUsageSummary(ctx context.Context, userID int64, sta
 			COALESCE(SUM(total_tokens), 0) as total_tokens,
 			COALESCE(SUM(prompt_tokens), 0) as total_prompt_tokens,
 			COALESCE(SUM(completion_tokens), 0) as total_completion_tokens,
-			COALESCE(AVG(latency_ms), 0) as average_latency_ms
+			COALESCE(AVG(latency_ms), 0) as average_latency_ms,
+			COUNT(*) FILTER (WHERE cache_hit) as cache_hits
 		FROM usage_records
 		WHERE user_id = $1 AND timestamp >= $2 AND timestamp <= $3
 	`
@@ -36,6 +36,9 @@ type UserRepository interface {
 	// UpdatePassword 更新用户密码
 	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
 
+	// UpdateUserRateLimits 更新用户的速率限制，为 nil 的字段清除设置
+	UpdateUserRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error
+
 	// CreateAPIKey 创建 API Key
 	CreateAPIKey(ctx context.Context, key *model.APIKey) error
 
@@ -54,6 +57,9 @@ type UserRepository interface {
 	// UpdateKeyLastUsed 更新 API Key 最后使用时间
 	UpdateKeyLastUsed(ctx context.Context, id int64) error
 
+	// UpdateAPIKeyRateLimits 更新 API Key 的速率限制，为 nil 的字段清除设置
+	UpdateAPIKeyRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error
+
 	// DeleteAPIKey 删除 API Key（硬删除）
 	DeleteAPIKey(ctx context.Context, id int64) error
 }
@@ -91,7 +97,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error
 // GetUserByID 按 ID 查询用户
 func (r *userRepository) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, name, email, role, status, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT id, name, email, role, status, rpm_limit, tpm_limit, created_at, updated_at FROM users WHERE id = $1`
 	err := r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by id: %w", err)
@@ -102,7 +108,7 @@ func (r *userRepository) GetUserByID(ctx context.Context, id int64) (*model.User
 // GetUserByEmail 按 email 查询用户
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, name, email, role, status, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, name, email, role, status, rpm_limit, tpm_limit, created_at, updated_at FROM users WHERE email = $1`
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
@@ -113,7 +119,7 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 // GetUserByEmailWithPassword 按 email 查询用户（包含密码哈希，用于登录验证）
 func (r *userRepository) GetUserByEmailWithPassword(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	query := `SELECT id, name, email, password_hash, role, status, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, name, email, password_hash, role, status, rpm_limit, tpm_limit, created_at, updated_at FROM users WHERE email = $1`
 	err := r.db.GetContext(ctx, &user, query, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email with password: %w", err)
@@ -124,7 +130,7 @@ func (r *userRepository) GetUserByEmailWithPassword(ctx context.Context, email s
 // ListUsers 列出用户
 func (r *userRepository) ListUsers(ctx context.Context, status *string, limit, offset int) ([]*model.User, error) {
 	var users []*model.User
-	query := `SELECT id, name, email, role, status, created_at, updated_at FROM users`
+	query := `SELECT id, name, email, role, status, rpm_limit, tpm_limit, created_at, updated_at FROM users`
 	args := []interface{}{}
 
 	if status != nil {
@@ -201,11 +207,25 @@ func (r *userRepository) UpdatePassword(ctx context.Context, id int64, passwordH
 	return nil
 }
 
+// UpdateUserRateLimits 更新用户的速率限制
+func (r *userRepository) UpdateUserRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	query := `
+		UPDATE users
+		SET rpm_limit = $1, tpm_limit = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, rpmLimit, tpmLimit, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user rate limits: %w", err)
+	}
+	return nil
+}
+
 // CreateAPIKey 创建 API Key
 func (r *userRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
 	query := `
-		INSERT INTO api_keys (user_id, key_hash, key_prefix, name, status, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO api_keys (user_id, key_hash, key_prefix, name, status, rpm_limit, tpm_limit, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at
 	`
 	err := r.db.QueryRowContext(ctx, query,
@@ -214,6 +234,8 @@ func (r *userRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) er
 		key.KeyPrefix,
 		key.Name,
 		key.Status,
+		key.RPMLimit,
+		key.TPMLimit,
 		key.ExpiresAt,
 	).Scan(&key.ID, &key.CreatedAt)
 	if err != nil {
@@ -226,7 +248,7 @@ func (r *userRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) er
 func (r *userRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
 	var key model.APIKey
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, status, last_used_at, expires_at, created_at
+		SELECT id, user_id, key_hash, key_prefix, name, status, rpm_limit, tpm_limit, last_used_at, expires_at, created_at
 		FROM api_keys
 		WHERE key_hash = $1
 	`
@@ -241,7 +263,7 @@ func (r *userRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*
 func (r *userRepository) GetAPIKeyByID(ctx context.Context, id int64) (*model.APIKey, error) {
 	var key model.APIKey
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, status, last_used_at, expires_at, created_at
+		SELECT id, user_id, key_hash, key_prefix, name, status, rpm_limit, tpm_limit, last_used_at, expires_at, created_at
 		FROM api_keys
 		WHERE id = $1
 	`
@@ -256,7 +278,7 @@ func (r *userRepository) GetAPIKeyByID(ctx context.Context, id int64) (*model.AP
 func (r *userRepository) ListAPIKeysByUserID(ctx context.Context, userID int64) ([]*model.APIKey, error) {
 	var keys []*model.APIKey
 	query := `
-		SELECT id, user_id, key_hash, key_prefix, name, status, last_used_at, expires_at, created_at
+		SELECT id, user_id, key_hash, key_prefix, name, status, rpm_limit, tpm_limit, last_used_at, expires_at, created_at
 		FROM api_keys
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -296,6 +318,20 @@ func (r *userRepository) UpdateKeyLastUsed(ctx context.Context, id int64) error
 	return nil
 }
 
+// UpdateAPIKeyRateLimits 更新 API Key 的速率限制
+func (r *userRepository) UpdateAPIKeyRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	query := `
+		UPDATE api_keys
+		SET rpm_limit = $1, tpm_limit = $2
+		WHERE id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, rpmLimit, tpmLimit, id)
+	if err != nil {
+		return fmt.Errorf("failed to update api key rate limits: %w", err)
+	}
+	return nil
+}
+
 // HashAPIKey 生成 API Key 的 SHA256 哈希
 func HashAPIKey(apiKey string) string {
 	hash := sha256.Sum256([]byte(apiKey))
@@ -142,6 +142,8 @@ func (s *AuthService) CreateAPIKey(ctx context.Context, userID int64, req *model
 		KeyPrefix: keyPrefix,
 		Name:      req.Name,
 		Status:    "active",
+		RPMLimit:  req.RPMLimit,
+		TPMLimit:  req.TPMLimit,
 		ExpiresAt: req.ExpiresAt,
 	}
 
@@ -155,6 +157,8 @@ func (s *AuthService) CreateAPIKey(ctx context.Context, userID int64, req *model
 		KeyPrefix: keyPrefix,
 		Name:      req.Name,
 		Status:    "active",
+		RPMLimit:  req.RPMLimit,
+		TPMLimit:  req.TPMLimit,
 		ExpiresAt: req.ExpiresAt,
 		CreatedAt: keyRecord.CreatedAt,
 	}, nil
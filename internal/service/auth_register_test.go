@@ -129,6 +129,20 @@ func (m *MockUserRepository) DeleteAPIKey(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (m *MockUserRepository) UpdateUserRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	user, ok := m.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.RPMLimit = rpmLimit
+	user.TPMLimit = tpmLimit
+	return nil
+}
+
+func (m *MockUserRepository) UpdateAPIKeyRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	return nil
+}
+
 // TestAuthService_Register_Success 测试成功注册新用户
 func TestAuthService_Register_Success(t *testing.T) {
 	mockRepo := NewMockUserRepository()
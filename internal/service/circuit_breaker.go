@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // 正常放行
+	BreakerOpen     BreakerState = "open"      // 熔断，跳过该目标
+	BreakerHalfOpen BreakerState = "half_open" // 半开，只放行有限的探测请求
+)
+
+// BreakerConfig 熔断器配置
+type BreakerConfig struct {
+	Enabled          bool
+	FailureRatio     float64       // 统计窗口内失败率达到该值时熔断
+	MinRequests      int           // 统计窗口内请求数达到该值才计算失败率
+	Window           time.Duration // 统计窗口
+	OpenTimeout      time.Duration // 熔断持续时间，之后进入半开状态
+	HalfOpenRequests int           // 半开状态放行的探测请求数，全部成功后恢复
+	PerModel         bool          // 按 provider/model_name 熔断，默认按 Provider 熔断
+}
+
+// DefaultBreakerConfig 默认熔断器配置
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Enabled:          true,
+		FailureRatio:     0.5,
+		MinRequests:      10,
+		Window:           time.Minute,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 3,
+	}
+}
+
+// BreakerConfigFromEnv 从 CIRCUIT_BREAKER_* 环境变量读取熔断器配置，未设置或非法时使用默认值
+func BreakerConfigFromEnv() BreakerConfig {
+	cfg := DefaultBreakerConfig()
+
+	if v := os.Getenv("CIRCUIT_BREAKER_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Enabled = b
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_FAILURE_RATIO"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1 {
+			cfg.FailureRatio = f
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_MIN_REQUESTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MinRequests = n
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.Window = d
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_OPEN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.OpenTimeout = d
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_HALF_OPEN_REQUESTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.HalfOpenRequests = n
+		}
+	}
+	if v := os.Getenv("CIRCUIT_BREAKER_PER_MODEL"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.PerModel = b
+		}
+	}
+
+	return cfg
+}
+
+// circuitBreaker 单个 Provider（或 provider/model_name）的熔断器
+type circuitBreaker struct {
+	state       BreakerState
+	windowStart time.Time
+	requests    int // 统计窗口内的请求数
+	failures    int // 统计窗口内的失败数
+	openedAt    time.Time
+	probes      int // 半开状态已放行的探测请求数
+	successes   int // 半开状态成功的探测请求数
+}
+
+// BreakerRegistry 熔断器注册表，按 Provider（或 provider/model_name）维护熔断器
+type BreakerRegistry struct {
+	cfg      BreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+	now      func() time.Time
+}
+
+// NewBreakerRegistry 创建熔断器注册表
+func NewBreakerRegistry(cfg BreakerConfig) *BreakerRegistry {
+	return &BreakerRegistry{
+		cfg:      cfg,
+		breakers: make(map[string]*circuitBreaker),
+		now:      time.Now,
+	}
+}
+
+// key 路由目标 provider/model_name 对应的熔断器
+func (r *BreakerRegistry) key(ref string) string {
+	if r.cfg.PerModel {
+		return ref
+	}
+	providerName, _, _ := strings.Cut(ref, "/")
+	return providerName
+}
+
+// breakerOf 获取熔断器，并处理熔断超时后进入半开状态，调用方需持有 r.mu
+func (r *BreakerRegistry) breakerOf(key string) *circuitBreaker {
+	b, ok := r.breakers[key]
+	if !ok {
+		b = &circuitBreaker{state: BreakerClosed, windowStart: r.now()}
+		r.breakers[key] = b
+	}
+	if b.state == BreakerOpen && r.now().Sub(b.openedAt) >= r.cfg.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		b.successes = 0
+	}
+	return b
+}
+
+// Allow 判断是否向路由目标发送请求；半开状态下放行的请求计入探测名额
+// 放行后必须调用 Record 或 Release
+func (r *BreakerRegistry) Allow(ref string) bool {
+	if r == nil || !r.cfg.Enabled {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.breakerOf(r.key(ref))
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.probes >= r.cfg.HalfOpenRequests {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// Record 记录路由目标的请求结果
+func (r *BreakerRegistry) Record(ref string, success bool) {
+	if r == nil || !r.cfg.Enabled {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.breakerOf(r.key(ref))
+	now := r.now()
+	switch b.state {
+	case BreakerHalfOpen:
+		if !success {
+			// 探测失败，重新熔断
+			b.state = BreakerOpen
+			b.openedAt = now
+			return
+		}
+		b.successes++
+		if b.successes >= r.cfg.HalfOpenRequests {
+			*b = circuitBreaker{state: BreakerClosed, windowStart: now}
+		}
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= r.cfg.Window {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+		b.requests++
+		if !success {
+			b.failures++
+		}
+		if b.requests >= r.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= r.cfg.FailureRatio {
+			b.state = BreakerOpen
+			b.openedAt = now
+		}
+	}
+}
+
+// Release 放弃已放行请求的结果（如客户端取消请求），只归还半开状态的探测名额
+func (r *BreakerRegistry) Release(ref string) {
+	if r == nil || !r.cfg.Enabled {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := r.breakerOf(r.key(ref))
+	if b.state == BreakerHalfOpen && b.probes > b.successes {
+		b.probes--
+	}
+}
+
+// ProviderState Provider 的熔断状态
+// 按 Provider 熔断时返回 Provider 的状态；按模型熔断时返回最差的状态，以及所有未关闭的模型熔断器
+func (r *BreakerRegistry) ProviderState(providerName string) (BreakerState, map[string]BreakerState) {
+	if r == nil || !r.cfg.Enabled {
+		return BreakerClosed, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.cfg.PerModel {
+		if _, ok := r.breakers[providerName]; !ok {
+			return BreakerClosed, nil
+		}
+		return r.breakerOf(providerName).state, nil
+	}
+
+	state := BreakerClosed
+	var models map[string]BreakerState
+	for key := range r.breakers {
+		name, modelName, _ := strings.Cut(key, "/")
+		if name != providerName {
+			continue
+		}
+		b := r.breakerOf(key)
+		if b.state == BreakerClosed {
+			continue
+		}
+		if models == nil {
+			models = make(map[string]BreakerState)
+		}
+		models[modelName] = b.state
+		if state != BreakerOpen {
+			state = b.state
+		}
+	}
+	return state, models
+}
+
+// CircuitOpenError 路由目标已熔断
+type CircuitOpenError struct {
+	Ref string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %s", e.Ref)
+}
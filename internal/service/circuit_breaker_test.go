@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// newTestBreakers 创建使用可控时钟的熔断器注册表
+func newTestBreakers(cfg BreakerConfig) (*BreakerRegistry, *time.Time) {
+	now := time.Now()
+	r := NewBreakerRegistry(cfg)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+// TestBreaker_OpenAndHalfOpen 测试失败率达到阈值后熔断，超时后半开探测并恢复
+func TestBreaker_OpenAndHalfOpen(t *testing.T) {
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 4
+	cfg.HalfOpenRequests = 2
+	r, now := newTestBreakers(cfg)
+
+	// 请求数不足时不熔断
+	r.Record("openai/gpt-4o", false)
+	r.Record("openai/gpt-4o", false)
+	r.Record("openai/gpt-4o", false)
+	assert.True(t, r.Allow("openai/gpt-4o"))
+
+	// 失败率达到阈值，按 Provider 熔断
+	state, _ := r.ProviderState("openai")
+	assert.Equal(t, BreakerClosed, state)
+	r.Record("openai/gpt-4o-mini", true)
+	state, _ = r.ProviderState("openai")
+	assert.Equal(t, BreakerOpen, state)
+	assert.False(t, r.Allow("openai/gpt-4o-mini"))
+	assert.True(t, r.Allow("azure/gpt-4o"))
+
+	// 熔断超时后半开，只放行有限的探测请求
+	*now = now.Add(cfg.OpenTimeout)
+	assert.True(t, r.Allow("openai/gpt-4o"))
+	assert.True(t, r.Allow("openai/gpt-4o"))
+	assert.False(t, r.Allow("openai/gpt-4o"))
+	state, _ = r.ProviderState("openai")
+	assert.Equal(t, BreakerHalfOpen, state)
+
+	// 取消的探测归还名额
+	r.Release("openai/gpt-4o")
+	assert.True(t, r.Allow("openai/gpt-4o"))
+
+	// 探测全部成功后恢复
+	r.Record("openai/gpt-4o", true)
+	r.Record("openai/gpt-4o", true)
+	state, _ = r.ProviderState("openai")
+	assert.Equal(t, BreakerClosed, state)
+}
+
+// TestBreaker_HalfOpenFailure 测试半开探测失败后重新熔断
+func TestBreaker_HalfOpenFailure(t *testing.T) {
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 1
+	r, now := newTestBreakers(cfg)
+
+	r.Record("openai/gpt-4o", false)
+	assert.False(t, r.Allow("openai/gpt-4o"))
+
+	*now = now.Add(cfg.OpenTimeout)
+	assert.True(t, r.Allow("openai/gpt-4o"))
+	r.Record("openai/gpt-4o", false)
+	assert.False(t, r.Allow("openai/gpt-4o"))
+}
+
+// TestBreaker_Window 测试统计窗口过期后重新计数
+func TestBreaker_Window(t *testing.T) {
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 2
+	r, now := newTestBreakers(cfg)
+
+	r.Record("openai/gpt-4o", false)
+	*now = now.Add(cfg.Window)
+	r.Record("openai/gpt-4o", true)
+	r.Record("openai/gpt-4o", true)
+	assert.True(t, r.Allow("openai/gpt-4o"))
+}
+
+// TestBreaker_PerModel 测试按模型熔断
+func TestBreaker_PerModel(t *testing.T) {
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 1
+	cfg.PerModel = true
+	r, _ := newTestBreakers(cfg)
+
+	r.Record("vllm/meta-llama/Llama-3-8B", false)
+	assert.False(t, r.Allow("vllm/meta-llama/Llama-3-8B"))
+	assert.True(t, r.Allow("vllm/qwen"))
+
+	state, models := r.ProviderState("vllm")
+	assert.Equal(t, BreakerOpen, state)
+	assert.Equal(t, map[string]BreakerState{"meta-llama/Llama-3-8B": BreakerOpen}, models)
+}
+
+// TestRetryWithFallback_SkipsOpenBreaker 测试 Fallback 跳过已熔断的 Provider，且只有可重试错误计为失败
+func TestRetryWithFallback_SkipsOpenBreaker(t *testing.T) {
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 1
+	breakers, _ := newTestBreakers(cfg)
+	svc := NewRetryServiceWithBreakers(breakers)
+	ctx := context.Background()
+
+	// 4xx 错误不计为失败
+	_, err := svc.RetryWithFallback(ctx, []string{"openai/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		return nil, errors.New("HTTP 400: bad request")
+	})
+	require.Error(t, err)
+	assert.True(t, breakers.Allow("openai/gpt-4o"))
+
+	// 5xx 错误触发熔断
+	_, err = svc.RetryWithFallback(ctx, []string{"openai/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		return nil, &adapter.UpstreamError{StatusCode: 503, Message: "service unavailable"}
+	})
+	require.Error(t, err)
+
+	var called []string
+	result, err := svc.RetryWithFallback(ctx, []string{"openai/gpt-4o", "azure/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		called = append(called, ref)
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"azure/gpt-4o"}, called)
+	assert.Equal(t, 1, result.FallbackCount)
+	assert.Equal(t, "azure", result.FinalProviderName)
+	assert.Equal(t, "circuit_open", result.AttemptDetails[0].ErrorType)
+}
+
+// TestRetryWithFallback_BreakerPerAttempt 测试同一模型重试前也检查熔断器，半开状态只放行 HalfOpenRequests 个探测请求
+func TestRetryWithFallback_BreakerPerAttempt(t *testing.T) {
+	registerTestProviders(t, &fakeProvider{name: "openai", config: map[string]any{
+		"retry_policy": map[string]any{
+			"max_attempts":    float64(3),
+			"base_backoff_ms": float64(0),
+		},
+	}})
+
+	cfg := DefaultBreakerConfig()
+	cfg.MinRequests = 1
+	cfg.HalfOpenRequests = 1
+	breakers, now := newTestBreakers(cfg)
+	svc := NewRetryServiceWithBreakers(breakers)
+	ctx := context.Background()
+
+	// 第一次 5xx 后熔断，不再在同一模型上重试
+	var called []string
+	result, err := svc.RetryWithFallback(ctx, []string{"openai/gpt-4o", "azure/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		called = append(called, ref)
+		if ref == "openai/gpt-4o" {
+			return nil, &adapter.UpstreamError{StatusCode: 503, Message: "service unavailable"}
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai/gpt-4o", "azure/gpt-4o"}, called)
+	require.Len(t, result.AttemptDetails, 3)
+	assert.Equal(t, "server_error", result.AttemptDetails[0].ErrorType)
+	assert.Equal(t, 2, result.AttemptDetails[1].Attempt)
+	assert.Equal(t, "circuit_open", result.AttemptDetails[1].ErrorType)
+
+	// 半开状态只放行一个探测请求，探测失败后重新熔断
+	*now = now.Add(cfg.OpenTimeout)
+	called = nil
+	_, err = svc.RetryWithFallback(ctx, []string{"openai/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		called = append(called, ref)
+		return nil, &adapter.UpstreamError{StatusCode: 503, Message: "service unavailable"}
+	})
+	var circuitErr *CircuitOpenError
+	require.ErrorAs(t, err, &circuitErr)
+	assert.Equal(t, []string{"openai/gpt-4o"}, called)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+)
+
+const (
+	// healthCheckTick 健康检查调度间隔，各 Provider 按自己的 interval_seconds 到期后探测
+	healthCheckTick = 5 * time.Second
+
+	HealthCheckMethodModels = "models" // 查询上游模型列表
+	HealthCheckMethodChat   = "chat"   // 发送 max_tokens=1 的对话请求
+)
+
+// HealthStatus Provider 健康状态
+type HealthStatus string
+
+const (
+	HealthStatusUnknown   HealthStatus = "unknown"   // 尚未探测或未配置探测方式
+	HealthStatusHealthy   HealthStatus = "healthy"   // 最近一次探测成功
+	HealthStatusUnhealthy HealthStatus = "unhealthy" // 连续失败次数达到阈值
+)
+
+// HealthCheckConfig Provider 健康检查配置，来自 extra_config.health_check
+type HealthCheckConfig struct {
+	Enabled            bool          // models 探测默认启用；chat 探测会产生上游费用，需要显式启用
+	Method             string        // models 或 chat，默认支持模型列表的 Provider 使用 models，其他使用 chat
+	Model              string        // chat 探测使用的模型，默认使用第一个同 Provider 的 Fallback 模型
+	Interval           time.Duration // 探测间隔，默认 60 秒
+	Timeout            time.Duration // 单次探测超时，默认 10 秒
+	UnhealthyThreshold int           // 连续失败多少次后标记为不健康，默认 2
+}
+
+// HealthCheckConfigOf 解析 Provider 的健康检查配置
+func HealthCheckConfigOf(provider adapter.Provider) HealthCheckConfig {
+	cfg := HealthCheckConfig{
+		Method:             HealthCheckMethodChat,
+		Interval:           60 * time.Second,
+		Timeout:            10 * time.Second,
+		UnhealthyThreshold: 2,
+	}
+	if _, ok := provider.(adapter.ModelLister); ok {
+		cfg.Method = HealthCheckMethodModels
+	}
+
+	raw, _ := provider.Config()["health_check"].(map[string]any)
+	if method, ok := raw["method"].(string); ok && method != "" {
+		cfg.Method = method
+	}
+	// chat 探测是计费的对话请求，且不记录使用量，未设置 enabled 时不启用
+	cfg.Enabled = cfg.Method != HealthCheckMethodChat
+	if enabled, ok := raw["enabled"].(bool); ok {
+		cfg.Enabled = enabled
+	}
+	if m, ok := raw["model"].(string); ok {
+		cfg.Model = m
+	}
+	if v, ok := raw["interval_seconds"].(float64); ok && v > 0 {
+		cfg.Interval = time.Duration(v * float64(time.Second))
+	}
+	if v, ok := raw["timeout_seconds"].(float64); ok && v > 0 {
+		cfg.Timeout = time.Duration(v * float64(time.Second))
+	}
+	if v, ok := raw["unhealthy_threshold"].(float64); ok && v >= 1 {
+		cfg.UnhealthyThreshold = int(v)
+	}
+
+	if cfg.Method == HealthCheckMethodChat && cfg.Model == "" {
+		fallbackModels, _ := provider.Config()["fallback_models"].([]string)
+		for _, m := range fallbackModels {
+			if info, err := ParseFallbackModel(m, provider.Name()); err == nil && info.ProviderName == provider.Name() {
+				cfg.Model = info.ModelName
+				break
+			}
+		}
+	}
+
+	return cfg
+}
+
+// ProviderHealth Provider 最近一次健康检查结果
+type ProviderHealth struct {
+	Provider            string       `json:"provider"`
+	Status              HealthStatus `json:"status"`
+	Method              string       `json:"method,omitempty"`
+	LatencyMs           int64        `json:"latency_ms"`
+	Error               string       `json:"error,omitempty"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	CheckedAt           *time.Time   `json:"checked_at,omitempty"`
+}
+
+// healthEntry 健康检查内部状态
+type healthEntry struct {
+	provider adapter.Provider // Provider 重载后实例变化，结果失效
+	health   ProviderHealth
+	nextAt   time.Time
+}
+
+// HealthChecker Provider 主动健康检查
+// 后台定期探测每个已注册的 Provider，记录最近一次的状态、延迟和错误，供路由跳过不健康的 Provider
+type HealthChecker struct {
+	mu      sync.RWMutex
+	entries map[string]*healthEntry // provider name -> 检查结果
+}
+
+// NewHealthChecker 创建健康检查器
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{entries: make(map[string]*healthEntry)}
+}
+
+// Start 启动后台健康检查，ctx 取消时停止
+func (h *HealthChecker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(healthCheckTick)
+		defer ticker.Stop()
+
+		h.checkDue(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.checkDue(ctx)
+			}
+		}
+	}()
+}
+
+// checkDue 并发探测所有到期的 Provider
+func (h *HealthChecker) checkDue(ctx context.Context) {
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for _, provider := range adapter.ListProviders() {
+		h.mu.RLock()
+		entry, ok := h.entries[provider.Name()]
+		due := !ok || entry.provider != provider || !now.Before(entry.nextAt)
+		h.mu.RUnlock()
+		if !due {
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider adapter.Provider) {
+			defer wg.Done()
+			h.Check(ctx, provider)
+		}(provider)
+	}
+	wg.Wait()
+}
+
+// Check 立即探测 Provider 并记录结果
+func (h *HealthChecker) Check(ctx context.Context, provider adapter.Provider) ProviderHealth {
+	cfg := HealthCheckConfigOf(provider)
+	name := provider.Name()
+
+	if !cfg.Enabled {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		entry := &healthEntry{
+			provider: provider,
+			health:   ProviderHealth{Provider: name, Status: HealthStatusUnknown},
+			nextAt:   time.Now().Add(cfg.Interval),
+		}
+		h.entries[name] = entry
+		return entry.health
+	}
+
+	start := time.Now()
+	err := probe(ctx, provider, cfg)
+	latency := time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		// 服务关闭时不记录结果
+		return h.Health(name)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.entries[name]
+	if !ok || entry.provider != provider {
+		entry = &healthEntry{provider: provider, health: ProviderHealth{Provider: name, Status: HealthStatusUnknown}}
+		h.entries[name] = entry
+	}
+
+	checkedAt := time.Now()
+	entry.nextAt = checkedAt.Add(cfg.Interval)
+	entry.health.Method = cfg.Method
+	entry.health.LatencyMs = latency.Milliseconds()
+	entry.health.CheckedAt = &checkedAt
+
+	var configErr *healthCheckConfigError
+	switch {
+	case err == nil:
+		entry.health.Status = HealthStatusHealthy
+		entry.health.Error = ""
+		entry.health.ConsecutiveFailures = 0
+	case errors.As(err, &configErr), !isProbeFailure(err):
+		// 无法探测或探测被上游拒绝时状态未知，不影响路由
+		entry.health.Status = HealthStatusUnknown
+		entry.health.Error = err.Error()
+		entry.health.ConsecutiveFailures = 0
+	default:
+		entry.health.Error = err.Error()
+		entry.health.ConsecutiveFailures++
+		if entry.health.ConsecutiveFailures >= cfg.UnhealthyThreshold {
+			if entry.health.Status != HealthStatusUnhealthy {
+				logger.L.Warn("Provider marked unhealthy",
+					zap.String("provider_name", name),
+					zap.Int("consecutive_failures", entry.health.ConsecutiveFailures),
+					zap.Error(err))
+			}
+			entry.health.Status = HealthStatusUnhealthy
+		}
+	}
+
+	return entry.health
+}
+
+// probe 按配置的方式探测 Provider
+func probe(ctx context.Context, provider adapter.Provider, cfg HealthCheckConfig) error {
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	switch cfg.Method {
+	case HealthCheckMethodModels:
+		lister, ok := provider.(adapter.ModelLister)
+		if !ok {
+			return &healthCheckConfigError{reason: fmt.Sprintf("provider type %s does not support listing models", provider.Type())}
+		}
+		_, err := lister.ListModels(probeCtx)
+		return err
+	case HealthCheckMethodChat:
+		if cfg.Model == "" {
+			return &healthCheckConfigError{reason: "health_check.model is required for chat health checks"}
+		}
+		maxTokens := 1
+		_, err := provider.Chat(probeCtx, &adapter.ChatRequest{
+			Model:     cfg.Model,
+			Messages:  []adapter.Message{{Role: "user", Content: "ping"}},
+			MaxTokens: &maxTokens,
+		})
+		return err
+	default:
+		return &healthCheckConfigError{reason: fmt.Sprintf("unsupported health check method %q", cfg.Method)}
+	}
+}
+
+// Health 获取 Provider 最近一次健康检查结果
+func (h *HealthChecker) Health(name string) ProviderHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entry, ok := h.entries[name]
+	if !ok {
+		return ProviderHealth{Provider: name, Status: HealthStatusUnknown}
+	}
+	if current, ok := adapter.GetProvider(name); !ok || current != entry.provider {
+		// Provider 已注销或重载，等待重新探测
+		return ProviderHealth{Provider: name, Status: HealthStatusUnknown}
+	}
+	return entry.health
+}
+
+// Results 获取所有已注册 Provider 的健康检查结果，按名称排序
+func (h *HealthChecker) Results() []ProviderHealth {
+	providers := adapter.ListProviders()
+	results := make([]ProviderHealth, 0, len(providers))
+	for _, provider := range providers {
+		results = append(results, h.Health(provider.Name()))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Provider < results[j].Provider
+	})
+	return results
+}
+
+// IsHealthy Provider 是否可用于路由，未探测或状态未知时视为健康
+func (h *HealthChecker) IsHealthy(name string) bool {
+	if h == nil {
+		return true
+	}
+	return h.Health(name).Status != HealthStatusUnhealthy
+}
+
+// isProbeFailure 探测错误是否说明 Provider 不可用
+// 连接错误、超时和 5xx 计入连续失败；其他 4xx（429 限流、401 鉴权失败、探测模型不存在等）说明上游可以访问，
+// 只是本次探测被拒绝，不计入
+func isProbeFailure(err error) bool {
+	var upstreamErr *adapter.UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return upstreamErr.StatusCode >= http.StatusInternalServerError || upstreamErr.StatusCode == http.StatusRequestTimeout
+	}
+	return true
+}
+
+// healthCheckConfigError 健康检查配置无法执行探测
+type healthCheckConfigError struct {
+	reason string
+}
+
+func (e *healthCheckConfigError) Error() string {
+	return e.reason
+}
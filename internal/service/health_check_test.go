@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+)
+
+// TestHealthChecker_ListModels 测试模型列表探测，连续失败达到阈值后标记为不健康
+func TestHealthChecker_ListModels(t *testing.T) {
+	logger.L = zap.NewNop()
+	p := &fakeListerProvider{fakeProvider{name: "vllm-local", typ: "vllm", listErr: errors.New("connection refused")}}
+	registerTestProviders(t, p)
+	h := NewHealthChecker()
+	ctx := context.Background()
+
+	health := h.Check(ctx, p)
+	assert.Equal(t, HealthStatusUnknown, health.Status)
+	assert.Equal(t, HealthCheckMethodModels, health.Method)
+	assert.Equal(t, 1, health.ConsecutiveFailures)
+	assert.True(t, h.IsHealthy("vllm-local"))
+
+	health = h.Check(ctx, p)
+	assert.Equal(t, HealthStatusUnhealthy, health.Status)
+	assert.Equal(t, "connection refused", health.Error)
+	assert.False(t, h.IsHealthy("vllm-local"))
+
+	// 恢复后一次成功即标记为健康
+	p.listErr = nil
+	health = h.Check(ctx, p)
+	assert.Equal(t, HealthStatusHealthy, health.Status)
+	assert.Empty(t, health.Error)
+	assert.Equal(t, []ProviderHealth{health}, h.Results())
+
+	// Provider 重载后等待重新探测
+	registerTestProviders(t, &fakeListerProvider{fakeProvider{name: "vllm-local", typ: "vllm"}})
+	assert.Equal(t, HealthStatusUnknown, h.Health("vllm-local").Status)
+}
+
+// TestHealthChecker_Chat 测试对话探测及探测模型的默认值
+func TestHealthChecker_Chat(t *testing.T) {
+	logger.L = zap.NewNop()
+	ctx := context.Background()
+	h := NewHealthChecker()
+
+	configured := &fakeProvider{name: "claude", typ: "anthropic", config: map[string]any{
+		"health_check": map[string]any{"enabled": true, "model": "claude-3-haiku", "unhealthy_threshold": float64(1)},
+	}}
+	fallback := &fakeProvider{name: "azure", typ: "azure", config: map[string]any{
+		"health_check":    map[string]any{"enabled": true},
+		"fallback_models": []string{"claude/claude-3-opus", "gpt-4o-mini"},
+	}}
+	unconfigured := &fakeProvider{name: "bedrock", typ: "bedrock", config: map[string]any{
+		"health_check": map[string]any{"enabled": true},
+	}}
+	disabled := &fakeProvider{name: "gemini", typ: "gemini", config: map[string]any{
+		"health_check": map[string]any{"enabled": false, "model": "gemini-pro"},
+	}}
+	registerTestProviders(t, configured, fallback, unconfigured, disabled)
+
+	// chat 探测会产生上游费用，未显式启用时不探测
+	optIn := &fakeProvider{name: "vertex", typ: "gemini", config: map[string]any{
+		"fallback_models": []string{"gemini-pro"},
+	}}
+	assert.False(t, HealthCheckConfigOf(optIn).Enabled)
+	optIn.chatErr = errors.New("should not be called")
+	health := h.Check(ctx, optIn)
+	assert.Equal(t, HealthStatusUnknown, health.Status)
+	assert.Empty(t, health.Error)
+
+	assert.Equal(t, "claude-3-haiku", HealthCheckConfigOf(configured).Model)
+	assert.Equal(t, "gpt-4o-mini", HealthCheckConfigOf(fallback).Model)
+
+	configured.chatErr = errors.New("HTTP 503: overloaded")
+	assert.Equal(t, HealthStatusUnhealthy, h.Check(ctx, configured).Status)
+	assert.Equal(t, HealthStatusHealthy, h.Check(ctx, fallback).Status)
+
+	// 无法探测时状态未知，不影响路由
+	health = h.Check(ctx, unconfigured)
+	assert.Equal(t, HealthStatusUnknown, health.Status)
+	assert.NotEmpty(t, health.Error)
+	assert.True(t, h.IsHealthy("bedrock"))
+	assert.Equal(t, HealthStatusUnknown, h.Check(ctx, disabled).Status)
+}
+
+// TestHealthChecker_UpstreamErrors 测试只有连接错误、超时和 5xx 计入连续失败
+func TestHealthChecker_UpstreamErrors(t *testing.T) {
+	logger.L = zap.NewNop()
+	p := &fakeListerProvider{fakeProvider{name: "openai", typ: "openai", config: map[string]any{
+		"health_check": map[string]any{"unhealthy_threshold": float64(1)},
+	}}}
+	registerTestProviders(t, p)
+	h := NewHealthChecker()
+	ctx := context.Background()
+
+	tests := []struct {
+		err      error
+		expected HealthStatus
+	}{
+		{&adapter.UpstreamError{StatusCode: 429, Message: "rate limited"}, HealthStatusUnknown},
+		{&adapter.UpstreamError{StatusCode: 401, Message: "invalid api key"}, HealthStatusUnknown},
+		{&adapter.UpstreamError{StatusCode: 404, Message: "model not found"}, HealthStatusUnknown},
+		{&adapter.UpstreamError{StatusCode: 408, Message: "request timeout"}, HealthStatusUnhealthy},
+		{&adapter.UpstreamError{StatusCode: 503, Message: "overloaded"}, HealthStatusUnhealthy},
+		{context.DeadlineExceeded, HealthStatusUnhealthy},
+	}
+	for _, tt := range tests {
+		p.listErr = tt.err
+		health := h.Check(ctx, p)
+		assert.Equal(t, tt.expected, health.Status, tt.err.Error())
+		assert.Equal(t, tt.err.Error(), health.Error)
+		assert.Equal(t, tt.expected != HealthStatusUnhealthy, h.IsHealthy("openai"), tt.err.Error())
+	}
+}
+
+// TestSkipUnhealthy 测试路由跳过不健康的 Provider
+func TestSkipUnhealthy(t *testing.T) {
+	logger.L = zap.NewNop()
+	bad := &fakeListerProvider{fakeProvider{name: "openai", typ: "openai", listErr: errors.New("timeout")}}
+	good := &fakeListerProvider{fakeProvider{name: "azure", typ: "azure"}}
+	registerTestProviders(t, bad, good)
+
+	svc := NewRouterService()
+	refs := []string{"openai/gpt-4o", "azure/gpt-4o", "openai/gpt-4o-mini"}
+	assert.Equal(t, refs, svc.SkipUnhealthy(refs))
+
+	h := NewHealthChecker()
+	svc.SetHealthChecker(h)
+	h.Check(context.Background(), bad)
+	h.Check(context.Background(), bad)
+	h.Check(context.Background(), good)
+	assert.Equal(t, []string{"azure/gpt-4o"}, svc.SkipUnhealthy(refs))
+
+	// 全部不健康时仍按原顺序尝试
+	only := []string{"openai/gpt-4o", "openai/gpt-4o-mini"}
+	assert.Equal(t, only, svc.SkipUnhealthy(only))
+}
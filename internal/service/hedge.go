@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// HedgeDelayOf 解析 Provider 的对冲延迟（extra_config.hedge.delay_ms），未配置时为 0，即不对冲
+func HedgeDelayOf(provider adapter.Provider) time.Duration {
+	raw, _ := provider.Config()["hedge"].(map[string]any)
+	if v, ok := raw["delay_ms"].(float64); ok && v > 0 {
+		return time.Duration(v) * time.Millisecond
+	}
+	return 0
+}
+
+// HedgeDelay 请求的对冲延迟
+// 通过别名请求且别名设置了 hedge_delay_ms 时以别名为准，否则使用首选 Provider 的配置
+func (m *ModelInfo) HedgeDelay() time.Duration {
+	if m.aliasHedgeDelay > 0 {
+		return m.aliasHedgeDelay
+	}
+	if m.Provider == nil {
+		return 0
+	}
+	return HedgeDelayOf(m.Provider)
+}
+
+// hedgeAttempt 在途的尝试
+type hedgeAttempt struct {
+	ref           string
+	fallbackIndex int // 在 Fallback 列表中的位置
+	start         time.Time
+	cancel        context.CancelFunc
+}
+
+// hedgeOutcome 尝试的结果，index 为在 AttemptDetails 中的位置
+type hedgeOutcome struct {
+	index int
+	resp  any
+	err   error
+}
+
+// HedgedRetryWithFallback 带对冲请求的 Fallback
+// 主请求超过 delay 仍未返回（流式请求为未收到第一个数据块）时，向下一个 Fallback 模型并发发出对冲请求，
+// 使用先成功的响应并取消另一个；同一时间最多两个请求在途，任一失败时由下一个 Fallback 模型补上。
+// 对冲模式下不在同一模型上重试，跳过已熔断的目标的方式与 RetryWithFallback 相同
+func (s *RetryService) HedgedRetryWithFallback(
+	ctx context.Context,
+	fallbackModels []string,
+	delay time.Duration,
+	retryableFunc RetryableFunc,
+) (*RetryResult, error) {
+	startTime := time.Now()
+	result := &RetryResult{
+		AttemptDetails: make([]AttemptDetail, 0, len(fallbackModels)),
+	}
+
+	if len(fallbackModels) == 0 {
+		return nil, errors.New("no fallback models provided")
+	}
+
+	// 结果 channel 能容纳全部尝试，被取消的尝试返回时不会阻塞
+	outcomes := make(chan hedgeOutcome, len(fallbackModels))
+	inFlight := make(map[int]*hedgeAttempt)
+	next := 0
+	var lastErr error
+
+	// launch 向下一个未熔断的模型发出请求，没有可用模型时返回 false
+	launch := func(hedge bool) bool {
+		for next < len(fallbackModels) {
+			fallbackIndex, modelName := next, fallbackModels[next]
+			next++
+
+			providerName, name, hasProvider := strings.Cut(modelName, "/")
+			if !hasProvider {
+				providerName, name = "", modelName
+			}
+
+			breakerRef := hasProvider && s.breakers != nil
+			if breakerRef && !s.breakers.Allow(modelName) {
+				lastErr = &CircuitOpenError{Ref: modelName}
+				result.AttemptDetails = append(result.AttemptDetails, AttemptDetail{
+					ProviderName: providerName,
+					ModelName:    name,
+					Attempt:      1,
+					Error:        lastErr,
+					ErrorType:    "circuit_open",
+				})
+				log.Printf("[WARN] Circuit breaker open for %s, trying next fallback model", modelName)
+				continue
+			}
+
+			attemptCtx, cancel := context.WithCancel(ctx)
+			index := len(result.AttemptDetails)
+			result.AttemptDetails = append(result.AttemptDetails, AttemptDetail{
+				ProviderName: providerName,
+				ModelName:    name,
+				Attempt:      1,
+				Hedge:        hedge,
+			})
+			inFlight[index] = &hedgeAttempt{
+				ref:           modelName,
+				fallbackIndex: fallbackIndex,
+				start:         time.Now(),
+				cancel:        cancel,
+			}
+			if hedge {
+				result.Hedged = true
+			}
+
+			go func() {
+				resp, err := retryableFunc(attemptCtx, modelName)
+				if breakerRef {
+					s.recordBreaker(attemptCtx, modelName, err)
+				}
+				outcomes <- hedgeOutcome{index: index, resp: resp, err: err}
+			}()
+			return true
+		}
+		return false
+	}
+
+	// cancelInFlight 取消其余在途的尝试，记为 hedge_cancelled
+	cancelInFlight := func() {
+		for index, attempt := range inFlight {
+			attempt.cancel()
+			detail := &result.AttemptDetails[index]
+			detail.Duration = time.Since(attempt.start)
+			detail.Error = context.Canceled
+			detail.ErrorType = "hedge_cancelled"
+		}
+		clear(inFlight)
+	}
+
+	launch(false)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for len(inFlight) > 0 {
+		select {
+		case <-timer.C:
+			// 主请求超过对冲延迟仍未返回，发出对冲请求
+			if len(inFlight) == 1 && launch(true) {
+				log.Printf("[INFO] No response within %s, sending hedged request to %s", delay, fallbackModels[next-1])
+			}
+
+		case out := <-outcomes:
+			attempt := inFlight[out.index]
+			delete(inFlight, out.index)
+			detail := &result.AttemptDetails[out.index]
+			detail.Duration = time.Since(attempt.start)
+
+			if out.err == nil {
+				cancelInFlight()
+				result.Success = true
+				result.FallbackCount = attempt.fallbackIndex
+				result.FinalModelName = detail.ModelName
+				result.FinalProviderName = detail.ProviderName
+				result.Response = out.resp
+				result.TotalDuration = time.Since(startTime)
+				return result, nil
+			}
+
+			attempt.cancel()
+			lastErr = out.err
+			detail.Error = out.err
+			detail.ErrorType = classifyError(out.err)
+
+			if !s.IsRetryableError(out.err) {
+				cancelInFlight()
+				result.TotalDuration = time.Since(startTime)
+				return result, fmt.Errorf("non-retryable error with model %s: %w", attempt.ref, out.err)
+			}
+
+			log.Printf("[WARN] Model %s failed (%s), trying next fallback model", attempt.ref, out.err)
+			if len(inFlight) == 0 {
+				// 没有在途请求，下一个模型作为新的主请求重新计算对冲延迟
+				if launch(false) {
+					timer.Reset(delay)
+				}
+			} else {
+				launch(true)
+			}
+		}
+	}
+
+	// 所有模型都失败
+	result.TotalDuration = time.Since(startTime)
+	return result, fmt.Errorf("all models failed after %d attempts: %w", len(result.AttemptDetails), lastErr)
+}
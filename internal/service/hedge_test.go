@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestHedgedRetryWithFallback_HedgeWins 测试主请求超过对冲延迟后发出对冲请求，先返回的胜出并取消另一个
+func TestHedgedRetryWithFallback_HedgeWins(t *testing.T) {
+	svc := NewRetryService()
+
+	cancelled := make(chan struct{})
+	result, err := svc.HedgedRetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, 10*time.Millisecond,
+		func(ctx context.Context, ref string) (any, error) {
+			if ref == "openai/gpt-4o" {
+				<-ctx.Done()
+				close(cancelled)
+				return nil, ctx.Err()
+			}
+			return "azure", nil
+		})
+	require.NoError(t, err)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("primary request was not cancelled")
+	}
+
+	assert.True(t, result.Hedged)
+	assert.Equal(t, "azure", result.Response)
+	assert.Equal(t, 1, result.FallbackCount)
+	assert.Equal(t, "azure", result.FinalProviderName)
+	require.Len(t, result.AttemptDetails, 2)
+	assert.Equal(t, "hedge_cancelled", result.AttemptDetails[0].ErrorType)
+	assert.False(t, result.AttemptDetails[0].Hedge)
+	assert.True(t, result.AttemptDetails[1].Hedge)
+	assert.Empty(t, result.AttemptDetails[1].ErrorType)
+}
+
+// TestHedgedRetryWithFallback_PrimaryWins 测试主请求在对冲延迟内返回时不发出对冲请求
+func TestHedgedRetryWithFallback_PrimaryWins(t *testing.T) {
+	svc := NewRetryService()
+
+	var mu sync.Mutex
+	var calls []string
+	result, err := svc.HedgedRetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, time.Second,
+		func(ctx context.Context, ref string) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, ref)
+			return ref, nil
+		})
+	require.NoError(t, err)
+	assert.False(t, result.Hedged)
+	assert.Equal(t, []string{"openai/gpt-4o"}, calls)
+	assert.Len(t, result.AttemptDetails, 1)
+}
+
+// TestHedgedRetryWithFallback_Failures 测试失败的尝试由下一个 Fallback 模型补上，全部失败时返回最后的错误
+func TestHedgedRetryWithFallback_Failures(t *testing.T) {
+	svc := NewRetryService()
+
+	result, err := svc.HedgedRetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o", "vllm/qwen"}, time.Second,
+		func(ctx context.Context, ref string) (any, error) {
+			if ref == "vllm/qwen" {
+				return ref, nil
+			}
+			return nil, &adapter.UpstreamError{StatusCode: 503}
+		})
+	require.NoError(t, err)
+	assert.False(t, result.Hedged)
+	assert.Equal(t, 2, result.FallbackCount)
+	require.Len(t, result.AttemptDetails, 3)
+	assert.Equal(t, "server_error", result.AttemptDetails[0].ErrorType)
+
+	// 不可重试的错误直接返回
+	_, err = svc.HedgedRetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, time.Second,
+		func(ctx context.Context, ref string) (any, error) {
+			return nil, &adapter.UpstreamError{StatusCode: 400}
+		})
+	require.ErrorContains(t, err, "non-retryable error with model openai/gpt-4o")
+}
+
+// TestModelInfo_HedgeDelay 测试别名的对冲延迟优先于 Provider 配置
+func TestModelInfo_HedgeDelay(t *testing.T) {
+	registerTestProviders(t, &fakeProvider{name: "openai", config: map[string]any{
+		"hedge": map[string]any{"delay_ms": float64(500)},
+	}})
+	router := NewRouterService()
+
+	info, err := router.ResolveModel("openai/gpt-4o")
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, info.HedgeDelay())
+
+	router.SetModelAliases([]*model.ModelAlias{
+		{Name: "fast", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}}, HedgeDelayMs: 200},
+		{Name: "default", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}}},
+	})
+	info, err = router.ResolveModel("fast")
+	require.NoError(t, err)
+	assert.Equal(t, 200*time.Millisecond, info.HedgeDelay())
+
+	info, err = router.ResolveModel("default")
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, info.HedgeDelay())
+}
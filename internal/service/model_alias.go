@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+// ModelAliasService 模型别名管理服务
+// 别名保存在数据库中，变更后同步到 RouterService 的内存路由表
+type ModelAliasService struct {
+	repo         repository.ModelAliasRepository
+	providerRepo repository.ProviderRepository
+	router       *RouterService
+}
+
+// NewModelAliasService 创建 ModelAlias Service
+func NewModelAliasService(repo repository.ModelAliasRepository, providerRepo repository.ProviderRepository, router *RouterService) *ModelAliasService {
+	return &ModelAliasService{
+		repo:         repo,
+		providerRepo: providerRepo,
+		router:       router,
+	}
+}
+
+// ModelAliasUpdate 别名更新内容，nil 字段不更新
+type ModelAliasUpdate struct {
+	Targets       model.AliasTargets
+	Strategy      *string
+	HedgeDelayMs  *int
+	SemanticCache *bool
+	Description   *string
+}
+
+// CreateAlias 创建别名
+func (s *ModelAliasService) CreateAlias(ctx context.Context, alias *model.ModelAlias) error {
+	if alias.Strategy == "" {
+		alias.Strategy = model.RouteStrategyPriority
+	}
+	if err := s.validate(ctx, alias); err != nil {
+		return err
+	}
+
+	exists, err := s.repo.ExistsByName(ctx, alias.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check model alias name: %w", err)
+	}
+	if exists {
+		return &ModelAliasExistsError{Name: alias.Name}
+	}
+
+	if err := s.repo.Create(ctx, alias); err != nil {
+		return fmt.Errorf("failed to create model alias: %w", err)
+	}
+
+	return s.LoadAliases(ctx)
+}
+
+// GetAlias 获取别名
+func (s *ModelAliasService) GetAlias(ctx context.Context, name string) (*model.ModelAlias, error) {
+	alias, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, &ModelAliasNotFoundError{Name: name}
+	}
+	return alias, nil
+}
+
+// ListAliases 列出所有别名
+func (s *ModelAliasService) ListAliases(ctx context.Context) ([]*model.ModelAlias, error) {
+	return s.repo.List(ctx)
+}
+
+// UpdateAlias 更新别名的目标列表、路由策略、对冲延迟、语义缓存开关和说明
+func (s *ModelAliasService) UpdateAlias(ctx context.Context, name string, update *ModelAliasUpdate) (*model.ModelAlias, error) {
+	alias, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return nil, &ModelAliasNotFoundError{Name: name}
+	}
+
+	if update.Targets != nil {
+		alias.Targets = update.Targets
+	}
+	if update.Strategy != nil {
+		alias.Strategy = *update.Strategy
+	}
+	if update.HedgeDelayMs != nil {
+		alias.HedgeDelayMs = *update.HedgeDelayMs
+	}
+	if update.SemanticCache != nil {
+		alias.SemanticCache = *update.SemanticCache
+	}
+	if update.Description != nil {
+		alias.Description = *update.Description
+	}
+
+	if err := s.validate(ctx, alias); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, alias); err != nil {
+		return nil, fmt.Errorf("failed to update model alias: %w", err)
+	}
+
+	return alias, s.LoadAliases(ctx)
+}
+
+// DeleteAlias 删除别名
+func (s *ModelAliasService) DeleteAlias(ctx context.Context, name string) error {
+	alias, err := s.repo.GetByName(ctx, name)
+	if err != nil {
+		return &ModelAliasNotFoundError{Name: name}
+	}
+
+	if err := s.repo.Delete(ctx, alias.ID); err != nil {
+		return fmt.Errorf("failed to delete model alias: %w", err)
+	}
+
+	return s.LoadAliases(ctx)
+}
+
+// LoadAliases 从数据库加载全部别名到路由表
+func (s *ModelAliasService) LoadAliases(ctx context.Context) error {
+	aliases, err := s.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list model aliases: %w", err)
+	}
+
+	s.router.SetModelAliases(aliases)
+
+	log.Printf("Loaded %d model aliases", len(aliases))
+	return nil
+}
+
+// validate 校验别名名称、路由策略、对冲延迟和目标
+// 别名不能包含 "/"，以免与 provider/model_name 冲突；目标引用的 Provider 必须存在
+func (s *ModelAliasService) validate(ctx context.Context, alias *model.ModelAlias) error {
+	if alias.Name == "" || strings.Contains(alias.Name, "/") {
+		return &InvalidModelAliasError{Name: alias.Name, Reason: `name must be non-empty and must not contain "/"`}
+	}
+	if len(alias.Targets) == 0 {
+		return &InvalidModelAliasError{Name: alias.Name, Reason: "at least one target is required"}
+	}
+
+	switch alias.Strategy {
+	case model.RouteStrategyPriority, model.RouteStrategyWeighted, model.RouteStrategyRoundRobin,
+		model.RouteStrategyLeastInFlight, model.RouteStrategyLowestLatency:
+	default:
+		return &InvalidModelAliasError{Name: alias.Name, Reason: fmt.Sprintf("unsupported strategy %q", alias.Strategy)}
+	}
+	if alias.HedgeDelayMs < 0 {
+		return &InvalidModelAliasError{Name: alias.Name, Reason: "hedge_delay_ms must not be negative"}
+	}
+
+	for _, target := range alias.Targets {
+		if target.Weight < 0 {
+			return &InvalidModelAliasError{Name: alias.Name, Reason: fmt.Sprintf("target %q has a negative weight", target.Model)}
+		}
+
+		info, err := s.router.ParseModel(target.Model)
+		if err != nil {
+			return &InvalidModelAliasError{Name: alias.Name, Reason: fmt.Sprintf("target %q must be provider/model_name", target.Model)}
+		}
+
+		exists, err := s.providerRepo.ExistsByName(ctx, info.ProviderName)
+		if err != nil {
+			return fmt.Errorf("failed to check provider name: %w", err)
+		}
+		if !exists {
+			return &InvalidModelAliasError{Name: alias.Name, Reason: fmt.Sprintf("provider %s does not exist", info.ProviderName)}
+		}
+	}
+	return nil
+}
+
+// InvalidModelAliasError 别名配置错误
+type InvalidModelAliasError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidModelAliasError) Error() string {
+	return fmt.Sprintf("invalid model alias %q: %s", e.Name, e.Reason)
+}
+
+// ModelAliasExistsError 别名已存在错误
+type ModelAliasExistsError struct {
+	Name string
+}
+
+func (e *ModelAliasExistsError) Error() string {
+	return fmt.Sprintf("model alias already exists: %s", e.Name)
+}
+
+// ModelAliasNotFoundError 别名不存在错误
+type ModelAliasNotFoundError struct {
+	Name string
+}
+
+func (e *ModelAliasNotFoundError) Error() string {
+	return fmt.Sprintf("model alias not found: %s", e.Name)
+}
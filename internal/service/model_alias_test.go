@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// MockModelAliasRepository 模拟 ModelAlias Repository
+type MockModelAliasRepository struct {
+	mock.Mock
+}
+
+func (m *MockModelAliasRepository) Create(ctx context.Context, alias *model.ModelAlias) error {
+	args := m.Called(ctx, alias)
+	return args.Error(0)
+}
+
+func (m *MockModelAliasRepository) GetByName(ctx context.Context, name string) (*model.ModelAlias, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ModelAlias), args.Error(1)
+}
+
+func (m *MockModelAliasRepository) List(ctx context.Context) ([]*model.ModelAlias, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ModelAlias), args.Error(1)
+}
+
+func (m *MockModelAliasRepository) Update(ctx context.Context, alias *model.ModelAlias) error {
+	args := m.Called(ctx, alias)
+	return args.Error(0)
+}
+
+func (m *MockModelAliasRepository) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockModelAliasRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
+	args := m.Called(ctx, name)
+	return args.Bool(0), args.Error(1)
+}
+
+// TestCreateAlias_Success 测试创建别名后同步到路由表
+func TestCreateAlias_Success(t *testing.T) {
+	aliasRepo := new(MockModelAliasRepository)
+	providerRepo := new(MockProviderRepository)
+	router := NewRouterService()
+	svc := NewModelAliasService(aliasRepo, providerRepo, router)
+	ctx := context.Background()
+
+	alias := &model.ModelAlias{
+		Name:    "chat-default",
+		Targets: model.AliasTargets{{Model: "openai/gpt-4o"}, {Model: "vllm/meta-llama/Llama-3-8B"}},
+	}
+
+	providerRepo.On("ExistsByName", ctx, "openai").Return(true, nil)
+	providerRepo.On("ExistsByName", ctx, "vllm").Return(true, nil)
+	aliasRepo.On("ExistsByName", ctx, "chat-default").Return(false, nil)
+	aliasRepo.On("Create", ctx, alias).Return(nil)
+	aliasRepo.On("List", ctx).Return([]*model.ModelAlias{alias}, nil)
+
+	err := svc.CreateAlias(ctx, alias)
+
+	assert.NoError(t, err)
+	loaded, ok := router.lookupAlias("chat-default")
+	assert.True(t, ok)
+	assert.Equal(t, model.RouteStrategyPriority, loaded.Strategy)
+	assert.Equal(t, []string{"openai/gpt-4o", "vllm/meta-llama/Llama-3-8B"}, loaded.Targets.Models())
+	aliasRepo.AssertExpectations(t)
+}
+
+// TestCreateAlias_Invalid 测试别名名称和目标校验
+func TestCreateAlias_Invalid(t *testing.T) {
+	aliasRepo := new(MockModelAliasRepository)
+	providerRepo := new(MockProviderRepository)
+	svc := NewModelAliasService(aliasRepo, providerRepo, NewRouterService())
+	ctx := context.Background()
+
+	providerRepo.On("ExistsByName", ctx, "openai").Return(true, nil)
+	providerRepo.On("ExistsByName", ctx, "missing").Return(false, nil)
+
+	tests := []struct {
+		name  string
+		alias *model.ModelAlias
+	}{
+		{"包含斜杠", &model.ModelAlias{Name: "openai/chat", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}}}},
+		{"没有目标", &model.ModelAlias{Name: "chat-default"}},
+		{"策略不支持", &model.ModelAlias{Name: "chat-default", Strategy: "random", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}}}},
+		{"权重为负", &model.ModelAlias{Name: "chat-default", Targets: model.AliasTargets{{Model: "openai/gpt-4o", Weight: -1}}}},
+		{"目标格式错误", &model.ModelAlias{Name: "chat-default", Targets: model.AliasTargets{{Model: "gpt-4o"}}}},
+		{"Provider 不存在", &model.ModelAlias{Name: "chat-default", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}, {Model: "missing/gpt-4o"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invalidErr *InvalidModelAliasError
+			assert.ErrorAs(t, svc.CreateAlias(ctx, tt.alias), &invalidErr)
+		})
+	}
+
+	aliasRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestDeleteAlias_NotFound 测试删除不存在的别名
+func TestDeleteAlias_NotFound(t *testing.T) {
+	aliasRepo := new(MockModelAliasRepository)
+	svc := NewModelAliasService(aliasRepo, new(MockProviderRepository), NewRouterService())
+	ctx := context.Background()
+
+	aliasRepo.On("GetByName", ctx, "missing").Return(nil, assert.AnError)
+
+	var notFoundErr *ModelAliasNotFoundError
+	assert.ErrorAs(t, svc.DeleteAlias(ctx, "missing"), &notFoundErr)
+}
@@ -4,15 +4,40 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/lucheng0127/courier/internal/adapter"
 	"github.com/lucheng0127/courier/internal/model"
 	"github.com/lucheng0127/courier/internal/repository"
 )
 
+// configuredProviders 已配置的 Provider 名称（含未启用的），用于判断 fallback_models 中的项是否引用其他 Provider
+var configuredProviders sync.Map
+
+// isConfiguredProvider 判断是否为已配置的 Provider
+func isConfiguredProvider(name string) bool {
+	_, ok := configuredProviders.Load(name)
+	return ok
+}
+
+// setConfiguredProviders 以数据库中的 Provider 列表替换已配置的 Provider 名称
+func setConfiguredProviders(providers []*model.Provider) {
+	configuredProviders.Clear()
+	for _, p := range providers {
+		configuredProviders.Store(p.Name, struct{}{})
+	}
+}
+
+// slashModelProviderTypes 模型名称可能包含 "/" 的 Provider 类型（如 Hugging Face 的 meta-llama/Llama-3-8B）
+var slashModelProviderTypes = map[string]bool{
+	string(adapter.AdapterTypeVLLM):   true,
+	string(adapter.AdapterTypeOllama): true,
+}
+
 // ProviderService Provider 管理服务
 type ProviderService struct {
-	repo repository.ProviderRepository
+	repo     repository.ProviderRepository
+	breakers *BreakerRegistry
 }
 
 // NewProviderService 创建 Provider Service
@@ -20,6 +45,11 @@ func NewProviderService(repo repository.ProviderRepository) *ProviderService {
 	return &ProviderService{repo: repo}
 }
 
+// SetBreakers 设置熔断器注册表，用于在 Provider 列表中展示熔断状态
+func (s *ProviderService) SetBreakers(breakers *BreakerRegistry) {
+	s.breakers = breakers
+}
+
 // CreateProvider 创建 Provider
 func (s *ProviderService) CreateProvider(ctx context.Context, provider *model.Provider) error {
 	// 检查 name 唯一性
@@ -31,10 +61,15 @@ func (s *ProviderService) CreateProvider(ctx context.Context, provider *model.Pr
 		return fmt.Errorf("provider name already exists: %s", provider.Name)
 	}
 
+	if err := s.validateFallbackModels(ctx, provider); err != nil {
+		return err
+	}
+
 	// 创建 Provider
 	if err := s.repo.Create(ctx, provider); err != nil {
 		return fmt.Errorf("failed to create provider: %w", err)
 	}
+	configuredProviders.Store(provider.Name, struct{}{})
 
 	// 如果启用，初始化并注册
 	if provider.Enabled {
@@ -73,9 +108,12 @@ func (s *ProviderService) ListProviders(ctx context.Context, enabledFilter *bool
 		}
 
 		instance, ok := adapter.GetProvider(p.Name)
+		breakerState, modelBreakers := s.breakers.ProviderState(p.Name)
 		info := &ProviderInfo{
-			Provider:  p,
-			IsRunning: ok && instance != nil,
+			Provider:      p,
+			IsRunning:     ok && instance != nil,
+			BreakerState:  breakerState,
+			ModelBreakers: modelBreakers,
 		}
 		infos = append(infos, info)
 	}
@@ -117,6 +155,7 @@ func (s *ProviderService) ReloadAllProviders(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to list providers: %w", err)
 	}
+	setConfiguredProviders(providers)
 
 	var lastErr error
 	for _, provider := range providers {
@@ -211,6 +250,10 @@ func (s *ProviderService) UpdateProvider(ctx context.Context, name string, updat
 		provider.FallbackModels = fallbackJSON
 	}
 
+	if err := s.validateFallbackModels(ctx, provider); err != nil {
+		return nil, err
+	}
+
 	// 更新数据库
 	if err := s.repo.Update(ctx, provider); err != nil {
 		return nil, fmt.Errorf("failed to update provider: %w", err)
@@ -239,6 +282,30 @@ func (s *ProviderService) UpdateProvider(ctx context.Context, name string, updat
 	return provider, nil
 }
 
+// validateFallbackModels 校验 fallback_models 中的模型引用
+// `provider/model_name` 形式的项引用的 Provider 必须存在（不要求已启用，未运行时请求阶段跳过）；
+// 模型名称可能包含 "/" 的 Provider（vLLM、Ollama）中，"/" 之前不是 Provider 名称的项为同一 Provider 下的模型名称
+func (s *ProviderService) validateFallbackModels(ctx context.Context, provider *model.Provider) error {
+	for _, entry := range adapter.NewProviderConfig(provider).FallbackModels {
+		info, err := parseFallbackModel(entry, provider.Name, func(string) bool { return true })
+		if err != nil {
+			return &InvalidFallbackModelError{Model: entry, Reason: "expected model_name or provider/model_name"}
+		}
+		if info.ProviderName == provider.Name {
+			continue
+		}
+
+		exists, err := s.repo.ExistsByName(ctx, info.ProviderName)
+		if err != nil {
+			return fmt.Errorf("failed to check provider name: %w", err)
+		}
+		if !exists && !slashModelProviderTypes[provider.Type] {
+			return &InvalidFallbackModelError{Model: entry, Reason: fmt.Sprintf("provider %s does not exist", info.ProviderName)}
+		}
+	}
+	return nil
+}
+
 // DeleteProvider 删除 Provider
 func (s *ProviderService) DeleteProvider(ctx context.Context, name string) error {
 	// 获取 Provider
@@ -257,6 +324,7 @@ func (s *ProviderService) DeleteProvider(ctx context.Context, name string) error
 	if err := s.repo.Delete(ctx, provider.ID); err != nil {
 		return fmt.Errorf("failed to delete provider: %w", err)
 	}
+	configuredProviders.Delete(name)
 
 	log.Printf("Provider %s deleted successfully", name)
 	return nil
@@ -268,6 +336,7 @@ func (s *ProviderService) InitProviders(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to list providers: %w", err)
 	}
+	setConfiguredProviders(providers)
 
 	var initErrs []error
 	for _, provider := range providers {
@@ -307,6 +376,8 @@ func (s *ProviderService) initAndRegisterProvider(provider *model.Provider) erro
 
 // ProviderInfo Provider 信息
 type ProviderInfo struct {
-	Provider  *model.Provider `json:"provider"`
-	IsRunning bool            `json:"is_running"`
+	Provider      *model.Provider         `json:"provider"`
+	IsRunning     bool                    `json:"is_running"`
+	BreakerState  BreakerState            `json:"breaker_state"`            // 熔断状态
+	ModelBreakers map[string]BreakerState `json:"model_breakers,omitempty"` // 按模型熔断时未关闭的模型熔断器
 }
@@ -118,6 +118,100 @@ func TestUpdateProvider_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestUpdateProvider_InvalidFallbackModel 测试 fallback_models 引用不存在的 Provider
+func TestUpdateProvider_InvalidFallbackModel(t *testing.T) {
+	mockRepo := new(MockProviderRepository)
+	svc := NewProviderService(mockRepo)
+	ctx := context.Background()
+
+	existingProvider := &model.Provider{
+		ID:      1,
+		Name:    "openai",
+		Type:    "openai",
+		BaseURL: "https://api.openai.com/v1",
+		Timeout: 60,
+	}
+
+	mockRepo.On("GetByName", ctx, "openai").Return(existingProvider, nil)
+	mockRepo.On("ExistsByName", ctx, "azure").Return(true, nil)
+	mockRepo.On("ExistsByName", ctx, "missing").Return(false, nil)
+
+	updates := map[string]any{
+		"fallback_models": []interface{}{"gpt-4o-mini", "azure/gpt-4o", "missing/gpt-4o"},
+	}
+
+	_, err := svc.UpdateProvider(ctx, "openai", updates)
+
+	// 验证：返回校验错误且未写入数据库
+	var fallbackErr *InvalidFallbackModelError
+	assert.ErrorAs(t, err, &fallbackErr)
+	assert.Equal(t, "missing/gpt-4o", fallbackErr.Model)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// 以 "/" 开头的项格式错误
+	_, err = svc.UpdateProvider(ctx, "openai", map[string]any{
+		"fallback_models": []interface{}{"/gpt-4o"},
+	})
+	assert.ErrorAs(t, err, &fallbackErr)
+	assert.Equal(t, "/gpt-4o", fallbackErr.Model)
+}
+
+// TestUpdateProvider_DisabledProviderFallback 测试 fallback_models 可以引用已配置但未启用的 Provider
+func TestUpdateProvider_DisabledProviderFallback(t *testing.T) {
+	mockRepo := new(MockProviderRepository)
+	svc := NewProviderService(mockRepo)
+	ctx := context.Background()
+
+	existingProvider := &model.Provider{
+		ID:      1,
+		Name:    "openai",
+		Type:    "openai",
+		BaseURL: "https://api.openai.com/v1",
+		Timeout: 60,
+	}
+
+	// azure 已配置但未启用，不在运行中的 Provider 中
+	mockRepo.On("GetByName", ctx, "openai").Return(existingProvider, nil)
+	mockRepo.On("ExistsByName", ctx, "azure").Return(true, nil)
+	mockRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+	updates := map[string]any{
+		"fallback_models": []interface{}{"azure/gpt-4o"},
+	}
+
+	_, err := svc.UpdateProvider(ctx, "openai", updates)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateProvider_SlashModelFallback 测试同一 Provider 下包含 "/" 的模型名称可以作为 Fallback 模型
+func TestUpdateProvider_SlashModelFallback(t *testing.T) {
+	mockRepo := new(MockProviderRepository)
+	svc := NewProviderService(mockRepo)
+	ctx := context.Background()
+
+	existingProvider := &model.Provider{
+		ID:      1,
+		Name:    "vllm-local",
+		Type:    "vllm",
+		BaseURL: "http://localhost:8000/v1",
+		Timeout: 60,
+	}
+
+	mockRepo.On("GetByName", ctx, "vllm-local").Return(existingProvider, nil)
+	mockRepo.On("ExistsByName", ctx, "meta-llama").Return(false, nil)
+	mockRepo.On("ExistsByName", ctx, "Qwen").Return(false, nil)
+	mockRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+	updates := map[string]any{
+		"fallback_models": []interface{}{"meta-llama/Llama-3-8B", "Qwen/Qwen2.5-7B-Instruct"},
+	}
+
+	_, err := svc.UpdateProvider(ctx, "vllm-local", updates)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
 // TestUpdateProvider_DisableToEnable 测试从禁用变为启用
 func TestUpdateProvider_DisableToEnable(t *testing.T) {
 	mockRepo := new(MockProviderRepository)
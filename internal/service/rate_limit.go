@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+const (
+	// rateLimitWindow RPM/TPM 的统计周期，令牌桶在一个周期内补满
+	rateLimitWindow = time.Minute
+	// rateLimitCacheTTL 用户和 API Key 限制的缓存时间，其他副本上的修改最迟在该时间后生效
+	rateLimitCacheTTL = 30 * time.Second
+
+	RateLimitKindRequests = "requests" // 每分钟请求数
+	RateLimitKindTokens   = "tokens"   // 每分钟 Token 数
+)
+
+// RateLimits 每分钟请求数（RPM）和 Token 数（TPM）上限，0 表示不限制
+type RateLimits struct {
+	RPM int `json:"rpm"`
+	TPM int `json:"tpm"`
+}
+
+// RateLimitConfig 按角色的默认速率限制，用户未单独设置时使用
+type RateLimitConfig struct {
+	User  RateLimits // 普通用户
+	Admin RateLimits // 管理员
+}
+
+// DefaultRateLimitConfig 默认速率限制配置（不限制）
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{}
+}
+
+// RateLimitConfigFromEnv 从 RATE_LIMIT_* 环境变量读取按角色的默认速率限制，未设置或非法时使用默认值
+func RateLimitConfigFromEnv() RateLimitConfig {
+	cfg := DefaultRateLimitConfig()
+
+	for env, dst := range map[string]*int{
+		"RATE_LIMIT_USER_RPM":  &cfg.User.RPM,
+		"RATE_LIMIT_USER_TPM":  &cfg.User.TPM,
+		"RATE_LIMIT_ADMIN_RPM": &cfg.Admin.RPM,
+		"RATE_LIMIT_ADMIN_TPM": &cfg.Admin.TPM,
+	} {
+		if v := os.Getenv(env); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				*dst = n
+			}
+		}
+	}
+
+	return cfg
+}
+
+// defaultsFor 角色的默认限制
+func (c RateLimitConfig) defaultsFor(role string) RateLimits {
+	if role == "admin" {
+		return c.Admin
+	}
+	return c.User
+}
+
+// RateLimitSubject 限流对象：用户，以及 API Key 认证时使用的 API Key
+type RateLimitSubject struct {
+	UserID   int64
+	APIKeyID *int64
+}
+
+// RateLimitResult 一次限流检查的结果，用于设置 x-ratelimit-* 响应头
+// 用户和 API Key 都有限制时，每个维度报告剩余额度较少的一个；Limit 为 0 表示该维度不限制
+type RateLimitResult struct {
+	Allowed    bool
+	Exceeded   string        // 超出限制的维度：requests 或 tokens
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+
+	LimitRequests     int
+	RemainingRequests int
+	ResetRequests     time.Duration // 请求额度恢复到上限所需的时间
+	LimitTokens       int
+	RemainingTokens   int
+	ResetTokens       time.Duration // Token 额度恢复到上限所需的时间
+
+	tokenCharges []RateLimitCharge // 预扣了 Token 的令牌桶及预扣数量，对账时调整
+}
+
+// RateLimitTargetNotFoundError 设置限制的用户或 API Key 不存在
+type RateLimitTargetNotFoundError struct {
+	Kind string // user 或 api_key
+	ID   int64
+}
+
+func (e *RateLimitTargetNotFoundError) Error() string {
+	return fmt.Sprintf("%s %d not found", e.Kind, e.ID)
+}
+
+// RateLimitService 按用户和 API Key 限制 Chat 请求的 RPM 和 TPM
+// 请求前按估算的 Token 数预扣额度，请求结束后按实际使用量对账；
+// 令牌桶保存在 RateLimitBackend 中，使用共享后端时限制在多个网关副本间共享
+type RateLimitService struct {
+	cfg      RateLimitConfig
+	userRepo repository.UserRepository
+	backend  RateLimitBackend
+
+	mu     sync.Mutex
+	limits map[string]cachedRateLimits // user:<id> / api_key:<id> -> 限制
+	now    func() time.Time
+}
+
+// cachedRateLimits 缓存的用户或 API Key 限制
+type cachedRateLimits struct {
+	limits    RateLimits
+	expiresAt time.Time
+}
+
+// NewRateLimitService 创建速率限制服务
+func NewRateLimitService(cfg RateLimitConfig, userRepo repository.UserRepository, backend RateLimitBackend) *RateLimitService {
+	return &RateLimitService{
+		cfg:      cfg,
+		userRepo: userRepo,
+		backend:  backend,
+		limits:   make(map[string]cachedRateLimits),
+		now:      time.Now,
+	}
+}
+
+// RateLimitTokens 请求预扣的 Token 数：估算的提示词 Token 数加上 max_tokens（多个候选时乘以 n）
+func RateLimitTokens(req *model.ChatRequest) int {
+	tokens := EstimatePromptTokens(req.Messages)
+	if req.MaxTokens != nil {
+		n := 1
+		if req.N != nil && *req.N > 1 {
+			n = *req.N
+		}
+		tokens += *req.MaxTokens * n
+	}
+	return tokens
+}
+
+// Reserve 检查用户和 API Key 的限制并预扣 1 个请求和 tokens 个 Token
+// 任一限制超出时不扣减任何额度，返回的结果中 Allowed 为 false
+// 单个请求的 Token 数超过 TPM 上限时按上限预扣，额度补满后仍可发出，超出部分在对账时扣减
+func (s *RateLimitService) Reserve(ctx context.Context, subject RateLimitSubject, tokens int) (*RateLimitResult, error) {
+	userLimits, err := s.userLimits(ctx, subject.UserID)
+	if err != nil {
+		return nil, err
+	}
+	checks := limitChecks(fmt.Sprintf("user:%d", subject.UserID), userLimits, tokens)
+
+	if subject.APIKeyID != nil {
+		keyLimits, err := s.apiKeyLimits(ctx, *subject.APIKeyID)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, limitChecks(fmt.Sprintf("api_key:%d", *subject.APIKeyID), keyLimits, tokens)...)
+	}
+
+	result := &RateLimitResult{Allowed: true}
+	if len(checks) == 0 {
+		return result, nil
+	}
+
+	charges := make([]RateLimitCharge, len(checks))
+	for i, check := range checks {
+		charges[i] = check.RateLimitCharge
+	}
+	states, allowed, err := s.backend.Take(ctx, charges)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Allowed = allowed
+	for i, check := range checks {
+		state := states[i]
+		switch check.kind {
+		case RateLimitKindRequests:
+			if result.LimitRequests == 0 || state.Remaining < result.RemainingRequests {
+				result.LimitRequests = check.Limit
+				result.RemainingRequests = state.Remaining
+				result.ResetRequests = state.Reset
+			}
+		case RateLimitKindTokens:
+			if result.LimitTokens == 0 || state.Remaining < result.RemainingTokens {
+				result.LimitTokens = check.Limit
+				result.RemainingTokens = state.Remaining
+				result.ResetTokens = state.Reset
+			}
+			result.tokenCharges = append(result.tokenCharges, check.RateLimitCharge)
+		}
+		if !allowed && state.RetryAfter > result.RetryAfter {
+			result.Exceeded = check.kind
+			result.RetryAfter = state.RetryAfter
+		}
+	}
+	return result, nil
+}
+
+// Reconcile 请求结束后按实际使用的 Token 数调整预扣的额度
+// 实际使用量少于预扣时退回差额，多于预扣时补扣（额度可以为负，之后的请求需要等待补足）
+func (s *RateLimitService) Reconcile(ctx context.Context, result *RateLimitResult, usedTokens int) error {
+	if result == nil || !result.Allowed {
+		return nil
+	}
+	var adjustments []RateLimitCharge
+	for _, charge := range result.tokenCharges {
+		// 各令牌桶按上限截断后的预扣数量可能不同，分别计算差额
+		if delta := usedTokens - charge.Cost; delta != 0 {
+			charge.Cost = delta
+			adjustments = append(adjustments, charge)
+		}
+	}
+	if len(adjustments) == 0 {
+		return nil
+	}
+	return s.backend.Adjust(ctx, adjustments)
+}
+
+// SetUserLimits 设置用户的限制，为 nil 的字段恢复角色默认值
+func (s *RateLimitService) SetUserLimits(ctx context.Context, userID int64, rpmLimit, tpmLimit *int) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, &RateLimitTargetNotFoundError{Kind: "user", ID: userID}
+	}
+	if err := s.userRepo.UpdateUserRateLimits(ctx, userID, rpmLimit, tpmLimit); err != nil {
+		return nil, err
+	}
+	s.invalidate(fmt.Sprintf("user:%d", userID))
+
+	user.RPMLimit = rpmLimit
+	user.TPMLimit = tpmLimit
+	return user, nil
+}
+
+// SetAPIKeyLimits 设置 API Key 的限制，为 nil 的字段清除设置（只受用户限制）
+func (s *RateLimitService) SetAPIKeyLimits(ctx context.Context, keyID int64, rpmLimit, tpmLimit *int) (*model.APIKey, error) {
+	key, err := s.userRepo.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, &RateLimitTargetNotFoundError{Kind: "api_key", ID: keyID}
+	}
+	if err := s.userRepo.UpdateAPIKeyRateLimits(ctx, keyID, rpmLimit, tpmLimit); err != nil {
+		return nil, err
+	}
+	s.invalidate(fmt.Sprintf("api_key:%d", keyID))
+
+	key.RPMLimit = rpmLimit
+	key.TPMLimit = tpmLimit
+	return key, nil
+}
+
+// userLimits 用户的有效限制：用户单独设置的值，未设置时为角色默认值
+func (s *RateLimitService) userLimits(ctx context.Context, userID int64) (RateLimits, error) {
+	return s.cachedLimits(fmt.Sprintf("user:%d", userID), func() (RateLimits, error) {
+		user, err := s.userRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			return RateLimits{}, err
+		}
+		limits := s.cfg.defaultsFor(user.Role)
+		if user.RPMLimit != nil {
+			limits.RPM = *user.RPMLimit
+		}
+		if user.TPMLimit != nil {
+			limits.TPM = *user.TPMLimit
+		}
+		return limits, nil
+	})
+}
+
+// apiKeyLimits API Key 单独设置的限制，未设置的维度为 0（只受用户限制）
+func (s *RateLimitService) apiKeyLimits(ctx context.Context, keyID int64) (RateLimits, error) {
+	return s.cachedLimits(fmt.Sprintf("api_key:%d", keyID), func() (RateLimits, error) {
+		key, err := s.userRepo.GetAPIKeyByID(ctx, keyID)
+		if err != nil {
+			return RateLimits{}, err
+		}
+		var limits RateLimits
+		if key.RPMLimit != nil {
+			limits.RPM = *key.RPMLimit
+		}
+		if key.TPMLimit != nil {
+			limits.TPM = *key.TPMLimit
+		}
+		return limits, nil
+	})
+}
+
+// cachedLimits 读取缓存的限制，过期时通过 load 重新加载
+func (s *RateLimitService) cachedLimits(key string, load func() (RateLimits, error)) (RateLimits, error) {
+	s.mu.Lock()
+	cached, ok := s.limits[key]
+	s.mu.Unlock()
+	if ok && s.now().Before(cached.expiresAt) {
+		return cached.limits, nil
+	}
+
+	limits, err := load()
+	if err != nil {
+		return RateLimits{}, fmt.Errorf("failed to load rate limits of %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	s.limits[key] = cachedRateLimits{limits: limits, expiresAt: s.now().Add(rateLimitCacheTTL)}
+	s.mu.Unlock()
+	return limits, nil
+}
+
+// invalidate 清除缓存的限制，本副本上的修改立即生效
+func (s *RateLimitService) invalidate(key string) {
+	s.mu.Lock()
+	delete(s.limits, key)
+	s.mu.Unlock()
+}
+
+// rateLimitCheck 一个维度的令牌桶扣减
+type rateLimitCheck struct {
+	kind string // requests 或 tokens
+	RateLimitCharge
+}
+
+// limitChecks 按限制生成需要扣减的令牌桶，不限制的维度不扣减
+func limitChecks(prefix string, limits RateLimits, tokens int) []rateLimitCheck {
+	var checks []rateLimitCheck
+	if limits.RPM > 0 {
+		checks = append(checks, rateLimitCheck{kind: RateLimitKindRequests, RateLimitCharge: RateLimitCharge{
+			Key: prefix + ":" + RateLimitKindRequests, Limit: limits.RPM, Window: rateLimitWindow, Cost: 1,
+		}})
+	}
+	if limits.TPM > 0 {
+		checks = append(checks, rateLimitCheck{kind: RateLimitKindTokens, RateLimitCharge: RateLimitCharge{
+			Key: prefix + ":" + RateLimitKindTokens, Limit: limits.TPM, Window: rateLimitWindow, Cost: min(tokens, limits.TPM),
+		}})
+	}
+	return checks
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/pkg/resp"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+const (
+	RateLimitBackendMemory   = "memory"   // 进程内，各副本独立计数
+	RateLimitBackendPostgres = "postgres" // rate_limit_buckets 表，多副本共享
+	RateLimitBackendRedis    = "redis"    // Redis（或兼容 Redis 协议的服务），多副本共享
+
+	// rateLimitSweepInterval 进程内后端清理已补满的令牌桶的间隔
+	rateLimitSweepInterval = 5 * time.Minute
+)
+
+// ErrRateLimitContention 共享后端的令牌桶被大量并发请求同时修改，多次重试后仍无法扣减
+// 与后端不可用不同，此时调用方应拒绝请求，否则突发流量会绕过限制
+var ErrRateLimitContention = errors.New("rate limit buckets are modified concurrently")
+
+// RateLimitCharge 一次请求对一个令牌桶的扣减
+type RateLimitCharge struct {
+	Key    string        // 令牌桶标识，如 user:1:tokens
+	Limit  int           // 桶容量，即每个周期的上限
+	Window time.Duration // 周期，令牌桶按 Limit/Window 的速度匀速补充
+	Cost   int
+}
+
+// RateLimitBucketState 扣减后令牌桶的状态
+type RateLimitBucketState struct {
+	Remaining  int
+	Reset      time.Duration // 补满所需的时间
+	RetryAfter time.Duration // 额度不足时，补足本次扣减所需的时间
+}
+
+// RateLimitBackend 令牌桶存储
+// 多个网关副本使用同一个共享后端时，限制按全部副本的请求计算，且不会因重启而重置
+type RateLimitBackend interface {
+	// Take 在全部令牌桶额度足够时一起扣减，否则都不扣减；返回每个令牌桶的状态和是否放行
+	Take(ctx context.Context, charges []RateLimitCharge) ([]RateLimitBucketState, bool, error)
+
+	// Adjust 按 charges 的 Cost 补扣令牌，Cost 为负时退回（退回后不超过上限）
+	Adjust(ctx context.Context, charges []RateLimitCharge) error
+}
+
+// RateLimitBackendConfig 限流后端配置
+type RateLimitBackendConfig struct {
+	Backend  string // memory、postgres 或 redis
+	RedisURL string // redis://[:password@]host:port[/db]
+}
+
+// DefaultRateLimitBackendConfig 默认限流后端配置（进程内）
+func DefaultRateLimitBackendConfig() RateLimitBackendConfig {
+	return RateLimitBackendConfig{Backend: RateLimitBackendMemory}
+}
+
+// RateLimitBackendConfigFromEnv 从 RATE_LIMIT_BACKEND、RATE_LIMIT_REDIS_URL 环境变量读取限流后端配置
+func RateLimitBackendConfigFromEnv() RateLimitBackendConfig {
+	cfg := DefaultRateLimitBackendConfig()
+	if v := os.Getenv("RATE_LIMIT_BACKEND"); v != "" {
+		cfg.Backend = v
+	}
+	cfg.RedisURL = os.Getenv("RATE_LIMIT_REDIS_URL")
+	return cfg
+}
+
+// NewRateLimitBackend 按配置创建限流后端
+func NewRateLimitBackend(cfg RateLimitBackendConfig, repo repository.RateLimitRepository) (RateLimitBackend, error) {
+	switch cfg.Backend {
+	case RateLimitBackendMemory:
+		return NewMemoryRateLimitBackend(), nil
+	case RateLimitBackendPostgres:
+		return NewPostgresRateLimitBackend(repo), nil
+	case RateLimitBackendRedis:
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("RATE_LIMIT_REDIS_URL is required for the redis rate limit backend")
+		}
+		opts, err := resp.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisRateLimitBackend(resp.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend %q", cfg.Backend)
+	}
+}
+
+// tokenBucket 令牌桶状态，按经过的时间计算补充的令牌，不需要定时任务
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newTokenBucket 新的令牌桶是满的
+func newTokenBucket(now time.Time, limit int) *tokenBucket {
+	return &tokenBucket{tokens: float64(limit), updated: now}
+}
+
+// refillRate 每秒补充的令牌数
+func refillRate(charge RateLimitCharge) float64 {
+	return float64(charge.Limit) / charge.Window.Seconds()
+}
+
+// refill 按经过的时间补充令牌，不超过上限（上限调低时截断）
+func (b *tokenBucket) refill(now time.Time, charge RateLimitCharge) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * refillRate(charge)
+		b.updated = now
+	}
+	b.tokens = math.Min(b.tokens, float64(charge.Limit))
+}
+
+// fullAt 令牌桶补满的时间，之后的状态与新建的令牌桶相同，共享后端以此作为过期时间
+func (b *tokenBucket) fullAt(charge RateLimitCharge) time.Time {
+	missing := float64(charge.Limit) - b.tokens
+	return b.updated.Add(secondsToDuration(missing / refillRate(charge)))
+}
+
+// takeTokens 在全部令牌桶额度足够时一起扣减，否则都不扣减；buckets 与 charges 一一对应
+func takeTokens(now time.Time, charges []RateLimitCharge, buckets []*tokenBucket) ([]RateLimitBucketState, bool) {
+	allowed := true
+	for i, charge := range charges {
+		buckets[i].refill(now, charge)
+		if buckets[i].tokens < float64(charge.Cost) {
+			allowed = false
+		}
+	}
+
+	states := make([]RateLimitBucketState, len(charges))
+	for i, charge := range charges {
+		b := buckets[i]
+		rate := refillRate(charge)
+		if allowed {
+			b.tokens -= float64(charge.Cost)
+		} else if b.tokens < float64(charge.Cost) {
+			states[i].RetryAfter = secondsToDuration((float64(charge.Cost) - b.tokens) / rate)
+		}
+		states[i].Remaining = max(int(math.Floor(b.tokens)), 0)
+		states[i].Reset = secondsToDuration((float64(charge.Limit) - b.tokens) / rate)
+	}
+	return states, allowed
+}
+
+// adjustTokens 补扣 charge.Cost 个令牌，退回后不超过上限
+func adjustTokens(now time.Time, charge RateLimitCharge, b *tokenBucket) {
+	b.refill(now, charge)
+	b.tokens = math.Min(b.tokens-float64(charge.Cost), float64(charge.Limit))
+}
+
+// secondsToDuration 秒数转换为 time.Duration，向上取整
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// memoryBucket 进程内的令牌桶
+type memoryBucket struct {
+	tokenBucket
+	fullAt time.Time
+}
+
+// MemoryRateLimitBackend 进程内限流后端，各副本独立计数，重启后重置
+type MemoryRateLimitBackend struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryRateLimitBackend 创建进程内限流后端
+func NewMemoryRateLimitBackend() *MemoryRateLimitBackend {
+	return &MemoryRateLimitBackend{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take 在全部令牌桶额度足够时一起扣减
+func (m *MemoryRateLimitBackend) Take(ctx context.Context, charges []RateLimitCharge) ([]RateLimitBucketState, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	buckets := make([]*tokenBucket, len(charges))
+	for i, charge := range charges {
+		b, ok := m.buckets[charge.Key]
+		if !ok {
+			b = &memoryBucket{tokenBucket: *newTokenBucket(now, charge.Limit)}
+			m.buckets[charge.Key] = b
+		}
+		buckets[i] = &b.tokenBucket
+	}
+
+	states, allowed := takeTokens(now, charges, buckets)
+	for _, charge := range charges {
+		b := m.buckets[charge.Key]
+		b.fullAt = b.tokenBucket.fullAt(charge)
+	}
+	return states, allowed, nil
+}
+
+// Adjust 补扣或退回令牌，已被清理的令牌桶按满桶调整
+func (m *MemoryRateLimitBackend) Adjust(ctx context.Context, charges []RateLimitCharge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, charge := range charges {
+		b, ok := m.buckets[charge.Key]
+		if !ok {
+			b = &memoryBucket{tokenBucket: *newTokenBucket(now, charge.Limit)}
+			m.buckets[charge.Key] = b
+		}
+		adjustTokens(now, charge, &b.tokenBucket)
+		b.fullAt = b.tokenBucket.fullAt(charge)
+	}
+	return nil
+}
+
+// sweep 定期删除已经补满的令牌桶，调用方需持有 m.mu
+func (m *MemoryRateLimitBackend) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if !now.Before(b.fullAt) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/pkg/resp"
+	"github.com/lucheng0127/courier/internal/pkg/resp/resptest"
+)
+
+// fakeRateLimitRepository 进程内的 RateLimitRepository，模拟 rate_limit_buckets 表
+type fakeRateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]model.RateLimitBucket
+	now     func() time.Time
+}
+
+func newFakeRateLimitRepository(now func() time.Time) *fakeRateLimitRepository {
+	return &fakeRateLimitRepository{buckets: make(map[string]model.RateLimitBucket), now: now}
+}
+
+func (r *fakeRateLimitRepository) Update(ctx context.Context, keys []string, fn func(now time.Time, buckets map[string]*model.RateLimitBucket) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	rows := make(map[string]*model.RateLimitBucket, len(keys))
+	for _, key := range keys {
+		row, ok := r.buckets[key]
+		if !ok {
+			row = model.RateLimitBucket{Key: key, UpdatedAt: now, ExpiresAt: now}
+		}
+		rows[key] = &row
+	}
+	if err := fn(now, rows); err != nil {
+		return err
+	}
+	for key, row := range rows {
+		r.buckets[key] = *row
+	}
+	return nil
+}
+
+func (r *fakeRateLimitRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for key, row := range r.buckets {
+		if !row.ExpiresAt.After(r.now()) {
+			delete(r.buckets, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newRedisTestBackend 创建连接 resptest 服务端的 Redis 限流后端
+func newRedisTestBackend(t *testing.T, now func() time.Time) (*RedisRateLimitBackend, *resptest.Server) {
+	server, err := resptest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	server.SetNow(now)
+
+	client := resp.NewClient(resp.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisRateLimitBackend(client), server
+}
+
+// TestRateLimitBackends 测试各限流后端的令牌桶行为一致
+func TestRateLimitBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T, now func() time.Time) RateLimitBackend{
+		"memory": func(t *testing.T, now func() time.Time) RateLimitBackend {
+			backend := NewMemoryRateLimitBackend()
+			backend.now = now
+			return backend
+		},
+		"postgres": func(t *testing.T, now func() time.Time) RateLimitBackend {
+			return NewPostgresRateLimitBackend(newFakeRateLimitRepository(now))
+		},
+		"redis": func(t *testing.T, now func() time.Time) RateLimitBackend {
+			backend, _ := newRedisTestBackend(t, now)
+			return backend
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1700000000, 0)
+			backend := newBackend(t, func() time.Time { return now })
+			ctx := context.Background()
+
+			requests := RateLimitCharge{Key: "user:1:requests", Limit: 2, Window: time.Minute, Cost: 1}
+			tokens := RateLimitCharge{Key: "user:1:tokens", Limit: 60, Window: time.Minute, Cost: 40}
+			charges := []RateLimitCharge{requests, tokens}
+
+			states, allowed, err := backend.Take(ctx, charges)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, 1, states[0].Remaining)
+			assert.Equal(t, 30*time.Second, states[0].Reset)
+			assert.Equal(t, 20, states[1].Remaining)
+			assert.Equal(t, 40*time.Second, states[1].Reset)
+
+			// Token 额度不足，请求额度也不扣减
+			states, allowed, err = backend.Take(ctx, charges)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, 1, states[0].Remaining)
+			assert.Zero(t, states[0].RetryAfter)
+			assert.Equal(t, 20*time.Second, states[1].RetryAfter)
+
+			// 退回 30 个 Token 后额度足够
+			require.NoError(t, backend.Adjust(ctx, []RateLimitCharge{{Key: tokens.Key, Limit: 60, Window: time.Minute, Cost: -30}}))
+			states, allowed, err = backend.Take(ctx, charges)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, 0, states[0].Remaining)
+			assert.Equal(t, 10, states[1].Remaining)
+
+			// 退回不超过上限
+			require.NoError(t, backend.Adjust(ctx, []RateLimitCharge{{Key: tokens.Key, Limit: 60, Window: time.Minute, Cost: -100}}))
+
+			// 额度按 Limit/Window 匀速恢复
+			now = now.Add(30 * time.Second)
+			states, allowed, err = backend.Take(ctx, []RateLimitCharge{requests})
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, 0, states[0].Remaining)
+
+			now = now.Add(time.Hour)
+			states, allowed, err = backend.Take(ctx, charges)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, 1, states[0].Remaining)
+			assert.Equal(t, 20, states[1].Remaining)
+		})
+	}
+}
+
+// TestRedisRateLimitBackend_Conflict 测试令牌桶被其他副本同时修改时重新读取后扣减
+func TestRedisRateLimitBackend_Conflict(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	backend, server := newRedisTestBackend(t, func() time.Time { return now })
+	ctx := context.Background()
+	charge := RateLimitCharge{Key: "register:10.0.0.1", Limit: 5, Window: time.Hour, Cost: 1}
+
+	// 第一次读取后，其他副本扣减到只剩 1 个
+	attempts := 0
+	var states []RateLimitBucketState
+	err := backend.update(ctx, []RateLimitCharge{charge}, func(now time.Time, buckets []*tokenBucket) {
+		attempts++
+		if attempts == 1 {
+			server.Set(redisRateLimitPrefix+charge.Key, formatRedisBucket(&tokenBucket{tokens: 1, updated: now}))
+		}
+		states, _ = takeTokens(now, []RateLimitCharge{charge}, buckets)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 0, states[0].Remaining)
+
+	value, ok := server.Get(redisRateLimitPrefix + charge.Key)
+	require.True(t, ok)
+	bucket, ok := parseRedisBucket(value)
+	require.True(t, ok)
+	assert.Zero(t, bucket.tokens)
+
+	_, allowed, err := backend.Take(ctx, []RateLimitCharge{charge})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+// TestRedisRateLimitBackend_Contention 测试令牌桶每次写回前都被修改时，多次重试后返回 ErrRateLimitContention
+func TestRedisRateLimitBackend_Contention(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	backend, server := newRedisTestBackend(t, func() time.Time { return now })
+	charge := RateLimitCharge{Key: "register:10.0.0.1", Limit: 5, Window: time.Hour, Cost: 1}
+
+	attempts := 0
+	err := backend.update(context.Background(), []RateLimitCharge{charge}, func(now time.Time, buckets []*tokenBucket) {
+		attempts++
+		server.Set(redisRateLimitPrefix+charge.Key, formatRedisBucket(&tokenBucket{tokens: 5, updated: now}))
+		takeTokens(now, []RateLimitCharge{charge}, buckets)
+	})
+	assert.ErrorIs(t, err, ErrRateLimitContention)
+	assert.Equal(t, redisRateLimitMaxAttempts, attempts)
+}
+
+// TestRedisRateLimitBackend_ConcurrentTake 测试并发扣减同一个令牌桶时放行的请求数不超过上限
+func TestRedisRateLimitBackend_ConcurrentTake(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	backend, _ := newRedisTestBackend(t, func() time.Time { return now })
+	charge := RateLimitCharge{Key: "user:1:requests", Limit: 10, Window: time.Hour, Cost: 1}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		granted int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, allowed, err := backend.Take(context.Background(), []RateLimitCharge{charge})
+			if err != nil {
+				assert.ErrorIs(t, err, ErrRateLimitContention)
+				return
+			}
+			if allowed {
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, granted, charge.Limit)
+	assert.Positive(t, granted)
+}
+
+// TestRateLimitBackendConfigFromEnv 测试从环境变量读取限流后端配置
+func TestRateLimitBackendConfigFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_BACKEND", "")
+	t.Setenv("RATE_LIMIT_REDIS_URL", "")
+	cfg := RateLimitBackendConfigFromEnv()
+	assert.Equal(t, RateLimitBackendMemory, cfg.Backend)
+
+	backend, err := NewRateLimitBackend(cfg, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryRateLimitBackend{}, backend)
+
+	t.Setenv("RATE_LIMIT_BACKEND", "redis")
+	_, err = NewRateLimitBackend(RateLimitBackendConfigFromEnv(), nil)
+	assert.Error(t, err)
+
+	t.Setenv("RATE_LIMIT_REDIS_URL", "redis://localhost:6379/1")
+	backend, err = NewRateLimitBackend(RateLimitBackendConfigFromEnv(), nil)
+	require.NoError(t, err)
+	assert.IsType(t, &RedisRateLimitBackend{}, backend)
+
+	_, err = NewRateLimitBackend(RateLimitBackendConfig{Backend: "etcd"}, nil)
+	assert.Error(t, err)
+}
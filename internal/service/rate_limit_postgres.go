@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+// rateLimitCleanupEvery Postgres 后端每更新多少次清理一次已补满的令牌桶
+const rateLimitCleanupEvery = 1000
+
+// PostgresRateLimitBackend 基于 rate_limit_buckets 表的限流后端，多个网关副本共享
+// 每次扣减在一个事务中锁定相关的令牌桶，使用数据库时间计算补充的令牌
+type PostgresRateLimitBackend struct {
+	repo    repository.RateLimitRepository
+	updates atomic.Int64
+}
+
+// NewPostgresRateLimitBackend 创建 Postgres 限流后端
+func NewPostgresRateLimitBackend(repo repository.RateLimitRepository) *PostgresRateLimitBackend {
+	return &PostgresRateLimitBackend{repo: repo}
+}
+
+// Take 在全部令牌桶额度足够时一起扣减
+func (p *PostgresRateLimitBackend) Take(ctx context.Context, charges []RateLimitCharge) ([]RateLimitBucketState, bool, error) {
+	var states []RateLimitBucketState
+	var allowed bool
+	err := p.update(ctx, charges, func(now time.Time, buckets []*tokenBucket) {
+		states, allowed = takeTokens(now, charges, buckets)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return states, allowed, nil
+}
+
+// Adjust 补扣或退回令牌
+func (p *PostgresRateLimitBackend) Adjust(ctx context.Context, charges []RateLimitCharge) error {
+	return p.update(ctx, charges, func(now time.Time, buckets []*tokenBucket) {
+		for i, charge := range charges {
+			adjustTokens(now, charge, buckets[i])
+		}
+	})
+}
+
+// update 锁定 charges 对应的令牌桶，由 fn 修改后写回
+func (p *PostgresRateLimitBackend) update(ctx context.Context, charges []RateLimitCharge, fn func(now time.Time, buckets []*tokenBucket)) error {
+	keys := make([]string, len(charges))
+	for i, charge := range charges {
+		keys[i] = charge.Key
+	}
+
+	err := p.repo.Update(ctx, keys, func(now time.Time, rows map[string]*model.RateLimitBucket) error {
+		buckets := make([]*tokenBucket, len(charges))
+		for i, charge := range charges {
+			row := rows[charge.Key]
+			if row.Tokens == nil {
+				buckets[i] = newTokenBucket(now, charge.Limit)
+			} else {
+				buckets[i] = &tokenBucket{tokens: *row.Tokens, updated: row.UpdatedAt}
+			}
+		}
+
+		fn(now, buckets)
+
+		for i, charge := range charges {
+			tokens := buckets[i].tokens
+			row := rows[charge.Key]
+			row.Tokens = &tokens
+			row.UpdatedAt = buckets[i].updated
+			row.ExpiresAt = buckets[i].fullAt(charge)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if p.updates.Add(1)%rateLimitCleanupEvery == 0 {
+		if _, err := p.repo.DeleteExpired(ctx); err != nil {
+			// 清理失败不影响本次扣减，下次再清理
+			logger.L.Warn("Failed to delete expired rate limit buckets", zap.Error(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/pkg/resp"
+)
+
+const (
+	// redisRateLimitPrefix 令牌桶 key 的前缀
+	redisRateLimitPrefix = "courier:ratelimit:"
+	// redisRateLimitMaxAttempts 令牌桶被其他副本同时修改时的最大尝试次数
+	redisRateLimitMaxAttempts = 10
+	// redisRateLimitBaseBackoff 令牌桶被同时修改后重新读取前的初始退避时间，之后每次翻倍
+	redisRateLimitBaseBackoff = 2 * time.Millisecond
+	// redisRateLimitMaxBackoff 退避时间上限
+	redisRateLimitMaxBackoff = 50 * time.Millisecond
+)
+
+// RedisRateLimitBackend 基于 Redis 的限流后端，多个网关副本共享
+// 使用 WATCH/MULTI/EXEC 乐观事务读写令牌桶，被其他副本同时修改时随机退避后重试，多次失败后返回 ErrRateLimitContention；
+// 使用 Redis 的 TIME 作为时钟，
+// 令牌桶的值为 "<剩余令牌数> <更新时间（微秒）>"，补满后过期
+type RedisRateLimitBackend struct {
+	client *resp.Client
+}
+
+// NewRedisRateLimitBackend 创建 Redis 限流后端
+func NewRedisRateLimitBackend(client *resp.Client) *RedisRateLimitBackend {
+	return &RedisRateLimitBackend{client: client}
+}
+
+// Take 在全部令牌桶额度足够时一起扣减
+func (r *RedisRateLimitBackend) Take(ctx context.Context, charges []RateLimitCharge) ([]RateLimitBucketState, bool, error) {
+	var states []RateLimitBucketState
+	var allowed bool
+	err := r.update(ctx, charges, func(now time.Time, buckets []*tokenBucket) {
+		states, allowed = takeTokens(now, charges, buckets)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return states, allowed, nil
+}
+
+// Adjust 补扣或退回令牌
+func (r *RedisRateLimitBackend) Adjust(ctx context.Context, charges []RateLimitCharge) error {
+	return r.update(ctx, charges, func(now time.Time, buckets []*tokenBucket) {
+		for i, charge := range charges {
+			adjustTokens(now, charge, buckets[i])
+		}
+	})
+}
+
+// update 读取 charges 对应的令牌桶，由 fn 修改后写回，写回前令牌桶被修改时退避后重新读取
+func (r *RedisRateLimitBackend) update(ctx context.Context, charges []RateLimitCharge, fn func(now time.Time, buckets []*tokenBucket)) error {
+	for attempt := 0; attempt < redisRateLimitMaxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, redisRateLimitBackoff(attempt)); err != nil {
+				return err
+			}
+		}
+		conn, err := r.client.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		committed, err := r.tryUpdate(ctx, conn, charges, fn)
+		if err != nil {
+			// 连接可能仍处于 WATCH 或 MULTI 状态，不归还连接池
+			conn.Close()
+			return err
+		}
+		r.client.Release(conn, nil)
+		if committed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, gave up after %d attempts", ErrRateLimitContention, redisRateLimitMaxAttempts)
+}
+
+// redisRateLimitBackoff 第 attempt 次冲突后的退避时间：指数退避，取上限后在 [0, d] 之间随机，使同时冲突的副本错开重试
+func redisRateLimitBackoff(attempt int) time.Duration {
+	d := min(redisRateLimitBaseBackoff<<(attempt-1), redisRateLimitMaxBackoff)
+	return rand.N(d + 1)
+}
+
+// tryUpdate 执行一次乐观事务，令牌桶被其他连接修改时返回 false
+func (r *RedisRateLimitBackend) tryUpdate(ctx context.Context, conn *resp.Conn, charges []RateLimitCharge, fn func(now time.Time, buckets []*tokenBucket)) (bool, error) {
+	keys := make([]string, len(charges))
+	for i, charge := range charges {
+		keys[i] = redisRateLimitPrefix + charge.Key
+	}
+
+	if _, err := conn.Do(ctx, append([]string{"WATCH"}, keys...)...); err != nil {
+		return false, fmt.Errorf("redis watch failed: %w", err)
+	}
+	reply, err := conn.Do(ctx, "TIME")
+	if err != nil {
+		return false, fmt.Errorf("redis time failed: %w", err)
+	}
+	now, err := parseRedisTime(reply)
+	if err != nil {
+		return false, err
+	}
+	reply, err = conn.Do(ctx, append([]string{"MGET"}, keys...)...)
+	if err != nil {
+		return false, fmt.Errorf("redis mget failed: %w", err)
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != len(keys) {
+		return false, fmt.Errorf("unexpected redis mget reply %v", reply)
+	}
+
+	buckets := make([]*tokenBucket, len(charges))
+	for i, charge := range charges {
+		value, _ := values[i].(string)
+		bucket, ok := parseRedisBucket(value)
+		if !ok {
+			// 不存在（已补满过期）或无法解析的值按满桶处理
+			bucket = newTokenBucket(now, charge.Limit)
+		}
+		buckets[i] = bucket
+	}
+
+	fn(now, buckets)
+
+	if _, err := conn.Do(ctx, "MULTI"); err != nil {
+		return false, fmt.Errorf("redis multi failed: %w", err)
+	}
+	for i, charge := range charges {
+		ttl := max(buckets[i].fullAt(charge).Sub(now).Milliseconds(), 1)
+		if _, err := conn.Do(ctx, "SET", keys[i], formatRedisBucket(buckets[i]), "PX", strconv.FormatInt(ttl, 10)); err != nil {
+			return false, fmt.Errorf("redis set failed: %w", err)
+		}
+	}
+	if _, err := conn.Do(ctx, "EXEC"); err != nil {
+		if errors.Is(err, resp.ErrNil) {
+			return false, nil
+		}
+		return false, fmt.Errorf("redis exec failed: %w", err)
+	}
+	return true, nil
+}
+
+// parseRedisTime 解析 TIME 命令的回复（秒和微秒）
+func parseRedisTime(reply any) (time.Time, error) {
+	parts, ok := reply.([]any)
+	if ok && len(parts) == 2 {
+		sec, secOK := parts[0].(string)
+		usec, usecOK := parts[1].(string)
+		s, err1 := strconv.ParseInt(sec, 10, 64)
+		us, err2 := strconv.ParseInt(usec, 10, 64)
+		if secOK && usecOK && err1 == nil && err2 == nil {
+			return time.Unix(s, us*1000), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected redis time reply %v", reply)
+}
+
+// formatRedisBucket 令牌桶的存储格式："<剩余令牌数> <更新时间（微秒）>"
+func formatRedisBucket(b *tokenBucket) string {
+	return strconv.FormatFloat(b.tokens, 'f', -1, 64) + " " + strconv.FormatInt(b.updated.UnixMicro(), 10)
+}
+
+// parseRedisBucket 解析 formatRedisBucket 的结果
+func parseRedisBucket(value string) (*tokenBucket, bool) {
+	tokensStr, updatedStr, ok := strings.Cut(value, " ")
+	if !ok {
+		return nil, false
+	}
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, false
+	}
+	updated, err := strconv.ParseInt(updatedStr, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &tokenBucket{tokens: tokens, updated: time.UnixMicro(updated)}, true
+}
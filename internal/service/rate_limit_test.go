@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// rateLimitTestRepo 支持按 ID 查询 API Key 的 mock repository
+type rateLimitTestRepo struct {
+	*MockUserRepository
+	keys map[int64]*model.APIKey
+}
+
+func (r *rateLimitTestRepo) GetAPIKeyByID(ctx context.Context, id int64) (*model.APIKey, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (r *rateLimitTestRepo) UpdateAPIKeyRateLimits(ctx context.Context, id int64, rpmLimit, tpmLimit *int) error {
+	r.keys[id].RPMLimit = rpmLimit
+	r.keys[id].TPMLimit = tpmLimit
+	return nil
+}
+
+// newRateLimitTestService 创建使用 mock repository 和可控时钟的限流服务
+func newRateLimitTestService(t *testing.T, cfg RateLimitConfig, users ...*model.User) (*RateLimitService, *rateLimitTestRepo, *time.Time) {
+	repo := &rateLimitTestRepo{MockUserRepository: NewMockUserRepository(), keys: make(map[int64]*model.APIKey)}
+	for _, user := range users {
+		require.NoError(t, repo.CreateUser(context.Background(), user))
+	}
+
+	now := time.Now()
+	backend := NewMemoryRateLimitBackend()
+	backend.now = func() time.Time { return now }
+	svc := NewRateLimitService(cfg, repo, backend)
+	svc.now = func() time.Time { return now }
+	return svc, repo, &now
+}
+
+// TestRateLimitService_Requests 测试按角色默认 RPM 限制请求数，额度随时间匀速恢复
+func TestRateLimitService_Requests(t *testing.T) {
+	svc, _, now := newRateLimitTestService(t, RateLimitConfig{User: RateLimits{RPM: 2}},
+		&model.User{Email: "user@example.com", Role: "user"})
+	ctx := context.Background()
+	subject := RateLimitSubject{UserID: 1}
+
+	result, err := svc.Reserve(ctx, subject, 10)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.LimitRequests)
+	assert.Equal(t, 1, result.RemainingRequests)
+	assert.Equal(t, 30*time.Second, result.ResetRequests)
+	assert.Zero(t, result.LimitTokens)
+
+	result, _ = svc.Reserve(ctx, subject, 10)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.RemainingRequests)
+
+	result, _ = svc.Reserve(ctx, subject, 10)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, RateLimitKindRequests, result.Exceeded)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// 30 秒后恢复 1 个请求
+	*now = now.Add(30 * time.Second)
+	result, _ = svc.Reserve(ctx, subject, 10)
+	assert.True(t, result.Allowed)
+}
+
+// TestRateLimitService_Tokens 测试 TPM 预扣和按实际使用量对账
+func TestRateLimitService_Tokens(t *testing.T) {
+	svc, _, _ := newRateLimitTestService(t, RateLimitConfig{User: RateLimits{TPM: 100}},
+		&model.User{Email: "user@example.com", Role: "user"})
+	ctx := context.Background()
+	subject := RateLimitSubject{UserID: 1}
+
+	result, err := svc.Reserve(ctx, subject, 60)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 40, result.RemainingTokens)
+
+	// 实际只使用了 20 个 Token，退回 40 个
+	require.NoError(t, svc.Reconcile(ctx, result, 20))
+	result, _ = svc.Reserve(ctx, subject, 80)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.RemainingTokens)
+
+	// 实际使用超出预扣，额度为负
+	require.NoError(t, svc.Reconcile(ctx, result, 110))
+	result, _ = svc.Reserve(ctx, subject, 1)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, RateLimitKindTokens, result.Exceeded)
+	assert.Equal(t, 18600*time.Millisecond, result.RetryAfter)
+
+	// 被拒绝的请求不需要对账
+	require.NoError(t, svc.Reconcile(ctx, result, 0))
+	result, _ = svc.Reserve(ctx, subject, 1)
+	assert.False(t, result.Allowed)
+}
+
+// TestRateLimitService_TokensOverLimit 测试单个请求超过 TPM 上限时按上限预扣，对账时补扣超出部分
+func TestRateLimitService_TokensOverLimit(t *testing.T) {
+	svc, _, now := newRateLimitTestService(t, RateLimitConfig{User: RateLimits{TPM: 100}},
+		&model.User{Email: "user@example.com", Role: "user"})
+	ctx := context.Background()
+	subject := RateLimitSubject{UserID: 1}
+
+	result, err := svc.Reserve(ctx, subject, 150)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.RemainingTokens)
+
+	// 实际使用 150 个，补扣按上限预扣之外的 50 个
+	require.NoError(t, svc.Reconcile(ctx, result, 150))
+	*now = now.Add(30 * time.Second)
+	result, _ = svc.Reserve(ctx, subject, 1)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 600*time.Millisecond, result.RetryAfter)
+}
+
+// TestRateLimitService_APIKey 测试用户和 API Key 的限制同时生效，超出任一限制时都不扣减
+func TestRateLimitService_APIKey(t *testing.T) {
+	rpm := 1
+	svc, repo, _ := newRateLimitTestService(t, RateLimitConfig{User: RateLimits{RPM: 10}, Admin: RateLimits{RPM: 100}},
+		&model.User{Email: "user@example.com", Role: "user"},
+		&model.User{Email: "admin@example.com", Role: "admin"})
+	repo.keys[1] = &model.APIKey{ID: 1, UserID: 1, RPMLimit: &rpm}
+	repo.keys[2] = &model.APIKey{ID: 2, UserID: 1}
+	ctx := context.Background()
+	key1, key2 := int64(1), int64(2)
+
+	result, err := svc.Reserve(ctx, RateLimitSubject{UserID: 1, APIKeyID: &key1}, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.LimitRequests)
+	assert.Equal(t, 0, result.RemainingRequests)
+
+	result, _ = svc.Reserve(ctx, RateLimitSubject{UserID: 1, APIKeyID: &key1}, 0)
+	assert.False(t, result.Allowed)
+
+	// 同一用户的其他 API Key 只受用户限制，被拒绝的请求没有扣减用户额度
+	result, _ = svc.Reserve(ctx, RateLimitSubject{UserID: 1, APIKeyID: &key2}, 0)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 10, result.LimitRequests)
+	assert.Equal(t, 8, result.RemainingRequests)
+
+	// 管理员使用管理员默认值
+	result, _ = svc.Reserve(ctx, RateLimitSubject{UserID: 2}, 0)
+	assert.Equal(t, 100, result.LimitRequests)
+
+	// 清除 API Key 的限制后立即生效
+	_, err = svc.SetAPIKeyLimits(ctx, 1, nil, nil)
+	require.NoError(t, err)
+	result, _ = svc.Reserve(ctx, RateLimitSubject{UserID: 1, APIKeyID: &key1}, 0)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 10, result.LimitRequests)
+
+	_, err = svc.SetAPIKeyLimits(ctx, 3, nil, nil)
+	assert.IsType(t, &RateLimitTargetNotFoundError{}, err)
+}
+
+// TestRateLimitService_UserOverride 测试用户单独设置的限制优先于角色默认值，0 表示不限制
+func TestRateLimitService_UserOverride(t *testing.T) {
+	svc, _, _ := newRateLimitTestService(t, RateLimitConfig{User: RateLimits{RPM: 10, TPM: 1000}},
+		&model.User{Email: "user@example.com", Role: "user"})
+	ctx := context.Background()
+
+	result, err := svc.Reserve(ctx, RateLimitSubject{UserID: 1}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.LimitRequests)
+
+	rpm, tpm := 0, 5000
+	user, err := svc.SetUserLimits(ctx, 1, &rpm, &tpm)
+	require.NoError(t, err)
+	assert.Equal(t, &tpm, user.TPMLimit)
+
+	result, _ = svc.Reserve(ctx, RateLimitSubject{UserID: 1}, 0)
+	assert.Zero(t, result.LimitRequests)
+	assert.Equal(t, 5000, result.LimitTokens)
+
+	_, err = svc.SetUserLimits(ctx, 2, nil, nil)
+	assert.IsType(t, &RateLimitTargetNotFoundError{}, err)
+}
+
+// TestRateLimitTokens 测试预扣 Token 数的估算
+func TestRateLimitTokens(t *testing.T) {
+	req := &model.ChatRequest{Messages: []model.ChatMessage{{Role: "user", Content: model.TextContent("Hi")}}}
+	prompt := EstimatePromptTokens(req.Messages)
+	assert.Equal(t, prompt, RateLimitTokens(req))
+
+	maxTokens, n := 100, 2
+	req.MaxTokens = &maxTokens
+	assert.Equal(t, prompt+100, RateLimitTokens(req))
+	req.N = &n
+	assert.Equal(t, prompt+200, RateLimitTokens(req))
+}
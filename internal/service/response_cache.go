@@ -0,0 +1,256 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+const (
+	ResponseCacheBackendMemory   = "memory"   // 进程内 LRU，各副本独立
+	ResponseCacheBackendPostgres = "postgres" // response_cache 表，多副本共享
+
+	// responseCacheTrimEvery Postgres 缓存每写入多少次清理一次过期和超出容量的条目
+	responseCacheTrimEvery = 100
+)
+
+// ResponseCache 非流式 Chat 响应缓存，按 ResponseCacheKey 精确匹配
+type ResponseCache interface {
+	// Get 查询缓存的响应，未命中或已过期时返回 nil
+	Get(ctx context.Context, key string) (*model.ChatResponse, error)
+
+	// Set 缓存响应
+	Set(ctx context.Context, key string, resp *model.ChatResponse) error
+}
+
+// ResponseCacheConfig 响应缓存配置
+type ResponseCacheConfig struct {
+	Enabled    bool
+	Backend    string        // memory 或 postgres
+	TTL        time.Duration // 缓存有效期
+	MaxEntries int           // 最大条目数，超出后淘汰最久未使用（postgres 为最早写入）的条目
+}
+
+// DefaultResponseCacheConfig 默认响应缓存配置（默认关闭）
+func DefaultResponseCacheConfig() ResponseCacheConfig {
+	return ResponseCacheConfig{
+		Enabled:    false,
+		Backend:    ResponseCacheBackendMemory,
+		TTL:        time.Hour,
+		MaxEntries: 10000,
+	}
+}
+
+// ResponseCacheConfigFromEnv 从环境变量读取响应缓存配置，未设置或无效时使用默认值
+func ResponseCacheConfigFromEnv() ResponseCacheConfig {
+	cfg := DefaultResponseCacheConfig()
+
+	if v := os.Getenv("RESPONSE_CACHE_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Enabled = b
+		}
+	}
+	if v := os.Getenv("RESPONSE_CACHE_BACKEND"); v != "" {
+		cfg.Backend = v
+	}
+	if v := os.Getenv("RESPONSE_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.TTL = d
+		}
+	}
+	if v := os.Getenv("RESPONSE_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxEntries = n
+		}
+	}
+
+	return cfg
+}
+
+// NewResponseCache 按配置创建响应缓存，未启用时返回 nil
+func NewResponseCache(cfg ResponseCacheConfig, repo repository.ResponseCacheRepository) (ResponseCache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Backend {
+	case ResponseCacheBackendMemory:
+		return NewMemoryResponseCache(cfg.TTL, cfg.MaxEntries), nil
+	case ResponseCacheBackendPostgres:
+		return NewPostgresResponseCache(repo, cfg.TTL, cfg.MaxEntries), nil
+	default:
+		return nil, fmt.Errorf("unsupported response cache backend %q", cfg.Backend)
+	}
+}
+
+// ResponseCacheKey 计算请求的缓存 key，请求不可缓存时返回 false
+// 只缓存结果确定的非流式请求（temperature 为 0 且只生成一个候选）；
+// key 为模型和全部请求参数的规范化 JSON 的 SHA-256，不影响生成结果的 stream_options、user 不参与计算
+func ResponseCacheKey(req *model.ChatRequest) (string, bool) {
+	if req.Stream || req.Temperature == nil || *req.Temperature != 0 || (req.N != nil && *req.N != 1) {
+		return "", false
+	}
+
+	canonical := *req
+	canonical.StreamOptions = nil
+	canonical.User = ""
+	canonical.N = nil
+
+	// 结构体字段按声明顺序、map 按 key 排序序列化，相同参数得到相同的 JSON
+	data, err := json.Marshal(&canonical)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// memoryCacheEntry 内存缓存条目
+type memoryCacheEntry struct {
+	key       string
+	response  []byte // 序列化后的响应，避免调用方修改缓存内容
+	expiresAt time.Time
+}
+
+// MemoryResponseCache 进程内 LRU 响应缓存
+type MemoryResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu    sync.Mutex
+	ll    *list.List               // 最近使用的在前
+	items map[string]*list.Element // key -> ll 中的元素
+	now   func() time.Time
+}
+
+// NewMemoryResponseCache 创建进程内 LRU 响应缓存
+func NewMemoryResponseCache(ttl time.Duration, maxEntries int) *MemoryResponseCache {
+	return &MemoryResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get 查询缓存的响应
+func (c *MemoryResponseCache) Get(ctx context.Context, key string) (*model.ChatResponse, error) {
+	c.mu.Lock()
+	elem, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		c.mu.Unlock()
+		return nil, nil
+	}
+	c.ll.MoveToFront(elem)
+	data := entry.response
+	c.mu.Unlock()
+
+	var resp model.ChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Set 缓存响应，超出容量时淘汰最久未使用的条目
+func (c *MemoryResponseCache) Set(ctx context.Context, key string, resp *model.ChatResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, response: data, expiresAt: c.now().Add(c.ttl)}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.ll.MoveToFront(elem)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len 当前缓存的条目数（含未清理的过期条目）
+func (c *MemoryResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// PostgresResponseCache 基于 response_cache 表的响应缓存，多个网关副本共享
+type PostgresResponseCache struct {
+	repo       repository.ResponseCacheRepository
+	ttl        time.Duration
+	maxEntries int
+	writes     atomic.Int64
+}
+
+// NewPostgresResponseCache 创建 Postgres 响应缓存
+func NewPostgresResponseCache(repo repository.ResponseCacheRepository, ttl time.Duration, maxEntries int) *PostgresResponseCache {
+	return &PostgresResponseCache{repo: repo, ttl: ttl, maxEntries: maxEntries}
+}
+
+// Get 查询缓存的响应
+func (c *PostgresResponseCache) Get(ctx context.Context, key string) (*model.ChatResponse, error) {
+	entry, err := c.repo.Get(ctx, key)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	var resp model.ChatResponse
+	if err := json.Unmarshal(entry.Response, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Set 缓存响应，每写入 responseCacheTrimEvery 次清理一次过期和超出容量的条目
+func (c *PostgresResponseCache) Set(ctx context.Context, key string, resp *model.ChatResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	err = c.repo.Upsert(ctx, &model.ResponseCacheEntry{
+		Key:       key,
+		Response:  data,
+		ExpiresAt: time.Now().Add(c.ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.writes.Add(1)%responseCacheTrimEvery == 0 {
+		if _, err := c.repo.Trim(ctx, c.maxEntries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
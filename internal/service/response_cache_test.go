@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestResponseCacheKey 测试只有结果确定的非流式请求可缓存，key 只取决于影响生成结果的参数
+func TestResponseCacheKey(t *testing.T) {
+	zero, half := 0.0, 0.5
+	one, two := 1, 2
+	newReq := func() *model.ChatRequest {
+		return &model.ChatRequest{
+			Model:       "openai/gpt-4o",
+			Messages:    []model.ChatMessage{{Role: "user", Content: model.TextContent("Hi")}},
+			Temperature: &zero,
+		}
+	}
+
+	key, ok := ResponseCacheKey(newReq())
+	require.True(t, ok)
+	assert.Len(t, key, 64)
+
+	// user、n=1 不影响 key
+	req := newReq()
+	req.User = "user-1"
+	req.N = &one
+	other, ok := ResponseCacheKey(req)
+	require.True(t, ok)
+	assert.Equal(t, key, other)
+
+	// 模型或参数不同时 key 不同
+	req = newReq()
+	req.Model = "azure/gpt-4o"
+	other, _ = ResponseCacheKey(req)
+	assert.NotEqual(t, key, other)
+
+	for name, mutate := range map[string]func(*model.ChatRequest){
+		"stream":         func(r *model.ChatRequest) { r.Stream = true },
+		"no temperature": func(r *model.ChatRequest) { r.Temperature = nil },
+		"temperature":    func(r *model.ChatRequest) { r.Temperature = &half },
+		"n":              func(r *model.ChatRequest) { r.N = &two },
+	} {
+		req := newReq()
+		mutate(req)
+		_, ok := ResponseCacheKey(req)
+		assert.False(t, ok, name)
+	}
+}
+
+// TestMemoryResponseCache 测试 LRU 淘汰和过期
+func TestMemoryResponseCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	cache := NewMemoryResponseCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	require.NoError(t, cache.Set(ctx, "a", &model.ChatResponse{ID: "a"}))
+	require.NoError(t, cache.Set(ctx, "b", &model.ChatResponse{ID: "b"}))
+
+	// 读取 a 后 b 最久未使用，写入 c 时淘汰 b
+	resp, err := cache.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", resp.ID)
+	require.NoError(t, cache.Set(ctx, "c", &model.ChatResponse{ID: "c"}))
+	resp, _ = cache.Get(ctx, "b")
+	assert.Nil(t, resp)
+	assert.Equal(t, 2, cache.Len())
+
+	// 修改返回的响应不影响缓存
+	resp, _ = cache.Get(ctx, "a")
+	resp.ID = "changed"
+	resp, _ = cache.Get(ctx, "a")
+	assert.Equal(t, "a", resp.ID)
+
+	// 过期后未命中
+	now = now.Add(time.Minute)
+	resp, _ = cache.Get(ctx, "c")
+	assert.Nil(t, resp)
+	assert.Equal(t, 1, cache.Len())
+}
@@ -6,59 +6,80 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"os"
 	"strings"
 	"syscall"
 	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+const (
+	// maxRateLimitRetries 上游限流时同一模型的最大重试次数
+	maxRateLimitRetries = 2
+	// rateLimitBaseBackoff 上游限流且未返回 Retry-After 时的初始退避时间
+	rateLimitBaseBackoff = time.Second
+	// maxRetryAfterWait 重试前等待时间（Retry-After 或退避）的上限，超过时不再重试
+	maxRetryAfterWait = 10 * time.Second
 )
 
 // AttemptDetail 单次尝试详情
 type AttemptDetail struct {
-	ModelName string        `json:"model_name"`
-	Error     error         `json:"-"`
-	ErrorType string        `json:"error_type"`
-	Duration  time.Duration `json:"duration_ms"`
+	ProviderName string        `json:"provider_name,omitempty"` // 跨 Provider Fallback 时的 Provider 名称
+	ModelName    string        `json:"model_name"`
+	Attempt      int           `json:"attempt"`              // 同一模型上的第几次尝试，从 1 开始
+	Backoff      time.Duration `json:"backoff_ms,omitempty"` // 本次尝试前的退避等待时间
+	Hedge        bool          `json:"hedge,omitempty"`      // 是否为对冲请求（主请求超过对冲延迟未返回时发出）
+	Error        error         `json:"-"`
+	ErrorType    string        `json:"error_type"`
+	Duration     time.Duration `json:"duration_ms"`
 }
 
 // RetryResult 重试结果
 type RetryResult struct {
-	Success         bool             `json:"success"`
-	FallbackCount   int              `json:"fallback_count"`
-	FinalModelName  string           `json:"final_model_name"`
-	AttemptDetails  []AttemptDetail  `json:"attempt_details"`
-	TotalDuration   time.Duration    `json:"total_duration_ms"`
-	Response        any              `json:"-"` // 成功时的响应
+	Success           bool            `json:"success"`
+	FallbackCount     int             `json:"fallback_count"`
+	FinalModelName    string          `json:"final_model_name"`
+	FinalProviderName string          `json:"final_provider_name,omitempty"`
+	Hedged            bool            `json:"hedged,omitempty"` // 是否发出了对冲请求
+	AttemptDetails    []AttemptDetail `json:"attempt_details"`
+	TotalDuration     time.Duration   `json:"total_duration_ms"`
+	Response          any             `json:"-"` // 成功时的响应
 }
 
 // RetryableFunc 可重试的函数类型
 type RetryableFunc func(ctx context.Context, modelName string) (any, error)
 
 // RetryService 重试服务
-type RetryService struct{}
+type RetryService struct {
+	breakers *BreakerRegistry // 为 nil 时不熔断
+}
 
 // NewRetryService 创建重试服务
 func NewRetryService() *RetryService {
 	return &RetryService{}
 }
 
+// NewRetryServiceWithBreakers 创建带熔断的重试服务，已熔断的目标会被跳过
+func NewRetryServiceWithBreakers(breakers *BreakerRegistry) *RetryService {
+	return &RetryService{breakers: breakers}
+}
+
 // IsRetryableError 判断错误是否可重试
 func (s *RetryService) IsRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
-	// 网络错误
-	var netErr net.Error
-	if errors.As(err, &netErr) {
+	// Fallback 引用的 Provider 未运行（未启用或初始化失败），跳过该项继续 Fallback
+	var notFoundErr *ProviderNotFoundError
+	if errors.As(err, &notFoundErr) {
 		return true
 	}
 
-	// 系统调用错误（如连接拒绝）
-	var sysErr *os.SyscallError
-	if errors.As(err, &sysErr) {
-		if sysErr.Err == syscall.ECONNREFUSED {
-			return true
-		}
+	// 上游 HTTP 错误按状态码判断：请求超时、限流和 5xx 可重试
+	var upErr *adapter.UpstreamError
+	if errors.As(err, &upErr) {
+		return upErr.Retryable()
 	}
 
 	// 超时错误
@@ -66,22 +87,26 @@ func (s *RetryService) IsRetryableError(err error) bool {
 		return true
 	}
 
-	// 检查错误消息内容
-	errMsg := err.Error()
-	// 5xx 服务器错误
-	if containsStatusCode(errMsg, 500, 502, 503, 504) {
+	// 连接被拒绝或被重置
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
 		return true
 	}
 
-	// 连接失败、超时、DNS 解析失败等
-	if containsSubstring(errMsg, "timeout", "connection refused", "connection reset", "dns", "no such host") {
+	// 其他网络错误（超时、DNS 解析失败等）
+	var netErr net.Error
+	if errors.As(err, &netErr) {
 		return true
 	}
 
+	// 只按错误类型判断，不匹配错误消息（消息中可能包含模型名、用户输入等任意文本）
 	return false
 }
 
 // RetryWithFallback 带 Fallback 的重试逻辑
+// fallbackModels 中的项可以是 `model_name` 或 `provider/model_name`，原样传给 retryableFunc
+// 配置了熔断器时，跳过已熔断的 `provider/model_name`，并记录每次尝试的结果
+// 每个模型按所属 Provider 的重试策略（extra_config.retry_policy）在同一模型上重试，之后再 Fallback；
+// 上游限流（429）时优先 Fallback，已是最后一个模型时按 Retry-After（未返回时指数退避）等待后重试
 func (s *RetryService) RetryWithFallback(
 	ctx context.Context,
 	fallbackModels []string,
@@ -97,69 +122,160 @@ func (s *RetryService) RetryWithFallback(
 		return nil, errors.New("no fallback models provided")
 	}
 
+	// 重试时间预算以请求的模型所属 Provider 为准
+	var budgetDeadline time.Time
+	if budget := retryPolicyFor(fallbackModels[0]).Budget; budget > 0 {
+		budgetDeadline = startTime.Add(budget)
+	}
+	overBudget := func(wait time.Duration) bool {
+		return !budgetDeadline.IsZero() && time.Now().Add(wait).After(budgetDeadline)
+	}
+
 	// 依次尝试每个模型
+	var lastErr error
 	for i, modelName := range fallbackModels {
-		attemptStart := time.Now()
-		detail := AttemptDetail{
-			ModelName: modelName,
+		if i > 0 && overBudget(0) {
+			result.TotalDuration = time.Since(startTime)
+			return result, fmt.Errorf("retry budget exhausted after %d attempts: %w", len(result.AttemptDetails), lastErr)
 		}
 
-		// 执行函数
-		resp, err := retryableFunc(ctx, modelName)
-		detail.Duration = time.Since(attemptStart)
-
-		if err == nil {
-			// 成功
-			detail.ErrorType = ""
-			result.Success = true
-			result.FallbackCount = i
-			result.FinalModelName = modelName
-			result.Response = resp
-			result.AttemptDetails = append(result.AttemptDetails, detail)
-			result.TotalDuration = time.Since(startTime)
-			return result, nil
+		providerName, name, hasProvider := strings.Cut(modelName, "/")
+		if !hasProvider {
+			providerName, name = "", modelName
 		}
 
-		// 失败
-		detail.Error = err
-		detail.ErrorType = classifyError(err)
-		result.AttemptDetails = append(result.AttemptDetails, detail)
+		breakerRef := hasProvider && s.breakers != nil
+		policy := retryPolicyFor(modelName)
+		var backoff time.Duration
+		for attempt := 1; ; attempt++ {
+			attemptStart := time.Now()
+			detail := AttemptDetail{
+				ProviderName: providerName,
+				ModelName:    name,
+				Attempt:      attempt,
+				Backoff:      backoff,
+			}
+
+			// 每次尝试前检查熔断器，已熔断（或半开状态探测名额已用完）时跳过该目标
+			if breakerRef && !s.breakers.Allow(modelName) {
+				lastErr = &CircuitOpenError{Ref: modelName}
+				detail.Error = lastErr
+				detail.ErrorType = "circuit_open"
+				result.AttemptDetails = append(result.AttemptDetails, detail)
+				break
+			}
+
+			// 执行函数
+			resp, err := retryableFunc(ctx, modelName)
+			detail.Duration = time.Since(attemptStart)
+			if breakerRef {
+				s.recordBreaker(ctx, modelName, err)
+			}
+
+			if err == nil {
+				// 成功
+				result.Success = true
+				result.FallbackCount = i
+				result.FinalModelName = detail.ModelName
+				result.FinalProviderName = detail.ProviderName
+				result.Response = resp
+				result.AttemptDetails = append(result.AttemptDetails, detail)
+				result.TotalDuration = time.Since(startTime)
+				return result, nil
+			}
+
+			// 失败
+			lastErr = err
+			detail.Error = err
+			detail.ErrorType = classifyError(err)
+			result.AttemptDetails = append(result.AttemptDetails, detail)
 
-		// 判断是否可重试
-		if !s.IsRetryableError(err) {
-			// 不可重试错误，直接返回
-			result.TotalDuration = time.Since(startTime)
-			return result, fmt.Errorf("non-retryable error with model %s: %w", modelName, err)
+			// 判断是否可重试
+			if !s.IsRetryableError(err) {
+				// 不可重试错误，直接返回
+				result.TotalDuration = time.Since(startTime)
+				return result, fmt.Errorf("non-retryable error with model %s: %w", modelName, err)
+			}
+
+			// 在同一模型上重试
+			wait, ok := retryWait(ctx, policy, err, detail.ErrorType, attempt, i == len(fallbackModels)-1)
+			if !ok || overBudget(wait) {
+				break
+			}
+			log.Printf("[WARN] Model %s failed (%s), retrying in %s", modelName, err, wait)
+			if sleepContext(ctx, wait) != nil {
+				break
+			}
+			backoff = wait
 		}
 
 		// 记录日志
-		log.Printf("[WARN] Model %s failed (%s), trying next fallback model", modelName, err)
+		log.Printf("[WARN] Model %s failed (%s), trying next fallback model", modelName, lastErr)
 	}
 
 	// 所有模型都失败
 	result.TotalDuration = time.Since(startTime)
-	return result, fmt.Errorf("all models failed after %d attempts", len(fallbackModels))
+	return result, fmt.Errorf("all models failed after %d attempts: %w", len(result.AttemptDetails), lastErr)
 }
 
-// containsStatusCode 检查错误消息是否包含指定的 HTTP 状态码
-func containsStatusCode(msg string, codes ...int) bool {
-	for _, code := range codes {
-		if strings.Contains(msg, fmt.Sprintf("%d", code)) {
-			return true
+// retryWait 第 attempt 次尝试失败后，计算在同一模型上重试前的等待时间
+// 错误类型在重试策略的 retry_on 中且未达到最大尝试次数时按策略退避，上游返回 Retry-After 时至少等待该时间；
+// 最后一个模型被限流时，即使策略不重试也按 Retry-After 重试（最多 maxRateLimitRetries 次）；
+// 等待时间超过上限或超出请求截止时间时不重试
+func retryWait(ctx context.Context, policy RetryPolicy, err error, errorType string, attempt int, last bool) (time.Duration, bool) {
+	var upErr *adapter.UpstreamError
+	hasUpstream := errors.As(err, &upErr)
+
+	var wait time.Duration
+	switch {
+	case policy.shouldRetry(attempt, errorType):
+		wait = policy.backoff(attempt)
+		if hasUpstream && upErr.RetryAfter > wait {
+			wait = upErr.RetryAfter
 		}
+	case last && hasUpstream && upErr.RateLimited() && attempt <= maxRateLimitRetries:
+		wait = upErr.RetryAfter
+		if wait == 0 {
+			wait = rateLimitBaseBackoff << (attempt - 1)
+		}
+	default:
+		return 0, false
 	}
-	return false
+
+	if wait > maxRetryAfterWait {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return 0, false
+	}
+	return wait, true
 }
 
-// containsSubstring 检查字符串是否包含子串（忽略大小写）
-func containsSubstring(s string, substrs ...string) bool {
-	sLower := strings.ToLower(s)
-	for _, sub := range substrs {
-		if strings.Contains(sLower, strings.ToLower(sub)) {
-			return true
-		}
+// sleepContext 等待指定时间，ctx 取消时提前返回错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recordBreaker 记录尝试结果到熔断器
+// 只有可重试错误（超时、连接失败、5xx 等）计为失败；客户端取消请求或 Provider 未运行时不计入结果
+func (s *RetryService) recordBreaker(ctx context.Context, ref string, err error) {
+	var notFoundErr *ProviderNotFoundError
+	switch {
+	case err == nil:
+		s.breakers.Record(ref, true)
+	case errors.Is(ctx.Err(), context.Canceled), errors.As(err, &notFoundErr):
+		s.breakers.Release(ref)
+	default:
+		s.breakers.Record(ref, !s.IsRetryableError(err))
 	}
-	return false
 }
 
 // classifyError 分类错误类型
@@ -168,31 +284,51 @@ func classifyError(err error) string {
 		return "unknown"
 	}
 
-	errMsg := strings.ToLower(err.Error())
+	var notFoundErr *ProviderNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return "provider_unavailable"
+	}
+
+	// 上游 HTTP 错误按状态码分类
+	var upErr *adapter.UpstreamError
+	if errors.As(err, &upErr) {
+		switch {
+		case upErr.RateLimited():
+			return "rate_limited"
+		case upErr.StatusCode == 408 || upErr.StatusCode == 504:
+			return "timeout"
+		case upErr.StatusCode >= 500:
+			return "server_error"
+		case upErr.StatusCode == 401 || upErr.StatusCode == 403:
+			return "auth_error"
+		default:
+			return "client_error"
+		}
+	}
 
 	// 超时错误
-	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(errMsg, "timeout") {
+	if errors.Is(err, context.DeadlineExceeded) {
 		return "timeout"
 	}
 
 	// 连接错误
-	if strings.Contains(errMsg, "connection refused") || strings.Contains(errMsg, "connection reset") || strings.Contains(errMsg, "econnrefused") {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
 		return "connection_error"
 	}
 
 	// DNS 错误
-	if strings.Contains(errMsg, "no such host") || strings.Contains(errMsg, "dns") {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
 		return "dns_error"
 	}
 
-	// 5xx 错误
-	if containsStatusCode(errMsg, 500, 502, 503, 504) {
-		return "server_error"
-	}
-
-	// 4xx 错误
-	if containsStatusCode(errMsg, 400, 401, 403, 404) {
-		return "client_error"
+	// 其他网络错误
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "connection_error"
 	}
 
 	return "unknown"
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// RetryPolicy 单个 Provider 的重试策略，来自 extra_config.retry_policy
+type RetryPolicy struct {
+	MaxAttempts int           // 每个模型的最大尝试次数（含首次），默认 1，即失败后直接 Fallback
+	BaseBackoff time.Duration // 同一模型重试的初始退避时间，之后每次翻倍
+	MaxBackoff  time.Duration // 退避时间上限
+	RetryOn     []string      // 在同一模型上重试的错误类型（见 classifyError），默认不含 rate_limited，限流时优先 Fallback
+	Budget      time.Duration // 整个请求（含 Fallback）的重试时间预算，超出后不再发起新的尝试，0 表示只受请求超时限制
+}
+
+// DefaultRetryPolicy 默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 1,
+		BaseBackoff: 200 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		RetryOn:     []string{"timeout", "connection_error", "server_error"},
+	}
+}
+
+// RetryPolicyOf 解析 Provider 的重试策略，未配置的字段使用默认值
+func RetryPolicyOf(provider adapter.Provider) RetryPolicy {
+	policy := DefaultRetryPolicy()
+
+	raw, _ := provider.Config()["retry_policy"].(map[string]any)
+	if v, ok := raw["max_attempts"].(float64); ok && v >= 1 {
+		policy.MaxAttempts = int(v)
+	}
+	if v, ok := raw["base_backoff_ms"].(float64); ok && v >= 0 {
+		policy.BaseBackoff = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := raw["max_backoff_ms"].(float64); ok && v >= 0 {
+		policy.MaxBackoff = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := raw["budget_ms"].(float64); ok && v >= 0 {
+		policy.Budget = time.Duration(v) * time.Millisecond
+	}
+	if v, ok := raw["retry_on"].([]any); ok {
+		policy.RetryOn = make([]string, 0, len(v))
+		for _, item := range v {
+			if class, ok := item.(string); ok {
+				policy.RetryOn = append(policy.RetryOn, class)
+			}
+		}
+	}
+
+	return policy
+}
+
+// retryPolicyFor 获取 `provider/model_name` 所属 Provider 的重试策略，Provider 未注册或未带前缀时使用默认策略
+func retryPolicyFor(ref string) RetryPolicy {
+	providerName, _, ok := strings.Cut(ref, "/")
+	if !ok {
+		return DefaultRetryPolicy()
+	}
+	provider, ok := adapter.GetProvider(providerName)
+	if !ok {
+		return DefaultRetryPolicy()
+	}
+	return RetryPolicyOf(provider)
+}
+
+// shouldRetry 第 attempt 次尝试（从 1 开始）失败后是否在同一模型上重试
+func (p RetryPolicy) shouldRetry(attempt int, errorType string) bool {
+	return attempt < p.MaxAttempts && slices.Contains(p.RetryOn, errorType)
+}
+
+// backoff 第 attempt 次尝试失败后的退避时间：指数退避，取上限后在 [d/2, d] 之间随机
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
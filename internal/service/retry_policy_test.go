@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+)
+
+// TestRetryPolicyOf 测试解析 extra_config.retry_policy
+func TestRetryPolicyOf(t *testing.T) {
+	policy := RetryPolicyOf(&fakeProvider{name: "openai", config: map[string]any{}})
+	assert.Equal(t, DefaultRetryPolicy(), policy)
+
+	policy = RetryPolicyOf(&fakeProvider{name: "openai", config: map[string]any{
+		"retry_policy": map[string]any{
+			"max_attempts":    float64(3),
+			"base_backoff_ms": float64(100),
+			"max_backoff_ms":  float64(1000),
+			"budget_ms":       float64(5000),
+			"retry_on":        []any{"server_error", "rate_limited"},
+		},
+	}})
+	assert.Equal(t, RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+		RetryOn:     []string{"server_error", "rate_limited"},
+		Budget:      5 * time.Second,
+	}, policy)
+}
+
+// TestRetryPolicy_Backoff 测试指数退避的取值范围
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for range 100 {
+		d := policy.backoff(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 100*time.Millisecond, "attempt 1: %s", d)
+		d = policy.backoff(2)
+		assert.True(t, d >= 100*time.Millisecond && d <= 200*time.Millisecond, "attempt 2: %s", d)
+		d = policy.backoff(5)
+		assert.True(t, d >= 150*time.Millisecond && d <= 300*time.Millisecond, "attempt 5: %s", d)
+	}
+}
+
+// TestRetryWithFallback_RetryPolicy 测试按重试策略在同一模型上重试后再 Fallback
+func TestRetryWithFallback_RetryPolicy(t *testing.T) {
+	registerTestProviders(t, &fakeProvider{name: "openai", config: map[string]any{
+		"retry_policy": map[string]any{
+			"max_attempts":    float64(3),
+			"base_backoff_ms": float64(1),
+			"max_backoff_ms":  float64(2),
+		},
+	}})
+	svc := NewRetryService()
+
+	var calls []string
+	result, err := svc.RetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		calls = append(calls, ref)
+		if ref == "openai/gpt-4o" {
+			return nil, &adapter.UpstreamError{StatusCode: 500}
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai/gpt-4o", "openai/gpt-4o", "openai/gpt-4o", "azure/gpt-4o"}, calls)
+
+	require.Len(t, result.AttemptDetails, 4)
+	for i, want := range []int{1, 2, 3, 1} {
+		assert.Equal(t, want, result.AttemptDetails[i].Attempt)
+	}
+	assert.Zero(t, result.AttemptDetails[0].Backoff)
+	assert.Positive(t, result.AttemptDetails[1].Backoff)
+	assert.Equal(t, "server_error", result.AttemptDetails[2].ErrorType)
+}
+
+// TestRetryWithFallback_RetryOn 测试不在 retry_on 中的错误类型直接 Fallback
+func TestRetryWithFallback_RetryOn(t *testing.T) {
+	registerTestProviders(t, &fakeProvider{name: "openai", config: map[string]any{
+		"retry_policy": map[string]any{
+			"max_attempts": float64(3),
+			"retry_on":     []any{"timeout"},
+		},
+	}})
+	svc := NewRetryService()
+
+	var calls []string
+	_, err := svc.RetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		calls = append(calls, ref)
+		if ref == "openai/gpt-4o" {
+			return nil, &adapter.UpstreamError{StatusCode: 502}
+		}
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai/gpt-4o", "azure/gpt-4o"}, calls)
+}
+
+// TestRetryWithFallback_Budget 测试超出重试时间预算后不再发起新的尝试
+func TestRetryWithFallback_Budget(t *testing.T) {
+	registerTestProviders(t, &fakeProvider{name: "openai", config: map[string]any{
+		"retry_policy": map[string]any{
+			"max_attempts": float64(5),
+			"budget_ms":    float64(20),
+		},
+	}})
+	svc := NewRetryService()
+
+	var calls []string
+	result, err := svc.RetryWithFallback(context.Background(), []string{"openai/gpt-4o", "azure/gpt-4o"}, func(ctx context.Context, ref string) (any, error) {
+		calls = append(calls, ref)
+		time.Sleep(30 * time.Millisecond)
+		return nil, &adapter.UpstreamError{StatusCode: 503}
+	})
+	require.ErrorContains(t, err, "retry budget exhausted")
+	assert.Equal(t, []string{"openai/gpt-4o"}, calls)
+	assert.False(t, result.Success)
+}
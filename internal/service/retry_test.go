@@ -5,9 +5,12 @@ import (
 	"errors"
 	"net"
 	"os"
+	"slices"
 	"syscall"
 	"testing"
 	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
 )
 
 // TestIsRetryableError 测试错误分类
@@ -15,48 +18,83 @@ func TestIsRetryableError(t *testing.T) {
 	svc := NewRetryService()
 
 	tests := []struct {
-		name     string
-		err      error
+		name      string
+		err       error
 		retryable bool
 	}{
 		{
-			name:     "网络错误",
-			err:      &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			name:      "网络错误",
+			err:       &net.OpError{Op: "dial", Err: errors.New("connection refused")},
 			retryable: true,
 		},
 		{
-			name:     "超时错误",
-			err:      context.DeadlineExceeded,
+			name:      "超时错误",
+			err:       context.DeadlineExceeded,
 			retryable: true,
 		},
 		{
-			name:     "系统调用错误 - ECONNREFUSED",
-			err:      &net.OpError{Err: &os.SyscallError{Err: syscall.ECONNREFUSED}},
+			name:      "系统调用错误 - ECONNREFUSED",
+			err:       &net.OpError{Err: &os.SyscallError{Err: syscall.ECONNREFUSED}},
 			retryable: true,
 		},
 		{
-			name:     "5xx 错误消息",
-			err:      errors.New("HTTP 500: Internal Server Error"),
+			name:      "系统调用错误 - ECONNRESET",
+			err:       &net.OpError{Op: "read", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}},
 			retryable: true,
 		},
 		{
-			name:     "timeout 错误消息",
-			err:      errors.New("request timeout after 30s"),
+			name:      "DNS 解析失败",
+			err:       &net.DNSError{Err: "no such host", Name: "api.openai.com", IsNotFound: true},
 			retryable: true,
 		},
 		{
-			name:     "4xx 客户端错误",
-			err:      errors.New("HTTP 400: Bad Request"),
+			name:      "未分类的错误消息中包含 5xx",
+			err:       errors.New("HTTP 500: Internal Server Error"),
+			retryable: false,
+		},
+		{
+			name:      "未分类的错误消息中包含 timeout",
+			err:       errors.New("request timeout after 30s"),
+			retryable: false,
+		},
+		{
+			name:      "模型名中包含 dns",
+			err:       errors.New("model dns-resolver-7b not found"),
+			retryable: false,
+		},
+		{
+			name:      "4xx 客户端错误",
+			err:       errors.New("HTTP 400: Bad Request"),
+			retryable: false,
+		},
+		{
+			name:      "认证失败",
+			err:       errors.New("authentication failed: invalid API key"),
 			retryable: false,
 		},
 		{
-			name:     "认证失败",
-			err:      errors.New("authentication failed: invalid API key"),
+			name:      "消息中包含非状态码的数字",
+			err:       errors.New("prompt is too long: 5000 tokens"),
+			retryable: false,
+		},
+		{
+			name:      "上游限流",
+			err:       &adapter.UpstreamError{StatusCode: 429},
+			retryable: true,
+		},
+		{
+			name:      "上游 503",
+			err:       &adapter.UpstreamError{StatusCode: 503},
+			retryable: true,
+		},
+		{
+			name:      "上游 400（消息中包含 500）",
+			err:       &adapter.UpstreamError{StatusCode: 400, Message: "max_tokens must be less than 500"},
 			retryable: false,
 		},
 		{
-			name:     "nil 错误",
-			err:      nil,
+			name:      "nil 错误",
+			err:       nil,
 			retryable: false,
 		},
 	}
@@ -81,7 +119,7 @@ func TestRetryWithFallback_Success(t *testing.T) {
 	mockFunc := func(ctx context.Context, modelName string) (any, error) {
 		attemptCount++
 		if modelName == "model-1" {
-			return nil, errors.New("timeout")
+			return nil, context.DeadlineExceeded
 		}
 		return "success", nil
 	}
@@ -115,7 +153,7 @@ func TestRetryWithFallback_AllFailed(t *testing.T) {
 	ctx := context.Background()
 
 	mockFunc := func(ctx context.Context, modelName string) (any, error) {
-		return nil, errors.New("timeout")
+		return nil, context.DeadlineExceeded
 	}
 
 	result, err := svc.RetryWithFallback(ctx, []string{"model-1", "model-2", "model-3"}, mockFunc)
@@ -225,6 +263,92 @@ func TestRetryWithFallback_FirstSuccess(t *testing.T) {
 	}
 }
 
+// TestRetryWithFallback_RateLimited 测试限流时优先 Fallback，最后一个模型按 Retry-After 重试
+func TestRetryWithFallback_RateLimited(t *testing.T) {
+	svc := NewRetryService()
+	ctx := context.Background()
+
+	var calls []string
+	mockFunc := func(ctx context.Context, modelName string) (any, error) {
+		calls = append(calls, modelName)
+		if len(calls) <= 2 {
+			return nil, &adapter.UpstreamError{StatusCode: 429, RetryAfter: 10 * time.Millisecond}
+		}
+		return "success", nil
+	}
+
+	result, err := svc.RetryWithFallback(ctx, []string{"model-1", "model-2"}, mockFunc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// model-1 限流后直接 Fallback，model-2 限流后等待重试
+	if want := []string{"model-1", "model-2", "model-2"}; !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+	if result.FallbackCount != 1 || len(result.AttemptDetails) != 3 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.AttemptDetails[0].ErrorType != "rate_limited" {
+		t.Errorf("expected error type rate_limited, got %s", result.AttemptDetails[0].ErrorType)
+	}
+}
+
+// TestRetryWithFallback_ProviderUnavailable 测试 Fallback 引用的 Provider 未运行时跳过该项，继续尝试后续模型
+func TestRetryWithFallback_ProviderUnavailable(t *testing.T) {
+	svc := NewRetryService()
+	ctx := context.Background()
+
+	var calls []string
+	mockFunc := func(ctx context.Context, ref string) (any, error) {
+		calls = append(calls, ref)
+		switch ref {
+		case "openai/gpt-4o":
+			return nil, &adapter.UpstreamError{StatusCode: 503}
+		case "azure/gpt-4o":
+			return nil, &ProviderNotFoundError{ProviderName: "azure"}
+		}
+		return "success", nil
+	}
+
+	result, err := svc.RetryWithFallback(ctx, []string{"openai/gpt-4o", "azure/gpt-4o", "openai/gpt-4o-mini"}, mockFunc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"openai/gpt-4o", "azure/gpt-4o", "openai/gpt-4o-mini"}; !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+	if result.FallbackCount != 2 || len(result.AttemptDetails) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if detail := result.AttemptDetails[1]; detail.ProviderName != "azure" || detail.ErrorType != "provider_unavailable" {
+		t.Errorf("expected azure attempt to be provider_unavailable, got %+v", detail)
+	}
+}
+
+// TestRetryWithFallback_RetryAfterTooLong 测试 Retry-After 超过上限时不等待
+func TestRetryWithFallback_RetryAfterTooLong(t *testing.T) {
+	svc := NewRetryService()
+	ctx := context.Background()
+
+	attemptCount := 0
+	mockFunc := func(ctx context.Context, modelName string) (any, error) {
+		attemptCount++
+		return nil, &adapter.UpstreamError{StatusCode: 429, RetryAfter: time.Minute}
+	}
+
+	_, err := svc.RetryWithFallback(ctx, []string{"model-1"}, mockFunc)
+
+	var upErr *adapter.UpstreamError
+	if !errors.As(err, &upErr) {
+		t.Fatalf("expected wrapped UpstreamError, got %v", err)
+	}
+	if attemptCount != 1 {
+		t.Errorf("expected 1 attempt, got %d", attemptCount)
+	}
+}
+
 // TestClassifyError 测试错误分类
 func TestClassifyError(t *testing.T) {
 	tests := []struct {
@@ -232,11 +356,18 @@ func TestClassifyError(t *testing.T) {
 		expected string
 	}{
 		{context.DeadlineExceeded, "timeout"},
-		{errors.New("request timeout"), "timeout"},
-		{errors.New("connection refused"), "connection_error"},
-		{errors.New("no such host"), "dns_error"},
-		{errors.New("HTTP 500"), "server_error"},
-		{errors.New("HTTP 400"), "client_error"},
+		{&net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}, "connection_error"},
+		{&net.OpError{Op: "read", Err: syscall.ECONNRESET}, "connection_error"},
+		{&net.DNSError{Err: "no such host", Name: "api.openai.com", IsNotFound: true}, "dns_error"},
+		{&net.DNSError{Err: "i/o timeout", Name: "api.openai.com", IsTimeout: true}, "dns_error"},
+		{&adapter.UpstreamError{StatusCode: 504}, "timeout"},
+		{&adapter.UpstreamError{StatusCode: 400}, "client_error"},
+		{errors.New("request timeout"), "unknown"},
+		{errors.New("HTTP 500"), "unknown"},
+		{errors.New("context length is 5000 tokens"), "unknown"},
+		{&adapter.UpstreamError{StatusCode: 429}, "rate_limited"},
+		{&adapter.UpstreamError{StatusCode: 401}, "auth_error"},
+		{&adapter.UpstreamError{StatusCode: 502}, "server_error"},
 		{errors.New("unknown error"), "unknown"},
 	}
 
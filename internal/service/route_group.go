@@ -0,0 +1,142 @@
+package service
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// latencyWindow 计算延迟中位数时使用的最近样本数
+const latencyWindow = 64
+
+// targetStats 路由目标（provider/model_name）的实时统计
+type targetStats struct {
+	inFlight  int
+	latencies []time.Duration // 最近的延迟样本（环形缓冲）
+	next      int
+}
+
+// p50 最近延迟的中位数，没有样本时为 0（优先探测新目标）
+func (t *targetStats) p50() time.Duration {
+	if len(t.latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(t.latencies)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// statsOf 获取路由目标的统计，调用方需持有 s.mu
+func (s *RouterService) statsOf(ref string) *targetStats {
+	stats, ok := s.stats[ref]
+	if !ok {
+		stats = &targetStats{}
+		s.stats[ref] = stats
+	}
+	return stats
+}
+
+// BeginRequest 记录路由目标开始处理请求，返回的函数在请求结束时调用（多次调用只生效一次）
+func (s *RouterService) BeginRequest(ref string) func() {
+	s.mu.Lock()
+	s.statsOf(ref).inFlight++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.statsOf(ref).inFlight--
+			s.mu.Unlock()
+		})
+	}
+}
+
+// RecordLatency 记录路由目标成功响应的延迟（流式请求为收到第一个数据块的延迟）
+func (s *RouterService) RecordLatency(ref string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.statsOf(ref)
+	if len(stats.latencies) < latencyWindow {
+		stats.latencies = append(stats.latencies, latency)
+		return
+	}
+	stats.latencies[stats.next] = latency
+	stats.next = (stats.next + 1) % latencyWindow
+}
+
+// orderTargets 按别名的路由策略排列目标，第一个为首选，其余依次作为 Fallback
+func (s *RouterService) orderTargets(alias *model.ModelAlias) []string {
+	switch alias.Strategy {
+	case model.RouteStrategyWeighted:
+		return weightedOrder(alias.Targets)
+	case model.RouteStrategyRoundRobin:
+		return s.rotate(alias)
+	case model.RouteStrategyLeastInFlight:
+		// 先轮询再稳定排序，负载相同时依次分配
+		order := s.rotate(alias)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		slices.SortStableFunc(order, func(a, b string) int {
+			return cmp.Compare(s.statsOf(a).inFlight, s.statsOf(b).inFlight)
+		})
+		return order
+	case model.RouteStrategyLowestLatency:
+		order := s.rotate(alias)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		p50 := make(map[string]time.Duration, len(order))
+		for _, ref := range order {
+			p50[ref] = s.statsOf(ref).p50()
+		}
+		slices.SortStableFunc(order, func(a, b string) int {
+			return cmp.Compare(p50[a], p50[b])
+		})
+		return order
+	default:
+		return alias.Targets.Models()
+	}
+}
+
+// rotate 按别名的轮询计数旋转目标列表
+func (s *RouterService) rotate(alias *model.ModelAlias) []string {
+	models := alias.Targets.Models()
+	if len(models) == 0 {
+		return models
+	}
+
+	s.mu.Lock()
+	start := s.rrCounters[alias.Name] % uint64(len(models))
+	s.rrCounters[alias.Name]++
+	s.mu.Unlock()
+
+	return append(models[start:], models[:start]...)
+}
+
+// weightedOrder 按权重随机排列目标（不放回抽样）
+func weightedOrder(targets model.AliasTargets) []string {
+	remaining := slices.Clone(targets)
+	order := make([]string, 0, len(targets))
+	for len(remaining) > 0 {
+		total := 0
+		for _, t := range remaining {
+			total += t.EffectiveWeight()
+		}
+
+		n := rand.IntN(total)
+		for i, t := range remaining {
+			n -= t.EffectiveWeight()
+			if n < 0 {
+				order = append(order, t.Model)
+				remaining = slices.Delete(remaining, i, i+1)
+				break
+			}
+		}
+	}
+	return order
+}
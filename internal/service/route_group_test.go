@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// newTestAlias 创建测试用的路由组
+func newTestAlias(strategy string, targets ...model.AliasTarget) *model.ModelAlias {
+	return &model.ModelAlias{Name: "chat-default", Strategy: strategy, Targets: targets}
+}
+
+// TestOrderTargets_RoundRobin 测试轮询策略
+func TestOrderTargets_RoundRobin(t *testing.T) {
+	svc := NewRouterService()
+	alias := newTestAlias(model.RouteStrategyRoundRobin,
+		model.AliasTarget{Model: "a/m"}, model.AliasTarget{Model: "b/m"}, model.AliasTarget{Model: "c/m"})
+
+	assert.Equal(t, []string{"a/m", "b/m", "c/m"}, svc.orderTargets(alias))
+	assert.Equal(t, []string{"b/m", "c/m", "a/m"}, svc.orderTargets(alias))
+	assert.Equal(t, []string{"c/m", "a/m", "b/m"}, svc.orderTargets(alias))
+	assert.Equal(t, []string{"a/m", "b/m", "c/m"}, svc.orderTargets(alias))
+}
+
+// TestOrderTargets_Weighted 测试按权重随机
+func TestOrderTargets_Weighted(t *testing.T) {
+	svc := NewRouterService()
+	alias := newTestAlias(model.RouteStrategyWeighted,
+		model.AliasTarget{Model: "a/m", Weight: 9}, model.AliasTarget{Model: "b/m"})
+
+	first := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		order := svc.orderTargets(alias)
+		assert.ElementsMatch(t, []string{"a/m", "b/m"}, order)
+		first[order[0]]++
+	}
+
+	// 期望约 900:100
+	assert.Greater(t, first["a/m"], 800)
+	assert.Greater(t, first["b/m"], 30)
+}
+
+// TestOrderTargets_LeastInFlight 测试处理中请求最少策略
+func TestOrderTargets_LeastInFlight(t *testing.T) {
+	svc := NewRouterService()
+	alias := newTestAlias(model.RouteStrategyLeastInFlight,
+		model.AliasTarget{Model: "a/m"}, model.AliasTarget{Model: "b/m"}, model.AliasTarget{Model: "c/m"})
+
+	endA := svc.BeginRequest("a/m")
+	svc.BeginRequest("a/m")
+	svc.BeginRequest("c/m")
+
+	assert.Equal(t, []string{"b/m", "c/m", "a/m"}, svc.orderTargets(alias))
+
+	// 结束函数只生效一次
+	endA()
+	endA()
+	assert.Equal(t, 1, svc.statsOf("a/m").inFlight)
+}
+
+// TestOrderTargets_LowestLatency 测试延迟中位数最低策略
+func TestOrderTargets_LowestLatency(t *testing.T) {
+	svc := NewRouterService()
+	alias := newTestAlias(model.RouteStrategyLowestLatency,
+		model.AliasTarget{Model: "a/m"}, model.AliasTarget{Model: "b/m"})
+
+	for _, ms := range []int{100, 120, 5000} {
+		svc.RecordLatency("a/m", time.Duration(ms)*time.Millisecond)
+	}
+	for _, ms := range []int{300, 300, 300} {
+		svc.RecordLatency("b/m", time.Duration(ms)*time.Millisecond)
+	}
+
+	// 偶发的慢请求不影响中位数
+	assert.Equal(t, []string{"a/m", "b/m"}, svc.orderTargets(alias))
+	assert.Equal(t, []string{"a/m", "b/m"}, svc.orderTargets(alias))
+
+	// 只保留最近的样本
+	for i := 0; i < latencyWindow; i++ {
+		svc.RecordLatency("a/m", time.Second)
+	}
+	assert.Equal(t, []string{"b/m", "a/m"}, svc.orderTargets(alias))
+}
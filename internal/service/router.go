@@ -1,18 +1,89 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
 
 	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+const (
+	// upstreamModelsTimeout 查询上游模型列表的超时时间
+	upstreamModelsTimeout = 5 * time.Second
+	// upstreamModelsTTL 上游模型列表缓存时间
+	upstreamModelsTTL = time.Minute
 )
 
 // RouterService 模型路由服务
-type RouterService struct{}
+type RouterService struct {
+	mu             sync.Mutex
+	upstreamModels map[string]*upstreamModelsEntry // provider name -> 上游模型缓存
+	aliases        map[string]*model.ModelAlias    // 别名（路由组）
+	rrCounters     map[string]uint64               // 别名 -> 轮询计数
+	stats          map[string]*targetStats         // provider/model_name -> 实时统计
+	breakers       *BreakerRegistry                // 熔断器
+	health         *HealthChecker                  // 主动健康检查，为 nil 时不跳过 Provider
+}
+
+// upstreamModelsEntry 上游模型列表缓存项
+type upstreamModelsEntry struct {
+	provider  adapter.Provider // Provider 重载后实例变化，缓存失效
+	models    []string
+	expiresAt time.Time
+}
 
 // NewRouterService 创建路由服务
 func NewRouterService() *RouterService {
-	return &RouterService{}
+	return &RouterService{
+		upstreamModels: make(map[string]*upstreamModelsEntry),
+		aliases:        make(map[string]*model.ModelAlias),
+		rrCounters:     make(map[string]uint64),
+		stats:          make(map[string]*targetStats),
+		breakers:       NewBreakerRegistry(BreakerConfigFromEnv()),
+	}
+}
+
+// SetHealthChecker 设置健康检查器，路由时跳过不健康的 Provider
+func (s *RouterService) SetHealthChecker(health *HealthChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = health
+}
+
+// SkipUnhealthy 从 provider/model_name 列表中移除健康检查不通过的 Provider 的模型
+// 全部不健康时返回原列表，仍按顺序尝试
+func (s *RouterService) SkipUnhealthy(refs []string) []string {
+	s.mu.Lock()
+	health := s.health
+	s.mu.Unlock()
+	if health == nil {
+		return refs
+	}
+
+	healthy := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		providerName, _, _ := strings.Cut(ref, "/")
+		if health.IsHealthy(providerName) {
+			healthy = append(healthy, ref)
+		}
+	}
+	if len(healthy) == 0 {
+		return refs
+	}
+	return healthy
+}
+
+// Breakers 返回路由目标的熔断器注册表
+func (s *RouterService) Breakers() *BreakerRegistry {
+	return s.breakers
 }
 
 // ModelInfo 模型信息
@@ -20,25 +91,56 @@ type ModelInfo struct {
 	ProviderName string // Provider 名称
 	ModelName    string // 模型名称
 	Provider     adapter.Provider
+	Alias        string   // 通过别名解析时的别名
+	Targets      []string // 通过别名解析时别名的全部目标（provider/model_name，已按路由策略排列）
+
+	aliasHedgeDelay    time.Duration // 别名设置的对冲延迟，见 HedgeDelay
+	aliasSemanticCache bool          // 别名开启了语义缓存，见 SemanticCache.EnabledFor
 }
 
 // ParseModel 解析模型参数 `provider/model_name`
+// 第一个 "/" 之前为 Provider 名称，之后的部分均为模型名称（如 vllm/meta-llama/Llama-3-8B）
 func (s *RouterService) ParseModel(model string) (*ModelInfo, error) {
-	parts := strings.Split(model, "/")
-	if len(parts) != 2 {
+	providerName, modelName, ok := strings.Cut(model, "/")
+	if !ok || providerName == "" || modelName == "" {
 		return nil, &ModelFormatError{Model: model}
 	}
 
-	providerName := parts[0]
-	modelName := parts[1]
+	return &ModelInfo{
+		ProviderName: providerName,
+		ModelName:    modelName,
+	}, nil
+}
 
-	if providerName == "" || modelName == "" {
-		return nil, &ModelFormatError{Model: model}
+// ParseFallbackModel 解析 fallback_models 中的一项，以已配置的 Provider（含未启用的）判断前缀
+// `provider/model_name` 中第一个 "/" 之前为已配置的 Provider 名称时引用该 Provider 的模型（Provider 未运行时由重试服务跳过），
+// 否则整项为同一 Provider 下的模型名称（如 vLLM 的 meta-llama/Llama-3-8B）
+func ParseFallbackModel(entry, providerName string) (*ModelInfo, error) {
+	return parseFallbackModel(entry, providerName, func(name string) bool {
+		if isConfiguredProvider(name) {
+			return true
+		}
+		_, ok := adapter.GetProvider(name)
+		return ok
+	})
+}
+
+// parseFallbackModel 解析 fallback_models 中的一项，isProvider 判断 "/" 之前的部分是否为 Provider 名称
+func parseFallbackModel(entry, providerName string, isProvider func(name string) bool) (*ModelInfo, error) {
+	if entry == "" || strings.HasPrefix(entry, "/") || strings.HasSuffix(entry, "/") {
+		return nil, &ModelFormatError{Model: entry}
 	}
 
+	target, modelName, ok := strings.Cut(entry, "/")
+	if ok && (target == providerName || isProvider(target)) {
+		return &ModelInfo{
+			ProviderName: target,
+			ModelName:    modelName,
+		}, nil
+	}
 	return &ModelInfo{
 		ProviderName: providerName,
-		ModelName:    modelName,
+		ModelName:    entry,
 	}, nil
 }
 
@@ -52,8 +154,41 @@ func (s *RouterService) ResolveProvider(providerName string) (adapter.Provider,
 	return provider, nil
 }
 
+// SetModelAliases 替换全部模型别名
+func (s *RouterService) SetModelAliases(aliases []*model.ModelAlias) {
+	table := make(map[string]*model.ModelAlias, len(aliases))
+	for _, alias := range aliases {
+		table[alias.Name] = alias
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases = table
+}
+
+// lookupAlias 查询别名
+func (s *RouterService) lookupAlias(name string) (*model.ModelAlias, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alias, ok := s.aliases[name]
+	return alias, ok
+}
+
 // ResolveModel 解析模型并获取 Provider
+// 不带 "/" 的模型名按别名解析：按路由策略排列目标，使用第一个 Provider 运行中的目标
 func (s *RouterService) ResolveModel(model string) (*ModelInfo, error) {
+	if !strings.Contains(model, "/") {
+		if alias, ok := s.lookupAlias(model); ok {
+			info, err := s.resolveAlias(model, s.orderTargets(alias))
+			if err != nil {
+				return nil, err
+			}
+			info.aliasHedgeDelay = time.Duration(alias.HedgeDelayMs) * time.Millisecond
+			info.aliasSemanticCache = alias.SemanticCache
+			return info, nil
+		}
+	}
+
 	info, err := s.ParseModel(model)
 	if err != nil {
 		return nil, err
@@ -68,19 +203,206 @@ func (s *RouterService) ResolveModel(model string) (*ModelInfo, error) {
 	return info, nil
 }
 
-// GetAvailableModels 获取所有可用模型列表
-func (s *RouterService) GetAvailableModels() []string {
-	var models []string
+// resolveAlias 按顺序解析别名的目标
+func (s *RouterService) resolveAlias(alias string, targets []string) (*ModelInfo, error) {
+	for _, target := range targets {
+		info, err := s.ResolveModel(target)
+		if err != nil {
+			continue
+		}
+		info.Alias = alias
+		info.Targets = targets
+		return info, nil
+	}
+
+	// 没有可用的目标，按第一个目标报告
+	if len(targets) > 0 {
+		if info, err := s.ParseModel(targets[0]); err == nil {
+			return nil, &ProviderNotFoundError{ProviderName: info.ProviderName}
+		}
+	}
+	return nil, &ModelNotFoundError{Model: alias}
+}
+
+// AvailableModel 可用模型
+type AvailableModel struct {
+	ID           string // provider/model_name
+	ProviderName string
+	ModelName    string
+}
 
+// GetAvailableModels 获取所有运行中 Provider 的可用模型列表（按 ID 排序）
+// 模型来源：Provider 配置的 fallback_models，开启模型发现的 Provider 从上游查询到的模型，
+// 以及至少有一个目标可用的模型别名
+func (s *RouterService) GetAvailableModels(ctx context.Context) []AvailableModel {
 	providers := adapter.ListProviders()
-	for _, provider := range providers {
-		// 格式: provider-name/*
-		models = append(models, fmt.Sprintf("%s/*", provider.Name()))
+
+	results := make([][]string, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider adapter.Provider) {
+			defer wg.Done()
+			results[i] = s.providerModels(ctx, provider)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	models := make([]AvailableModel, 0)
+	for i, provider := range providers {
+		for _, modelName := range results[i] {
+			models = append(models, AvailableModel{
+				ID:           provider.Name() + "/" + modelName,
+				ProviderName: provider.Name(),
+				ModelName:    modelName,
+			})
+		}
 	}
 
+	s.mu.Lock()
+	aliases := make([]*model.ModelAlias, 0, len(s.aliases))
+	for _, alias := range s.aliases {
+		aliases = append(aliases, alias)
+	}
+	s.mu.Unlock()
+
+	// 列出模型不影响路由策略（如轮询计数），按目标的声明顺序解析
+	for _, alias := range aliases {
+		info, err := s.resolveAlias(alias.Name, alias.Targets.Models())
+		if err != nil {
+			continue
+		}
+		models = append(models, AvailableModel{
+			ID:           alias.Name,
+			ProviderName: info.ProviderName,
+			ModelName:    info.ModelName,
+		})
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].ID < models[j].ID
+	})
+
+	return models
+}
+
+// GetAvailableModel 根据 `provider/model_name` 或模型别名获取单个可用模型
+func (s *RouterService) GetAvailableModel(ctx context.Context, id string) (*AvailableModel, error) {
+	// 别名由管理员声明，不要求目标出现在 Provider 的模型列表中
+	if alias, ok := s.lookupAlias(id); ok {
+		info, err := s.resolveAlias(id, alias.Targets.Models())
+		if err != nil {
+			return nil, err
+		}
+		return &AvailableModel{
+			ID:           id,
+			ProviderName: info.ProviderName,
+			ModelName:    info.ModelName,
+		}, nil
+	}
+
+	info, err := s.ResolveModel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, modelName := range s.providerModels(ctx, info.Provider) {
+		if modelName == info.ModelName {
+			return &AvailableModel{
+				ID:           id,
+				ProviderName: info.ProviderName,
+				ModelName:    info.ModelName,
+			}, nil
+		}
+	}
+
+	return nil, &ModelNotFoundError{Model: id}
+}
+
+// providerModels 返回 Provider 的模型列表（配置的模型在前，上游发现的模型去重追加）
+func (s *RouterService) providerModels(ctx context.Context, provider adapter.Provider) []string {
+	models := make([]string, 0)
+	seen := make(map[string]bool)
+
+	if configured, ok := provider.Config()["fallback_models"].([]string); ok {
+		for _, m := range configured {
+			// 引用其他 Provider 的 Fallback 模型不属于当前 Provider
+			info, err := ParseFallbackModel(m, provider.Name())
+			if err != nil || info.ProviderName != provider.Name() {
+				continue
+			}
+			m = info.ModelName
+			if !seen[m] {
+				seen[m] = true
+				models = append(models, m)
+			}
+		}
+	}
+
+	for _, m := range s.UpstreamModels(ctx, provider) {
+		if !seen[m] {
+			seen[m] = true
+			models = append(models, m)
+		}
+	}
+
+	return models
+}
+
+// UpstreamModels 查询上游模型列表（带缓存），未开启模型发现或查询失败时返回 nil
+func (s *RouterService) UpstreamModels(ctx context.Context, provider adapter.Provider) []string {
+	lister, ok := provider.(adapter.ModelLister)
+	if !ok || !ModelDiscoveryEnabled(provider) {
+		return nil
+	}
+
+	name := provider.Name()
+
+	s.mu.Lock()
+	entry, ok := s.upstreamModels[name]
+	s.mu.Unlock()
+	if ok && entry.provider == provider && time.Now().Before(entry.expiresAt) {
+		return entry.models
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, upstreamModelsTimeout)
+	defer cancel()
+
+	models, err := lister.ListModels(listCtx)
+	if err != nil {
+		logger.L.Warn("Failed to list upstream models",
+			zap.String("provider_name", name),
+			zap.Error(err))
+		return nil
+	}
+
+	s.mu.Lock()
+	s.upstreamModels[name] = &upstreamModelsEntry{
+		provider:  provider,
+		models:    models,
+		expiresAt: time.Now().Add(upstreamModelsTTL),
+	}
+	s.mu.Unlock()
+
 	return models
 }
 
+// ModelDiscoveryEnabled 判断 Provider 是否开启上游模型发现
+// 由 extra_config.model_discovery 控制；未配置时本地部署类型（ollama、vllm）默认开启，
+// 其他类型（如 OpenAI 官方服务，上游模型列表包含大量不相关模型）默认关闭
+func ModelDiscoveryEnabled(provider adapter.Provider) bool {
+	if enabled, ok := provider.Config()["model_discovery"].(bool); ok {
+		return enabled
+	}
+
+	switch adapter.AdapterType(provider.Type()) {
+	case adapter.AdapterTypeOllama, adapter.AdapterTypeVLLM:
+		return true
+	default:
+		return false
+	}
+}
+
 // === 错误类型 ===
 
 // ModelFormatError 模型格式错误
@@ -92,6 +414,15 @@ func (e *ModelFormatError) Error() string {
 	return fmt.Sprintf("invalid model format: %s (expected format: provider/model_name)", e.Model)
 }
 
+// ModelNotFoundError 模型不存在错误
+type ModelNotFoundError struct {
+	Model string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("model not found: %s", e.Model)
+}
+
 // ProviderNotFoundError Provider 不存在错误
 type ProviderNotFoundError struct {
 	ProviderName string
@@ -101,6 +432,16 @@ func (e *ProviderNotFoundError) Error() string {
 	return fmt.Sprintf("provider not found: %s", e.ProviderName)
 }
 
+// InvalidFallbackModelError fallback_models 配置错误
+type InvalidFallbackModelError struct {
+	Model  string
+	Reason string
+}
+
+func (e *InvalidFallbackModelError) Error() string {
+	return fmt.Sprintf("invalid fallback model %q: %s", e.Model, e.Reason)
+}
+
 // ProviderDisabledError Provider 未启用错误
 type ProviderDisabledError struct {
 	ProviderName string
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/logger"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// fakeProvider 用于路由测试的 Provider
+type fakeProvider struct {
+	name     string
+	typ      string
+	config   map[string]any
+	upstream []string
+	listErr  error
+	chatErr  error
+	calls    int
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, req *adapter.ChatRequest) (*adapter.ChatResponse, error) {
+	return nil, p.chatErr
+}
+
+func (p *fakeProvider) ChatStream(ctx context.Context, req *adapter.ChatRequest) (<-chan *adapter.ChatStreamChunk, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) Type() string           { return p.typ }
+func (p *fakeProvider) Name() string           { return p.name }
+func (p *fakeProvider) Timeout() int           { return 30 }
+func (p *fakeProvider) Config() map[string]any { return p.config }
+
+// fakeListerProvider 支持上游模型发现的 Provider
+type fakeListerProvider struct {
+	fakeProvider
+}
+
+func (p *fakeListerProvider) ListModels(ctx context.Context) ([]string, error) {
+	p.calls++
+	return p.upstream, p.listErr
+}
+
+func registerTestProviders(t *testing.T, providers ...adapter.Provider) {
+	for _, p := range providers {
+		adapter.RegisterProvider(p)
+	}
+	t.Cleanup(func() {
+		for _, p := range providers {
+			adapter.UnregisterProvider(p.Name())
+		}
+	})
+}
+
+// TestGetAvailableModels 测试配置模型与上游发现模型合并
+func TestGetAvailableModels(t *testing.T) {
+	logger.L = zap.NewNop()
+
+	openaiProvider := &fakeListerProvider{fakeProvider{
+		name:     "openai",
+		typ:      "openai",
+		config:   map[string]any{"fallback_models": []string{"gpt-4o", "gpt-4o-mini", "ollama/llama3.1:8b"}}, // 其他 Provider 的模型不应出现
+		upstream: []string{"dall-e-3"},                                                                       // 未开启模型发现，不应出现
+	}}
+	ollamaProvider := &fakeListerProvider{fakeProvider{
+		name:     "ollama",
+		typ:      "ollama",
+		config:   map[string]any{"fallback_models": []string{"llama3.1:8b"}},
+		upstream: []string{"llama3.1:8b", "qwen2.5:7b"},
+	}}
+	registerTestProviders(t, openaiProvider, ollamaProvider)
+
+	svc := NewRouterService()
+	models := svc.GetAvailableModels(context.Background())
+
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	assert.Equal(t, []string{"ollama/llama3.1:8b", "ollama/qwen2.5:7b", "openai/gpt-4o", "openai/gpt-4o-mini"}, ids)
+	assert.Equal(t, 0, openaiProvider.calls)
+
+	// 上游结果被缓存
+	svc.GetAvailableModels(context.Background())
+	assert.Equal(t, 1, ollamaProvider.calls)
+}
+
+// TestGetAvailableModels_UpstreamError 测试上游查询失败时仅返回配置的模型
+func TestGetAvailableModels_UpstreamError(t *testing.T) {
+	logger.L = zap.NewNop()
+
+	vllmProvider := &fakeListerProvider{fakeProvider{
+		name:    "vllm",
+		typ:     "vllm",
+		config:  map[string]any{"fallback_models": []string{"qwen-72b"}},
+		listErr: errors.New("connection refused"),
+	}}
+	registerTestProviders(t, vllmProvider)
+
+	models := NewRouterService().GetAvailableModels(context.Background())
+	assert.Len(t, models, 1)
+	assert.Equal(t, "vllm/qwen-72b", models[0].ID)
+}
+
+// TestGetAvailableModel 测试查询单个模型
+func TestGetAvailableModel(t *testing.T) {
+	logger.L = zap.NewNop()
+
+	registerTestProviders(t, &fakeListerProvider{fakeProvider{
+		name:     "openai",
+		typ:      "openai",
+		config:   map[string]any{"model_discovery": true},
+		upstream: []string{"gpt-4o"},
+	}})
+
+	svc := NewRouterService()
+
+	m, err := svc.GetAvailableModel(context.Background(), "openai/gpt-4o")
+	assert.NoError(t, err)
+	assert.Equal(t, "openai", m.ProviderName)
+	assert.Equal(t, "gpt-4o", m.ModelName)
+
+	_, err = svc.GetAvailableModel(context.Background(), "openai/gpt-5")
+	assert.IsType(t, &ModelNotFoundError{}, err)
+
+	_, err = svc.GetAvailableModel(context.Background(), "missing/gpt-4o")
+	assert.IsType(t, &ProviderNotFoundError{}, err)
+}
+
+// TestParseFallbackModel 测试 fallback_models 项的解析
+func TestParseFallbackModel(t *testing.T) {
+	registerTestProviders(t,
+		&fakeProvider{name: "azure", typ: "azure"},
+		&fakeProvider{name: "vllm", typ: "vllm"},
+	)
+
+	info, err := ParseFallbackModel("gpt-4o-mini", "openai")
+	assert.NoError(t, err)
+	assert.Equal(t, "openai", info.ProviderName)
+	assert.Equal(t, "gpt-4o-mini", info.ModelName)
+
+	info, err = ParseFallbackModel("azure/gpt-4o", "openai")
+	assert.NoError(t, err)
+	assert.Equal(t, "azure", info.ProviderName)
+	assert.Equal(t, "gpt-4o", info.ModelName)
+
+	// 模型名称包含 "/" 时以第一个 "/" 分隔 Provider
+	info, err = ParseFallbackModel("vllm/meta-llama/Llama-3-8B", "openai")
+	assert.NoError(t, err)
+	assert.Equal(t, "vllm", info.ProviderName)
+	assert.Equal(t, "meta-llama/Llama-3-8B", info.ModelName)
+
+	// 已配置但未运行的 Provider 仍按 Provider 解析，由重试服务跳过
+	setConfiguredProviders([]*model.Provider{{Name: "bedrock"}})
+	t.Cleanup(func() { setConfiguredProviders(nil) })
+	info, err = ParseFallbackModel("bedrock/claude-3-5-sonnet", "openai")
+	assert.NoError(t, err)
+	assert.Equal(t, "bedrock", info.ProviderName)
+	assert.Equal(t, "claude-3-5-sonnet", info.ModelName)
+
+	// "/" 之前不是 Provider 名称时为同一 Provider 下包含 "/" 的模型名称
+	info, err = ParseFallbackModel("meta-llama/Llama-3-8B", "vllm")
+	assert.NoError(t, err)
+	assert.Equal(t, "vllm", info.ProviderName)
+	assert.Equal(t, "meta-llama/Llama-3-8B", info.ModelName)
+
+	info, err = ParseFallbackModel("vllm/meta-llama/Llama-3-8B", "vllm")
+	assert.NoError(t, err)
+	assert.Equal(t, "vllm", info.ProviderName)
+	assert.Equal(t, "meta-llama/Llama-3-8B", info.ModelName)
+
+	for _, entry := range []string{"", "azure/", "/gpt-4o"} {
+		_, err := ParseFallbackModel(entry, "openai")
+		var formatErr *ModelFormatError
+		assert.ErrorAs(t, err, &formatErr, entry)
+	}
+}
+
+// TestResolveModel_Alias 测试别名解析与包含 "/" 的模型名称
+func TestResolveModel_Alias(t *testing.T) {
+	registerTestProviders(t,
+		&fakeProvider{name: "azure", typ: "azure"},
+		&fakeProvider{name: "vllm", typ: "vllm"},
+	)
+
+	svc := NewRouterService()
+	svc.SetModelAliases([]*model.ModelAlias{
+		{Name: "chat-default", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}, {Model: "azure/gpt-4o"}, {Model: "vllm/meta-llama/Llama-3-8B"}}},
+		{Name: "offline", Targets: model.AliasTargets{{Model: "openai/gpt-4o"}}},
+	})
+
+	// 跳过未运行的 Provider，使用第一个可用目标
+	info, err := svc.ResolveModel("chat-default")
+	assert.NoError(t, err)
+	assert.Equal(t, "azure", info.ProviderName)
+	assert.Equal(t, "gpt-4o", info.ModelName)
+	assert.Equal(t, "chat-default", info.Alias)
+	assert.Len(t, info.Targets, 3)
+
+	info, err = svc.ResolveModel("vllm/meta-llama/Llama-3-8B")
+	assert.NoError(t, err)
+	assert.Equal(t, "vllm", info.ProviderName)
+	assert.Equal(t, "meta-llama/Llama-3-8B", info.ModelName)
+	assert.Empty(t, info.Alias)
+
+	var notFoundErr *ProviderNotFoundError
+	_, err = svc.ResolveModel("offline")
+	assert.ErrorAs(t, err, &notFoundErr)
+
+	var formatErr *ModelFormatError
+	_, err = svc.ResolveModel("gpt-4o")
+	assert.ErrorAs(t, err, &formatErr)
+
+	available, err := svc.GetAvailableModel(context.Background(), "chat-default")
+	assert.NoError(t, err)
+	assert.Equal(t, "azure", available.ProviderName)
+}
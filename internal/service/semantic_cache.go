@@ -0,0 +1,452 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+	"github.com/lucheng0127/courier/internal/repository"
+)
+
+const (
+	SemanticCacheBackendMemory   = "memory"   // 进程内向量索引，各副本独立
+	SemanticCacheBackendPgvector = "pgvector" // Postgres pgvector 扩展，多副本共享
+
+	SemanticCacheScopeUser   = "user"    // 按用户隔离
+	SemanticCacheScopeAPIKey = "api_key" // 按 API Key 隔离，JWT 认证的请求按用户隔离
+
+	// semanticCacheTrimEvery pgvector 缓存每写入多少次清理一次过期和超出容量的条目
+	semanticCacheTrimEvery = 100
+)
+
+// SemanticCacheConfig 语义缓存配置
+// 配置了 EmbeddingModel 后，在 Provider 的 extra_config 或模型别名上开启 semantic_cache 的请求使用语义缓存
+type SemanticCacheConfig struct {
+	Backend        string        // memory 或 pgvector
+	EmbeddingModel string        // 计算向量使用的 provider/model_name，Provider 需支持 Embeddings
+	Threshold      float64       // 命中所需的最低余弦相似度
+	Scope          string        // user 或 api_key
+	TTL            time.Duration // 缓存有效期
+	MaxEntries     int           // 最大条目数，超出后淘汰最早写入的条目
+}
+
+// DefaultSemanticCacheConfig 默认语义缓存配置（未配置 EmbeddingModel，即关闭）
+func DefaultSemanticCacheConfig() SemanticCacheConfig {
+	return SemanticCacheConfig{
+		Backend:    SemanticCacheBackendMemory,
+		Threshold:  0.95,
+		Scope:      SemanticCacheScopeUser,
+		TTL:        24 * time.Hour,
+		MaxEntries: 10000,
+	}
+}
+
+// SemanticCacheConfigFromEnv 从环境变量读取语义缓存配置，未设置或无效时使用默认值
+func SemanticCacheConfigFromEnv() SemanticCacheConfig {
+	cfg := DefaultSemanticCacheConfig()
+
+	if v := os.Getenv("SEMANTIC_CACHE_BACKEND"); v != "" {
+		cfg.Backend = v
+	}
+	cfg.EmbeddingModel = os.Getenv("SEMANTIC_CACHE_EMBEDDING_MODEL")
+	if v := os.Getenv("SEMANTIC_CACHE_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f <= 1 {
+			cfg.Threshold = f
+		}
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_SCOPE"); v != "" {
+		cfg.Scope = v
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.TTL = d
+		}
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxEntries = n
+		}
+	}
+
+	return cfg
+}
+
+// SemanticCacheStore 语义缓存的向量存储
+type SemanticCacheStore interface {
+	// Search 在分区中查找余弦相似度最高且不低于 threshold 的响应，未命中时返回 nil
+	Search(ctx context.Context, partition string, embedding []float32, threshold float64) (*model.ChatResponse, float64, error)
+
+	// Add 写入响应
+	Add(ctx context.Context, partition string, embedding []float32, resp *model.ChatResponse) error
+}
+
+// SemanticCache 语义缓存
+// 对最后一条用户消息计算向量，在相同用户（或 API Key）、相同模型、相同上文和参数的分区中按余弦相似度查找缓存的响应
+type SemanticCache struct {
+	cfg   SemanticCacheConfig
+	store SemanticCacheStore
+}
+
+// NewSemanticCache 按配置创建语义缓存，未配置 EmbeddingModel 时返回 nil
+func NewSemanticCache(cfg SemanticCacheConfig, repo repository.SemanticCacheRepository) (*SemanticCache, error) {
+	if cfg.EmbeddingModel == "" {
+		return nil, nil
+	}
+	if _, _, ok := strings.Cut(cfg.EmbeddingModel, "/"); !ok {
+		return nil, fmt.Errorf("semantic cache embedding model must be provider/model_name, got %q", cfg.EmbeddingModel)
+	}
+	switch cfg.Scope {
+	case SemanticCacheScopeUser, SemanticCacheScopeAPIKey:
+	default:
+		return nil, fmt.Errorf("unsupported semantic cache scope %q", cfg.Scope)
+	}
+
+	var store SemanticCacheStore
+	switch cfg.Backend {
+	case SemanticCacheBackendMemory:
+		store = NewMemoryVectorIndex(cfg.TTL, cfg.MaxEntries)
+	case SemanticCacheBackendPgvector:
+		store = NewPgvectorSemanticCache(repo, cfg.TTL, cfg.MaxEntries)
+	default:
+		return nil, fmt.Errorf("unsupported semantic cache backend %q", cfg.Backend)
+	}
+
+	return &SemanticCache{cfg: cfg, store: store}, nil
+}
+
+// NewSemanticCacheWithStore 使用指定的向量存储创建语义缓存
+func NewSemanticCacheWithStore(cfg SemanticCacheConfig, store SemanticCacheStore) *SemanticCache {
+	return &SemanticCache{cfg: cfg, store: store}
+}
+
+// Scope 缓存的隔离范围
+func (c *SemanticCache) Scope() string {
+	return c.cfg.Scope
+}
+
+// EnabledFor 请求是否使用语义缓存：别名设置了 semantic_cache，或首选 Provider 的 extra_config.semantic_cache 为 true
+func (c *SemanticCache) EnabledFor(info *ModelInfo) bool {
+	if c == nil {
+		return false
+	}
+	if info.aliasSemanticCache {
+		return true
+	}
+	enabled, _ := info.Provider.Config()["semantic_cache"].(bool)
+	return enabled
+}
+
+// SemanticQuery 一次请求的语义缓存查询，查询和写入共用同一个向量
+type SemanticQuery struct {
+	partition string
+	embedding []float32
+}
+
+// SemanticEmbeddingCall 计算查询向量时对 Embeddings 模型的一次调用，调用方据此记录使用量
+type SemanticEmbeddingCall struct {
+	Model        string // provider/model_name
+	ProviderName string
+	PromptTokens int
+	TotalTokens  int
+	Latency      time.Duration
+	Err          error // 调用失败时的错误
+}
+
+// Prepare 计算请求的语义缓存分区和最后一条用户消息的向量
+// scope 为调用方的隔离标识（如 user:1、api_key:2）；请求不适用语义缓存时查询为 nil
+// 调用了 Embeddings 模型时（包括调用失败）返回该次调用，未调用时为 nil
+// 只处理非流式、只生成一个候选、最后一条消息为纯文本用户消息的请求
+func (c *SemanticCache) Prepare(ctx context.Context, scope string, req *model.ChatRequest) (*SemanticQuery, *SemanticEmbeddingCall, error) {
+	if req.Stream || (req.N != nil && *req.N != 1) || len(req.Messages) == 0 {
+		return nil, nil, nil
+	}
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "user" || last.Content.Text == "" || len(last.Content.Parts) > 0 {
+		return nil, nil, nil
+	}
+
+	// 分区包含隔离范围、模型、上文和请求参数，只有最后一条用户消息按相似度匹配
+	canonical := *req
+	canonical.Messages = req.Messages[:len(req.Messages)-1]
+	canonical.StreamOptions = nil
+	canonical.User = ""
+	canonical.N = nil
+	data, err := json.Marshal(&canonical)
+	if err != nil {
+		return nil, nil, nil
+	}
+	sum := sha256.Sum256(data)
+
+	embedding, call, err := c.embed(ctx, last.Content.Text)
+	if err != nil {
+		return nil, call, err
+	}
+
+	return &SemanticQuery{
+		partition: scope + ":" + hex.EncodeToString(sum[:]),
+		embedding: embedding,
+	}, call, nil
+}
+
+// Lookup 查询语义缓存，命中时返回缓存的响应和相似度
+func (c *SemanticCache) Lookup(ctx context.Context, q *SemanticQuery) (*model.ChatResponse, float64, error) {
+	return c.store.Search(ctx, q.partition, q.embedding, c.cfg.Threshold)
+}
+
+// Store 写入语义缓存
+func (c *SemanticCache) Store(ctx context.Context, q *SemanticQuery, resp *model.ChatResponse) error {
+	return c.store.Add(ctx, q.partition, q.embedding, resp)
+}
+
+// embed 使用配置的 Embeddings 模型计算文本向量，同时返回该次调用的使用量
+// Provider 未运行或不支持 Embeddings 时未调用上游，返回的调用为 nil
+func (c *SemanticCache) embed(ctx context.Context, text string) ([]float32, *SemanticEmbeddingCall, error) {
+	providerName, modelName, _ := strings.Cut(c.cfg.EmbeddingModel, "/")
+	provider, ok := adapter.GetProvider(providerName)
+	if !ok {
+		return nil, nil, &ProviderNotFoundError{ProviderName: providerName}
+	}
+	embedder, ok := provider.(adapter.EmbeddingProvider)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider %s (type %s) does not support embeddings", providerName, provider.Type())
+	}
+
+	call := &SemanticEmbeddingCall{Model: c.cfg.EmbeddingModel, ProviderName: providerName}
+	start := time.Now()
+	resp, err := embedder.Embed(ctx, &adapter.EmbeddingRequest{Model: modelName, Input: []string{text}})
+	call.Latency = time.Since(start)
+	if err != nil {
+		call.Err = fmt.Errorf("failed to embed message: %w", err)
+		return nil, call, call.Err
+	}
+	call.PromptTokens = resp.Usage.PromptTokens
+	call.TotalTokens = resp.Usage.TotalTokens
+	if len(resp.Data) == 0 || len(resp.Data[0].Embedding) == 0 {
+		call.Err = errors.New("embeddings provider returned no vector")
+		return nil, call, call.Err
+	}
+
+	embedding := make([]float32, len(resp.Data[0].Embedding))
+	for i, v := range resp.Data[0].Embedding {
+		embedding[i] = float32(v)
+	}
+	return embedding, call, nil
+}
+
+// normalize 返回单位向量，零向量返回 nil
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return nil
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// dot 两个向量的点积，维度不同时返回 -1
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return -1
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// vectorEntry 内存向量索引条目
+type vectorEntry struct {
+	partition string
+	embedding []float32 // 单位向量
+	response  []byte    // 序列化后的响应
+	expiresAt time.Time
+	elem      *list.Element
+}
+
+// MemoryVectorIndex 进程内向量索引，在分区内线性扫描查找最相似的条目
+// 每个分区的条目数通常很少，线性扫描即可；超出容量时淘汰最早写入的条目
+type MemoryVectorIndex struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu         sync.Mutex
+	partitions map[string][]*vectorEntry
+	order      *list.List // 写入顺序，最早的在前
+	now        func() time.Time
+}
+
+// NewMemoryVectorIndex 创建进程内向量索引
+func NewMemoryVectorIndex(ttl time.Duration, maxEntries int) *MemoryVectorIndex {
+	return &MemoryVectorIndex{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		partitions: make(map[string][]*vectorEntry),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Search 在分区中查找最相似的响应
+func (x *MemoryVectorIndex) Search(ctx context.Context, partition string, embedding []float32, threshold float64) (*model.ChatResponse, float64, error) {
+	query := normalize(embedding)
+	if query == nil {
+		return nil, 0, nil
+	}
+
+	x.mu.Lock()
+	now := x.now()
+	var best *vectorEntry
+	bestScore := threshold
+	for _, entry := range x.partitions[partition] {
+		if !now.Before(entry.expiresAt) {
+			continue
+		}
+		if score := dot(query, entry.embedding); score >= bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	if best == nil {
+		x.mu.Unlock()
+		return nil, 0, nil
+	}
+	data := best.response
+	x.mu.Unlock()
+
+	var resp model.ChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, bestScore, nil
+}
+
+// Add 写入响应，同时清理分区中过期的条目
+func (x *MemoryVectorIndex) Add(ctx context.Context, partition string, embedding []float32, resp *model.ChatResponse) error {
+	vector := normalize(embedding)
+	if vector == nil {
+		return nil
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := x.now()
+	entries := x.partitions[partition]
+	kept := entries[:0]
+	for _, entry := range entries {
+		if now.Before(entry.expiresAt) {
+			kept = append(kept, entry)
+		} else {
+			x.order.Remove(entry.elem)
+		}
+	}
+
+	entry := &vectorEntry{partition: partition, embedding: vector, response: data, expiresAt: now.Add(x.ttl)}
+	entry.elem = x.order.PushBack(entry)
+	x.partitions[partition] = append(kept, entry)
+
+	for x.order.Len() > x.maxEntries {
+		x.remove(x.order.Front().Value.(*vectorEntry))
+	}
+	return nil
+}
+
+// remove 删除条目，调用方需持有 x.mu
+func (x *MemoryVectorIndex) remove(entry *vectorEntry) {
+	x.order.Remove(entry.elem)
+	entries := x.partitions[entry.partition]
+	for i, e := range entries {
+		if e == entry {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(x.partitions, entry.partition)
+	} else {
+		x.partitions[entry.partition] = entries
+	}
+}
+
+// Len 当前索引的条目数（含未清理的过期条目）
+func (x *MemoryVectorIndex) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.order.Len()
+}
+
+// PgvectorSemanticCache 基于 pgvector 的语义缓存存储，多个网关副本共享
+type PgvectorSemanticCache struct {
+	repo       repository.SemanticCacheRepository
+	ttl        time.Duration
+	maxEntries int
+	writes     atomic.Int64
+}
+
+// NewPgvectorSemanticCache 创建 pgvector 语义缓存存储
+func NewPgvectorSemanticCache(repo repository.SemanticCacheRepository, ttl time.Duration, maxEntries int) *PgvectorSemanticCache {
+	return &PgvectorSemanticCache{repo: repo, ttl: ttl, maxEntries: maxEntries}
+}
+
+// Search 在分区中查找最相似的响应
+func (c *PgvectorSemanticCache) Search(ctx context.Context, partition string, embedding []float32, threshold float64) (*model.ChatResponse, float64, error) {
+	entry, err := c.repo.Nearest(ctx, partition, embedding)
+	if err != nil || entry == nil || entry.Similarity < threshold {
+		return nil, 0, err
+	}
+
+	var resp model.ChatResponse
+	if err := json.Unmarshal(entry.Response, &resp); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, entry.Similarity, nil
+}
+
+// Add 写入响应，每写入 semanticCacheTrimEvery 次清理一次过期和超出容量的条目
+func (c *PgvectorSemanticCache) Add(ctx context.Context, partition string, embedding []float32, resp *model.ChatResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	err = c.repo.Create(ctx, &model.SemanticCacheEntry{
+		Partition: partition,
+		Embedding: embedding,
+		Response:  data,
+		ExpiresAt: time.Now().Add(c.ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.writes.Add(1)%semanticCacheTrimEvery == 0 {
+		if _, err := c.repo.Trim(ctx, c.maxEntries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lucheng0127/courier/internal/adapter"
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// fakeEmbeddingProvider 按输入文本返回固定向量的 Provider
+type fakeEmbeddingProvider struct {
+	fakeProvider
+	vectors map[string][]float64
+}
+
+func (p *fakeEmbeddingProvider) Embed(ctx context.Context, req *adapter.EmbeddingRequest) (*adapter.EmbeddingResponse, error) {
+	return &adapter.EmbeddingResponse{
+		Model: req.Model,
+		Data:  []adapter.Embedding{{Embedding: p.vectors[req.Input[0]]}},
+		Usage: adapter.Usage{PromptTokens: 8, TotalTokens: 8},
+	}, nil
+}
+
+// TestMemoryVectorIndex 测试按分区查找相似度最高的条目、阈值和淘汰
+func TestMemoryVectorIndex(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	index := NewMemoryVectorIndex(time.Minute, 2)
+	index.now = func() time.Time { return now }
+
+	require.NoError(t, index.Add(ctx, "user:1", []float32{1, 0}, &model.ChatResponse{ID: "x"}))
+	require.NoError(t, index.Add(ctx, "user:1", []float32{0, 1}, &model.ChatResponse{ID: "y"}))
+
+	resp, score, err := index.Search(ctx, "user:1", []float32{2, 0.1}, 0.9)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "x", resp.ID)
+	assert.InDelta(t, 0.9988, score, 0.001)
+
+	// 相似度低于阈值、其他分区、维度不同时不命中
+	resp, _, _ = index.Search(ctx, "user:1", []float32{1, 1}, 0.9)
+	assert.Nil(t, resp)
+	resp, _, _ = index.Search(ctx, "user:2", []float32{1, 0}, 0.9)
+	assert.Nil(t, resp)
+	resp, _, _ = index.Search(ctx, "user:1", []float32{1, 0, 0}, 0.9)
+	assert.Nil(t, resp)
+
+	// 超出容量时淘汰最早写入的条目
+	require.NoError(t, index.Add(ctx, "user:2", []float32{1, 0}, &model.ChatResponse{ID: "z"}))
+	assert.Equal(t, 2, index.Len())
+	resp, _, _ = index.Search(ctx, "user:1", []float32{1, 0}, 0.9)
+	assert.Nil(t, resp)
+
+	// 过期后不命中
+	now = now.Add(time.Minute)
+	resp, _, _ = index.Search(ctx, "user:2", []float32{1, 0}, 0.9)
+	assert.Nil(t, resp)
+}
+
+// TestSemanticCache 测试语义缓存按最后一条用户消息的相似度命中，上文和调用方不同时不命中
+func TestSemanticCache(t *testing.T) {
+	registerTestProviders(t, &fakeEmbeddingProvider{
+		fakeProvider: fakeProvider{name: "embedder", config: map[string]any{}},
+		vectors: map[string][]float64{
+			"What is the capital of France?":    {0.9, 0.1, 0},
+			"what's the capital city of France": {0.88, 0.12, 0.01},
+			"How do I bake bread?":              {0, 0.2, 0.9},
+		},
+	})
+	cfg := DefaultSemanticCacheConfig()
+	cfg.EmbeddingModel = "embedder/text-embedding-3-small"
+	cache, err := NewSemanticCache(cfg, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	newReq := func(text string) *model.ChatRequest {
+		return &model.ChatRequest{
+			Model:    "openai/gpt-4o",
+			Messages: []model.ChatMessage{{Role: "user", Content: model.TextContent(text)}},
+		}
+	}
+
+	q, call, err := cache.Prepare(ctx, "user:1", newReq("What is the capital of France?"))
+	require.NoError(t, err)
+	require.NotNil(t, q)
+	// 返回 Embeddings 调用，供调用方记录使用量
+	require.NotNil(t, call)
+	assert.Equal(t, "embedder/text-embedding-3-small", call.Model)
+	assert.Equal(t, "embedder", call.ProviderName)
+	assert.Equal(t, 8, call.TotalTokens)
+	assert.NoError(t, call.Err)
+	require.NoError(t, cache.Store(ctx, q, &model.ChatResponse{ID: "paris"}))
+
+	q, _, _ = cache.Prepare(ctx, "user:1", newReq("what's the capital city of France"))
+	resp, similarity, err := cache.Lookup(ctx, q)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "paris", resp.ID)
+	assert.GreaterOrEqual(t, similarity, cfg.Threshold)
+
+	// 不相似的问题
+	q, _, _ = cache.Prepare(ctx, "user:1", newReq("How do I bake bread?"))
+	resp, _, _ = cache.Lookup(ctx, q)
+	assert.Nil(t, resp)
+
+	// 其他用户
+	q, _, _ = cache.Prepare(ctx, "user:2", newReq("What is the capital of France?"))
+	resp, _, _ = cache.Lookup(ctx, q)
+	assert.Nil(t, resp)
+
+	// 上文不同
+	req := newReq("What is the capital of France?")
+	req.Messages = append([]model.ChatMessage{{Role: "system", Content: model.TextContent("Answer in German.")}}, req.Messages...)
+	q, _, _ = cache.Prepare(ctx, "user:1", req)
+	resp, _, _ = cache.Lookup(ctx, q)
+	assert.Nil(t, resp)
+
+	// 流式请求不适用
+	req = newReq("What is the capital of France?")
+	req.Stream = true
+	q, call, err = cache.Prepare(ctx, "user:1", req)
+	require.NoError(t, err)
+	assert.Nil(t, q)
+	assert.Nil(t, call)
+}
+
+// TestSemanticCache_EnabledFor 测试按别名或 Provider 开启语义缓存
+func TestSemanticCache_EnabledFor(t *testing.T) {
+	registerTestProviders(t,
+		&fakeProvider{name: "openai", config: map[string]any{"semantic_cache": true}},
+		&fakeProvider{name: "azure", config: map[string]any{}},
+	)
+	router := NewRouterService()
+	router.SetModelAliases([]*model.ModelAlias{
+		{Name: "faq", Targets: model.AliasTargets{{Model: "azure/gpt-4o"}}, SemanticCache: true},
+	})
+	cache := NewSemanticCacheWithStore(DefaultSemanticCacheConfig(), NewMemoryVectorIndex(time.Minute, 10))
+
+	for model, want := range map[string]bool{"openai/gpt-4o": true, "azure/gpt-4o": false, "faq": true} {
+		info, err := router.ResolveModel(model)
+		require.NoError(t, err)
+		assert.Equal(t, want, cache.EnabledFor(info), model)
+	}
+
+	var disabled *SemanticCache
+	info, _ := router.ResolveModel("openai/gpt-4o")
+	assert.False(t, disabled.EnabledFor(info))
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"unicode"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// 消息格式的固定开销（OpenAI 每条消息约 3-4 个 Token，回复起始约 3 个 Token）
+const (
+	tokensPerMessage = 4
+	tokensPerReply   = 3
+)
+
+// EstimateTokens 粗略估算文本的 Token 数
+// 上游未返回使用量时用于计费兜底：CJK 字符按每字 1 个 Token，其他字符按每 4 个字符 1 个 Token
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	cjk := 0
+	other := 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+
+	return cjk + (other+3)/4
+}
+
+// EstimatePromptTokens 估算请求消息的 Token 数
+// 图片、音频片段无法可靠估算，仅计入文本部分
+func EstimatePromptTokens(messages []model.ChatMessage) int {
+	tokens := tokensPerReply
+	for _, msg := range messages {
+		tokens += tokensPerMessage + EstimateTokens(msg.Content.Text) + EstimateTokens(msg.Name)
+		for _, call := range msg.ToolCalls {
+			tokens += EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+		}
+	}
+	return tokens
+}
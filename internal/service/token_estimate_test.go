@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lucheng0127/courier/internal/model"
+)
+
+// TestEstimateTokens 测试文本 Token 估算
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("Hi"))
+	assert.Equal(t, 3, EstimateTokens("Hello world!"))
+	assert.Equal(t, 4, EstimateTokens("你好世界"))
+	assert.Equal(t, 3, EstimateTokens("你好 ok"))
+}
+
+// TestEstimatePromptTokens 测试请求消息 Token 估算
+func TestEstimatePromptTokens(t *testing.T) {
+	messages := []model.ChatMessage{
+		{Role: "system", Content: model.TextContent("Be brief.")},
+		{Role: "user", Content: model.TextContent("你好")},
+	}
+
+	// 3（回复） + 2 * 4（消息） + 3（Be brief.） + 2（你好）
+	assert.Equal(t, 16, EstimatePromptTokens(messages))
+}
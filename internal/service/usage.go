@@ -144,6 +144,7 @@ func (s *UsageService) GetUsageStats(ctx context.Context, req *model.UsageStatsR
 			TotalPromptTokens:     summary.TotalPromptTokens,
 			TotalCompletionTokens: summary.TotalCompletionTokens,
 			AverageLatencyMs:      summary.AverageLatencyMs,
+			CacheHits:             summary.CacheHits,
 		},
 	}
 